@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ssh-messer/internal/config_loader"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// 非交互环境中使用的口令环境变量
+const bundlePassphraseEnv = "MESSER_BUNDLE_PASSPHRASE"
+
+// runExport 将配置打包为加密文件
+func runExport(args []string) error {
+	flags := newFlagSet("export")
+	output := flags.String("o", "", "output file (default <config>.messer, \"-\" for stdout)")
+	includeKeys := flags.Bool("include-keys", false, "include referenced private keys and TLS files")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("at least one config is required")
+	}
+
+	configs, err := config_loader.LoadTomlConfigs()
+	if err != nil {
+		return err
+	}
+	var selected []*config_loader.TomlConfig
+	for _, name := range flags.Args() {
+		config, err := findConfig(configs, name)
+		if err != nil {
+			return err
+		}
+		if !config.IsValid() {
+			fmt.Fprintf(os.Stderr, "Warning: %s has %d problem(s), exporting anyway\n", name, len(config.Errors))
+		}
+		selected = append(selected, config)
+	}
+
+	bundle, err := config_loader.NewBundle(selected, *includeKeys)
+	if err != nil {
+		return err
+	}
+	passphrase, err := readPassphrase("Bundle passphrase: ", true)
+	if err != nil {
+		return err
+	}
+	data, err := bundle.Encrypt(passphrase)
+	if err != nil {
+		return err
+	}
+
+	path := *output
+	if path == "" {
+		path = "ssh-messer-configs" + config_loader.BundleExtension
+		if len(selected) == 1 {
+			path = strings.TrimSuffix(filepath.Base(selected[0].Path), ".toml") + config_loader.BundleExtension
+		}
+	}
+	if path == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d file(s) and %d key(s) to %s\n", len(bundle.Files), len(bundle.Keys), path)
+	if !*includeKeys {
+		fmt.Fprintln(os.Stderr, "Key files are not included, use --include-keys to share them.")
+	}
+	return nil
+}
+
+// runImport 解密、校验并安装加密包中的配置
+func runImport(args []string) error {
+	flags := newFlagSet("import")
+	force := flags.Bool("force", false, "install even if configs have problems")
+	overwrite := flags.Bool("overwrite", false, "replace existing files with the same name")
+	allowReferences := flags.Bool("allow-references", false, "install configs with cmd:/file: references that run commands or read files on connect")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("exactly one bundle file is required")
+	}
+
+	var data []byte
+	var err error
+	if flags.Arg(0) == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(flags.Arg(0))
+	}
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := readPassphrase("Bundle passphrase: ", false)
+	if err != nil {
+		return err
+	}
+	bundle, err := config_loader.DecryptBundle(data, passphrase)
+	if err != nil {
+		return err
+	}
+	dir, err := config_loader.PrimaryConfigDir()
+	if err != nil {
+		return err
+	}
+	plan, err := bundle.Plan(dir)
+	if err != nil {
+		return err
+	}
+
+	invalid := 0
+	for _, item := range plan.Items {
+		status := "new"
+		if item.Exists {
+			status = "exists"
+		}
+		if item.Dependency {
+			status += ", dependency"
+		}
+		fmt.Fprintf(os.Stderr, "  %s (%s)\n", item.Name, status)
+		for _, validationErr := range item.Errors {
+			fmt.Fprintf(os.Stderr, "    ⚠️  %v\n", validationErr)
+		}
+		for _, reference := range item.References {
+			fmt.Fprintf(os.Stderr, "    ❗ %s\n", reference)
+		}
+		if len(item.Errors) > 0 {
+			invalid++
+		}
+	}
+	if invalid > 0 && !*force {
+		return fmt.Errorf("%d config(s) have problems in this environment, re-run with --force to install anyway", invalid)
+	}
+	if conflicts := plan.Conflicts(); len(conflicts) > 0 && !*overwrite {
+		return fmt.Errorf("%s already exist(s) with different content, re-run with --overwrite to replace", strings.Join(conflicts, ", "))
+	}
+	if count := plan.References(); count > 0 && !*allowReferences {
+		return fmt.Errorf("%d cmd:/file: reference(s) above run commands or read files when connecting, review them and re-run with --allow-references", count)
+	}
+	if err := plan.Install(*overwrite, *allowReferences); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Installed %d file(s) and %d key(s) into %s\n", len(bundle.Files), len(bundle.Keys), dir)
+	return nil
+}
+
+// findConfig 按文件名（可省略 .toml）或配置中的 name 查找配置
+func findConfig(configs map[string]*config_loader.TomlConfig, name string) (*config_loader.TomlConfig, error) {
+	for _, candidate := range []string{name, name + ".toml"} {
+		if config, exists := configs[candidate]; exists {
+			return config, nil
+		}
+	}
+	for _, config := range configs {
+		if config.Name != nil && *config.Name == name {
+			return config, nil
+		}
+	}
+	return nil, fmt.Errorf("config %q not found", name)
+}
+
+// readPassphrase 读取口令：优先使用环境变量，否则在终端中不回显地输入
+func readPassphrase(prompt string, confirm bool) ([]byte, error) {
+	if value := os.Getenv(bundlePassphraseEnv); value != "" {
+		return []byte(value), nil
+	}
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		// 非终端时从标准输入读取一行（import 的包文件不能同时来自标准输入）
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return nil, fmt.Errorf("no passphrase given, set %s", bundlePassphraseEnv)
+		}
+		return []byte(strings.TrimRight(line, "\r\n")), nil
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "Confirm passphrase: ")
+		again, err := term.ReadPassword(os.Stdin.Fd())
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		if string(again) != string(passphrase) {
+			return nil, fmt.Errorf("passphrases do not match")
+		}
+	}
+	return passphrase, nil
+}
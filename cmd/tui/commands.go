@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// subcommand 命令行子命令，未指定子命令时启动 TUI
+type subcommand struct {
+	args    string
+	summary string
+	run     func(args []string) error
+}
+
+var subcommands = map[string]subcommand{
+	"export":  {args: "[-o file] [--include-keys] <config>...", summary: "pack configs into an encrypted bundle", run: runExport},
+	"import":  {args: "[--force] [--overwrite] [--allow-references] <file>", summary: "install configs from an encrypted bundle", run: runImport},
+	"migrate": {args: "[--dry-run]", summary: "upgrade configs to the current format, keeping backups", run: runMigrate},
+	"schema":  {args: "[-o file] [--hops] [--install]", summary: "write the JSON Schema of the config files", run: runSchema},
+}
+
+// runSubcommand 执行子命令，返回进程退出码
+func runSubcommand(name string, args []string) int {
+	command, exists := subcommands[name]
+	if !exists {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		flag.Usage()
+		return 2
+	}
+	if err := command.run(args); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		return 1
+	}
+	return 0
+}
+
+// usage 打印全局参数与子命令
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [--config-dir dir] [command]\n\n", os.Args[0])
+	fmt.Fprintln(out, "Without a command the TUI is started.")
+	fmt.Fprintln(out, "\nCommands:")
+	names := make([]string, 0, len(subcommands))
+	for name := range subcommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	width := 0
+	for _, name := range names {
+		width = max(width, len(name)+1+len(subcommands[name].args))
+	}
+	for _, name := range names {
+		command := subcommands[name]
+		fmt.Fprintf(out, "  %-*s  %s\n", width, name+" "+command.args, command.summary)
+	}
+	fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+}
+
+// newFlagSet 创建子命令的参数集合，-h 时打印子命令用法
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s %s [flags] [args]\n", os.Args[0], name)
+		flags.PrintDefaults()
+	}
+	return flags
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
 
+	"ssh-messer/internal/config_loader"
+	"ssh-messer/internal/secrets"
 	"ssh-messer/internal/tui"
 	"ssh-messer/pkg"
 
@@ -11,8 +14,25 @@ import (
 )
 
 func main() {
+	var configDir = flag.String("config-dir", "", "配置目录（默认 MESSER_CONFIG_DIR、$XDG_CONFIG_HOME/ssh_messer 与 ~/.ssh_messer）")
+	flag.Usage = usage
+	flag.Parse()
+
 	pkg.InitLogger("file")
 
+	if *configDir != "" {
+		config_loader.SetConfigDir(*configDir)
+	}
+	// 文件钥匙串与配置放在同一目录
+	if dir, err := config_loader.PrimaryConfigDir(); err == nil {
+		secrets.SetFileKeyringDir(dir)
+	}
+
+	// 子命令（export / import 等）执行后直接退出
+	if flag.NArg() > 0 {
+		os.Exit(runSubcommand(flag.Arg(0), flag.Args()[1:]))
+	}
+
 	model := tui.New()
 	p := tea.NewProgram(model)
 
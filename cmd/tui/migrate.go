@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"ssh-messer/internal/config_loader"
+)
+
+// runMigrate 将旧版本的配置文件改写为当前版本，原文件保留为备份
+func runMigrate(args []string) error {
+	flags := newFlagSet("migrate")
+	dryRun := flags.Bool("dry-run", false, "only list the files that would be migrated")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return fmt.Errorf("migrate takes no arguments")
+	}
+
+	results := config_loader.MigrateConfigFiles(*dryRun)
+	failed := 0
+	for _, result := range results {
+		switch {
+		case result.Err != nil:
+			failed++
+			fmt.Fprintf(os.Stderr, "  %s: %v\n", result.Path, result.Err)
+		case *dryRun:
+			fmt.Fprintf(os.Stderr, "  %s (v%d → v%d)\n", result.Path, result.From, config_loader.CurrentConfigVersion)
+		default:
+			fmt.Fprintf(os.Stderr, "  %s (v%d → v%d, backup %s)\n", result.Path, result.From, config_loader.CurrentConfigVersion, result.Backup)
+		}
+	}
+
+	migrated := len(results) - failed
+	switch {
+	case migrated == 0 && failed == 0:
+		fmt.Fprintf(os.Stderr, "All configs already use version %d.\n", config_loader.CurrentConfigVersion)
+	case *dryRun:
+		fmt.Fprintf(os.Stderr, "%d file(s) would be migrated, run without --dry-run to rewrite them.\n", migrated)
+	default:
+		fmt.Fprintf(os.Stderr, "Migrated %d file(s) to version %d.\n", migrated, config_loader.CurrentConfigVersion)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d file(s) could not be migrated", failed)
+	}
+	return nil
+}
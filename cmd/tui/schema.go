@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"ssh-messer/internal/config_loader"
+)
+
+// runSchema 输出配置文件的 JSON Schema，--install 时写入配置目录并生成 .taplo.toml
+func runSchema(args []string) error {
+	flags := newFlagSet("schema")
+	output := flags.String("o", "", "output file (default stdout)")
+	hops := flags.Bool("hops", false, "generate the schema of "+config_loader.HopLibraryFileName)
+	install := flags.Bool("install", false, "write both schemas and a Taplo config into the config directory")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return fmt.Errorf("schema takes no arguments")
+	}
+
+	if *install {
+		dir, err := config_loader.PrimaryConfigDir()
+		if err != nil {
+			return err
+		}
+		written, err := config_loader.InstallSchemas(dir)
+		for _, path := range written {
+			fmt.Fprintf(os.Stderr, "  %s\n", path)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Open %s in an editor with Taplo or Even Better TOML for completion and validation.\n", dir)
+		fmt.Fprintf(os.Stderr, "Single files can also point to the schema with a first line like: #:schema %s\n", dir+string(os.PathSeparator)+config_loader.ConfigSchemaFileName)
+		return nil
+	}
+
+	generate := config_loader.ConfigSchema
+	if *hops {
+		generate = config_loader.HopLibrarySchema
+	}
+	schema, err := generate()
+	if err != nil {
+		return err
+	}
+	if *output == "" || *output == "-" {
+		_, err := os.Stdout.Write(schema)
+		return err
+	}
+	if err := os.WriteFile(*output, schema, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s\n", *output)
+	return nil
+}
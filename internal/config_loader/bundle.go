@@ -0,0 +1,517 @@
+package config_loader
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"ssh-messer/internal/secrets"
+	"ssh-messer/pkg"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Config 加密分享包
+// ------------------------------------------------------------
+const (
+	BundleExtension = ".messer"
+	// bundleKeysFolder 导入的密钥文件所在目录（隐藏目录，不会被当作配置分组）
+	bundleKeysFolder = ".keys"
+
+	bundleMagic      = "MSRB"
+	bundleVersion    = 1
+	bundleScryptLogN = 15 // scrypt N = 2^15
+	bundleMaxLogN    = 20 // 解密时接受的最大 N，避免恶意文件消耗过多内存
+	bundleSaltSize   = 16
+	bundleArmorBegin = "-----BEGIN SSH MESSER BUNDLE-----"
+	bundleArmorEnd   = "-----END SSH MESSER BUNDLE-----"
+)
+
+// ErrBundlePassphrase 口令错误或包已损坏
+var ErrBundlePassphrase = errors.New("wrong passphrase or corrupted bundle")
+
+// BundleFile 包中的配置文件，Name 为相对配置目录的路径（如 "team/staging.toml"、"hops.toml"）
+type BundleFile struct {
+	Name    string `json:"name"`
+	Content []byte `json:"content"`
+	// 依赖文件（extends 的基础配置、hops.toml），不作为独立配置展示
+	Dependency bool `json:"dependency,omitempty"`
+}
+
+// BundleKey 包中的密钥文件，Ref 为配置中引用它的原始路径
+type BundleKey struct {
+	Ref     string `json:"ref"`
+	Name    string `json:"name"`
+	Content []byte `json:"content"`
+}
+
+// Bundle 一个或多个配置（保留原始文本与注释）及可选的密钥文件
+// keyring / env 等引用不会被解析，接收方在连接时按自己的环境解析
+type Bundle struct {
+	Version   int          `json:"version"`
+	CreatedAt time.Time    `json:"created_at"`
+	Files     []BundleFile `json:"files"`
+	Keys      []BundleKey  `json:"keys,omitempty"`
+}
+
+// NewBundle 打包配置及其依赖（extends 链与 hops.toml），includeKeys 为 true 时同时打包引用的密钥文件
+func NewBundle(configs []*TomlConfig, includeKeys bool) (*Bundle, error) {
+	bundle := &Bundle{Version: bundleVersion, CreatedAt: time.Now().UTC()}
+	added := make(map[string]bool)
+	add := func(name, path string, dependency bool) error {
+		if added[name] {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", displayPath(path), err)
+		}
+		added[name] = true
+		bundle.Files = append(bundle.Files, BundleFile{Name: name, Content: content, Dependency: dependency})
+		return nil
+	}
+
+	for _, config := range configs {
+		rootDir := config.rootDir
+		if rootDir == "" {
+			rootDir = filepath.Dir(config.Path)
+		}
+		name, err := filepath.Rel(rootDir, config.Path)
+		if err != nil {
+			return nil, err
+		}
+		if name == ProjectConfigName {
+			// 项目配置以所在目录命名，导入后作为普通配置
+			name = filepath.Base(rootDir) + ".toml"
+		}
+		if err := add(filepath.ToSlash(name), config.Path, false); err != nil {
+			return nil, err
+		}
+		if err := bundle.addDependencies(config.Path, rootDir, add); err != nil {
+			return nil, err
+		}
+	}
+
+	if includeKeys {
+		if err := bundle.addKeys(); err != nil {
+			return nil, err
+		}
+	}
+	pkg.Logger.Info().Int("files", len(bundle.Files)).Int("keys", len(bundle.Keys)).Msg("[Bundle] 打包配置完成")
+	return bundle, nil
+}
+
+// addDependencies 添加 extends 链中的基础配置与命名 hop 使用的 hops.toml
+func (b *Bundle) addDependencies(path, rootDir string, add func(name, path string, dependency bool) error) error {
+	visited := map[string]bool{path: true}
+	for {
+		var raw TomlConfig
+		if err := decodeConfigFile(path, &raw); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", displayPath(path), err)
+		}
+		if len(raw.Hops) > 0 {
+			if library := findHopLibrary(filepath.Dir(path), rootDir); library != "" {
+				if err := add(relativeName(rootDir, library), library, true); err != nil {
+					return err
+				}
+			}
+		}
+		if raw.Extends == nil || *raw.Extends == "" {
+			return nil
+		}
+		base := *raw.Extends
+		if filepath.Ext(base) == "" {
+			base += ".toml"
+		}
+		path = filepath.Join(filepath.Dir(path), base)
+		if visited[path] {
+			return fmt.Errorf("circular extends via %s", base)
+		}
+		visited[path] = true
+		if err := add(relativeName(rootDir, path), path, true); err != nil {
+			return err
+		}
+	}
+}
+
+// addKeys 添加文件中引用的私钥与 TLS 证书，引用（keyring:、env: 等）不会被打包
+func (b *Bundle) addKeys() error {
+	names := make(map[string]bool)
+	for _, file := range b.Files {
+		for _, ref := range referencedKeyFiles(file.Content) {
+			if b.hasKey(ref) {
+				continue
+			}
+			path, err := pkg.ExpandHome(ref)
+			if err != nil {
+				return fmt.Errorf("%s: failed to read key file %s: %w", file.Name, ref, err)
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("%s: failed to read key file %s: %w", file.Name, ref, err)
+			}
+			name := uniqueName(filepath.Base(ref), names)
+			names[name] = true
+			b.Keys = append(b.Keys, BundleKey{Ref: ref, Name: name, Content: content})
+		}
+	}
+	return nil
+}
+
+func (b *Bundle) hasKey(ref string) bool {
+	for _, key := range b.Keys {
+		if key.Ref == ref {
+			return true
+		}
+	}
+	return false
+}
+
+// referencedKeyFiles 返回配置或 hops.toml 中引用的密钥文件路径
+func referencedKeyFiles(content []byte) []string {
+	var raw TomlConfig
+	var library hopLibrary
+	decodeConfig(content, &raw)
+	decodeConfig(content, &library)
+
+	var refs []string
+	addRef := func(value *string) {
+		if value != nil && *value != "" && !secrets.IsReference(*value) && !containsString(refs, *value) {
+			refs = append(refs, *value)
+		}
+	}
+	for _, hop := range raw.SSHHops {
+		addRef(hop.PrivateKeyPath)
+	}
+	for _, hop := range raw.HopOverrides {
+		addRef(hop.PrivateKeyPath)
+	}
+	for _, hop := range library.Hops {
+		addRef(hop.PrivateKeyPath)
+	}
+	for _, service := range raw.SSHServices {
+		addRef(service.CAFile)
+		addRef(service.ClientCert)
+		addRef(service.ClientKey)
+	}
+	return refs
+}
+
+// Encrypt 使用口令加密（scrypt 派生密钥，XChaCha20-Poly1305），输出可以直接粘贴的文本
+func (b *Bundle) Encrypt(passphrase []byte) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("passphrase must not be empty")
+	}
+	plaintext, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, len(bundleMagic)+2+bundleSaltSize+chacha20poly1305.NonceSizeX)
+	header = append(header, bundleMagic...)
+	header = append(header, bundleVersion, bundleScryptLogN)
+	salt := make([]byte, bundleSaltSize)
+	nonce := make([]byte, chacha20poly1305.NonceSizeX)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	header = append(append(header, salt...), nonce...)
+
+	aead, err := bundleCipher(passphrase, salt, bundleScryptLogN)
+	if err != nil {
+		return nil, err
+	}
+	sealed := aead.Seal(header, nonce, plaintext, header)
+
+	var armored bytes.Buffer
+	armored.WriteString(bundleArmorBegin + "\n")
+	encoded := base64.StdEncoding.EncodeToString(sealed)
+	for len(encoded) > 64 {
+		armored.WriteString(encoded[:64] + "\n")
+		encoded = encoded[64:]
+	}
+	armored.WriteString(encoded + "\n" + bundleArmorEnd + "\n")
+	return armored.Bytes(), nil
+}
+
+// DecryptBundle 解密 Encrypt 生成的文本（也接受去掉首尾标记的 base64）
+func DecryptBundle(data, passphrase []byte) (*Bundle, error) {
+	text := strings.TrimSpace(string(data))
+	text = strings.TrimPrefix(text, bundleArmorBegin)
+	text = strings.TrimSuffix(text, bundleArmorEnd)
+	sealed, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+	if err != nil {
+		return nil, fmt.Errorf("not an ssh-messer bundle: %w", err)
+	}
+
+	headerSize := len(bundleMagic) + 2 + bundleSaltSize + chacha20poly1305.NonceSizeX
+	if len(sealed) < headerSize || string(sealed[:len(bundleMagic)]) != bundleMagic {
+		return nil, fmt.Errorf("not an ssh-messer bundle")
+	}
+	version, logN := sealed[len(bundleMagic)], sealed[len(bundleMagic)+1]
+	if version != bundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", version)
+	}
+	if logN > bundleMaxLogN {
+		return nil, fmt.Errorf("unsupported bundle scrypt cost 2^%d", logN)
+	}
+	header := sealed[:headerSize]
+	saltStart := len(bundleMagic) + 2
+	salt := header[saltStart : saltStart+bundleSaltSize]
+	nonce := header[saltStart+bundleSaltSize:]
+
+	aead, err := bundleCipher(passphrase, salt, logN)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, nonce, sealed[headerSize:], header)
+	if err != nil {
+		return nil, ErrBundlePassphrase
+	}
+
+	var bundle Bundle
+	if err := json.Unmarshal(plaintext, &bundle); err != nil {
+		return nil, fmt.Errorf("invalid bundle content: %w", err)
+	}
+	for _, file := range bundle.Files {
+		if !filepath.IsLocal(filepath.FromSlash(file.Name)) || filepath.Ext(file.Name) != ".toml" {
+			return nil, fmt.Errorf("invalid file name %q in bundle", file.Name)
+		}
+	}
+	for _, key := range bundle.Keys {
+		if key.Name != filepath.Base(key.Name) || !filepath.IsLocal(key.Name) {
+			return nil, fmt.Errorf("invalid key name %q in bundle", key.Name)
+		}
+	}
+	return &bundle, nil
+}
+
+func bundleCipher(passphrase, salt []byte, logN byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, 1<<logN, 8, 1, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.NewX(key)
+}
+
+// ImportItem 导入时的单个配置文件
+type ImportItem struct {
+	Name       string
+	Path       string // 安装后的完整路径
+	Dependency bool
+	Exists     bool             // 目标文件已存在且内容不同
+	Errors     ValidationErrors // 在接收方环境中的校验结果
+	References []string         // 连接时会执行命令或读取本地文件的 cmd: / file: 引用（"字段 = 值"）
+}
+
+// ImportPlan 预览导入结果，确认后调用 Install 写入配置目录
+type ImportPlan struct {
+	Dir   string
+	Items []ImportItem
+
+	bundle   *Bundle
+	keyPaths map[string]string // 原始引用 -> 安装后的路径
+}
+
+// Plan 计算安装位置并在临时目录中校验所有配置
+func (b *Bundle) Plan(dir string) (*ImportPlan, error) {
+	plan := &ImportPlan{Dir: dir, bundle: b, keyPaths: make(map[string]string)}
+	for _, key := range b.Keys {
+		plan.keyPaths[key.Ref] = filepath.Join(dir, bundleKeysFolder, key.Name)
+	}
+
+	staging, err := os.MkdirTemp("", "ssh-messer-import-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(staging)
+
+	// 在临时目录中使用临时的密钥路径校验，避免在确认前写入配置目录
+	stagedKeys := make(map[string]string)
+	for _, key := range b.Keys {
+		path := filepath.Join(staging, bundleKeysFolder, key.Name)
+		if err := writeFileAtomic(path, key.Content, 0600); err != nil {
+			return nil, err
+		}
+		stagedKeys[key.Ref] = path
+	}
+	for _, file := range b.Files {
+		path := filepath.Join(staging, filepath.FromSlash(file.Name))
+		if err := writeFileAtomic(path, rewriteKeyPaths(file.Content, stagedKeys), 0600); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, file := range b.Files {
+		item := ImportItem{
+			Name:       file.Name,
+			Path:       filepath.Join(dir, filepath.FromSlash(file.Name)),
+			Dependency: file.Dependency,
+			References: externalReferences(file.Content),
+		}
+		// 内容相同的文件（如同一份 hops.toml）不算冲突
+		if existing, err := os.ReadFile(item.Path); err == nil && !bytes.Equal(existing, rewriteKeyPaths(file.Content, plan.keyPaths)) {
+			item.Exists = true
+		}
+		if !file.Dependency {
+			stagedPath := filepath.Join(staging, filepath.FromSlash(file.Name))
+			if _, err := loadConfigFile(stagedPath, staging); err != nil {
+				var errs ValidationErrors
+				if !errors.As(err, &errs) {
+					errs = decodeError(stagedPath, err)
+				}
+				item.Errors = errs
+			}
+		}
+		plan.Items = append(plan.Items, item)
+	}
+	return plan, nil
+}
+
+// Conflicts 返回目标位置已存在的文件
+func (p *ImportPlan) Conflicts() []string {
+	var names []string
+	for _, item := range p.Items {
+		if item.Exists {
+			names = append(names, item.Name)
+		}
+	}
+	return names
+}
+
+// References 返回所有文件中的 cmd: / file: 引用数量
+func (p *ImportPlan) References() int {
+	count := 0
+	for _, item := range p.Items {
+		count += len(item.References)
+	}
+	return count
+}
+
+// Install 将配置与密钥写入配置目录，overwrite 为 false 时存在冲突则不写入任何文件
+// 包含 cmd: / file: 引用时必须由用户确认（allowReferences），否则不写入任何文件
+func (p *ImportPlan) Install(overwrite, allowReferences bool) error {
+	if conflicts := p.Conflicts(); len(conflicts) > 0 && !overwrite {
+		return fmt.Errorf("already exists in %s: %s", displayPath(p.Dir), strings.Join(conflicts, ", "))
+	}
+	if count := p.References(); count > 0 && !allowReferences {
+		return fmt.Errorf("bundle contains %d cmd:/file: reference(s) that run commands or read files on connect, review and confirm them first", count)
+	}
+
+	for _, key := range p.bundle.Keys {
+		if err := writeFileAtomic(p.keyPaths[key.Ref], key.Content, 0600); err != nil {
+			return err
+		}
+	}
+	for i, file := range p.bundle.Files {
+		if err := writeFileAtomic(p.Items[i].Path, rewriteKeyPaths(file.Content, p.keyPaths), 0600); err != nil {
+			return err
+		}
+	}
+	pkg.Logger.Info().Str("dir", p.Dir).Int("files", len(p.Items)).Int("keys", len(p.bundle.Keys)).Msg("[Bundle] 导入配置完成")
+	return nil
+}
+
+// externalReferences 列出配置文本中所有 cmd: / file: 引用，格式为 "字段 = 值"
+func externalReferences(content []byte) []string {
+	var raw map[string]any
+	if _, err := decodeConfig(content, &raw); err != nil {
+		return nil
+	}
+	var refs []string
+	var walk func(path string, value any)
+	walk = func(path string, value any) {
+		switch value := value.(type) {
+		case string:
+			if secrets.IsExternalReference(value) {
+				refs = append(refs, path+" = "+quotedOrEmpty(value))
+			}
+		case map[string]any:
+			for key, child := range value {
+				walk(joinFieldPath(path, key), child)
+			}
+		case []map[string]any:
+			for i, child := range value {
+				walk(fmt.Sprintf("%s[%d]", path, i), child)
+			}
+		case []any:
+			for i, child := range value {
+				walk(fmt.Sprintf("%s[%d]", path, i), child)
+			}
+		}
+	}
+	walk("", raw)
+	sort.Strings(refs)
+	return refs
+}
+
+// rewriteKeyPaths 将配置中引用的密钥路径替换为导入后的路径
+func rewriteKeyPaths(content []byte, paths map[string]string) []byte {
+	text := string(content)
+	for ref, path := range paths {
+		replacement := quotedOrEmpty(path)
+		text = strings.ReplaceAll(text, quotedOrEmpty(ref), replacement)
+		text = strings.ReplaceAll(text, "'"+ref+"'", replacement)
+	}
+	return []byte(text)
+}
+
+// writeFileAtomic 先写临时文件再替换，目录不存在时创建
+func writeFileAtomic(path string, content []byte, mode os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", displayPath(dir), err)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", displayPath(path), err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", displayPath(path), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", displayPath(path), err)
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", displayPath(path), err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", displayPath(path), err)
+	}
+	return nil
+}
+
+func relativeName(rootDir, path string) string {
+	name, err := filepath.Rel(rootDir, path)
+	if err != nil {
+		return filepath.Base(path)
+	}
+	return filepath.ToSlash(name)
+}
+
+// uniqueName 重名时追加序号，如 id_ed25519-2
+func uniqueName(name string, used map[string]bool) string {
+	candidate := name
+	for i := 2; used[candidate]; i++ {
+		ext := filepath.Ext(name)
+		candidate = fmt.Sprintf("%s-%d%s", strings.TrimSuffix(name, ext), i, ext)
+	}
+	return candidate
+}
+
+// ============================================================
@@ -0,0 +1,61 @@
+package config_loader
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestBundleEncryptRoundTrip(t *testing.T) {
+	bundle := &Bundle{
+		Version: 1,
+		Files:   []BundleFile{{Name: "dev.toml", Content: []byte("name = \"dev\"\n")}},
+		Keys:    []BundleKey{{Ref: "~/.ssh/id_ed25519", Name: "id_ed25519", Content: []byte("key")}},
+	}
+	encrypted, err := bundle.Encrypt([]byte("correct horse"))
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if bytes.Contains(encrypted, []byte("dev.toml")) {
+		t.Fatalf("encrypted bundle contains the plaintext file name")
+	}
+
+	decrypted, err := DecryptBundle(encrypted, []byte("correct horse"))
+	if err != nil {
+		t.Fatalf("DecryptBundle: %v", err)
+	}
+	if len(decrypted.Files) != 1 || decrypted.Files[0].Name != "dev.toml" || string(decrypted.Files[0].Content) != "name = \"dev\"\n" {
+		t.Errorf("files = %+v", decrypted.Files)
+	}
+	if len(decrypted.Keys) != 1 || decrypted.Keys[0].Name != "id_ed25519" || string(decrypted.Keys[0].Content) != "key" {
+		t.Errorf("keys = %+v", decrypted.Keys)
+	}
+
+	if _, err := DecryptBundle(encrypted, []byte("wrong")); !errors.Is(err, ErrBundlePassphrase) {
+		t.Errorf("wrong passphrase err = %v, want ErrBundlePassphrase", err)
+	}
+}
+
+func TestDecryptBundleRejectsUnsafeNames(t *testing.T) {
+	tests := []struct {
+		name   string
+		bundle Bundle
+	}{
+		{name: "file outside the config dir", bundle: Bundle{Files: []BundleFile{{Name: "../evil.toml"}}}},
+		{name: "absolute file path", bundle: Bundle{Files: []BundleFile{{Name: "/etc/evil.toml"}}}},
+		{name: "non-toml file", bundle: Bundle{Files: []BundleFile{{Name: "evil.sh"}}}},
+		{name: "key outside the keys dir", bundle: Bundle{Keys: []BundleKey{{Name: "../id_rsa"}}}},
+		{name: "key in a subdirectory", bundle: Bundle{Keys: []BundleKey{{Name: "sub/id_rsa"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encrypted, err := tt.bundle.Encrypt([]byte("passphrase"))
+			if err != nil {
+				t.Fatalf("Encrypt: %v", err)
+			}
+			if _, err := DecryptBundle(encrypted, []byte("passphrase")); err == nil {
+				t.Errorf("DecryptBundle accepted %+v", tt.bundle)
+			}
+		})
+	}
+}
@@ -0,0 +1,551 @@
+package config_loader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"ssh-messer/pkg"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config 编辑
+// ------------------------------------------------------------
+
+// HopEdit 编辑器中可修改的 hop 字段，Index 为源文件中 [[ssh_hops]] 的下标，新增的 hop 为 -1
+type HopEdit struct {
+	Index          int
+	Order          int // 仅新增的 hop 写入 order，已有 hop 的 order 保持不变
+	Host           string
+	Port           string
+	User           string
+	AuthType       string
+	PrivateKeyPath string
+	Passphrase     string
+}
+
+// ServiceEdit 编辑器中可修改的 service 字段，Index 为源文件中 [[services]] 的下标，新增的 service 为 -1
+type ServiceEdit struct {
+	Index     int
+	Host      string
+	Port      string
+	Subdomain string
+	Alias     string
+	UseTLS    bool
+}
+
+// ConfigEdit 表单编辑的配置
+// 保存时只改写表单中的字段，原文件中的注释、其他字段与表（如 [proxy]、[[services.routes]]）保持不变
+type ConfigEdit struct {
+	Path          string
+	Name          string
+	LocalHttpPort string
+	Hops          []HopEdit
+	Services      []ServiceEdit
+	// hops = [...] 引用的命名 hop 与 extends，编辑器中只读展示
+	NamedHops []string
+	Extends   string
+
+	rootDir string
+	source  []byte
+	exists  bool
+}
+
+// NewConfigEdit 在 rootDir 中新建配置，文件名通过 SetFileName 设置
+func NewConfigEdit(rootDir string) *ConfigEdit {
+	return &ConfigEdit{rootDir: rootDir}
+}
+
+// LoadConfigEdit 读取配置文件中未展开的原始字段用于编辑
+func LoadConfigEdit(config *TomlConfig) (*ConfigEdit, error) {
+	source, err := os.ReadFile(config.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", config.Path, err)
+	}
+
+	// 旧版本格式先升级，保存时一并写入当前版本
+	var raw TomlConfig
+	source, err = decodeConfig(source, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s cannot be parsed, fix it in a text editor first: %w", filepath.Base(config.Path), err)
+	}
+	// 内联数组（ssh_hops = [{...}]）无法逐项改写
+	for _, table := range []struct {
+		name  string
+		count int
+	}{{"ssh_hops", len(raw.SSHHops)}, {"services", len(raw.SSHServices)}} {
+		if countArrayTables(source, table.name) != table.count {
+			return nil, fmt.Errorf("%s defines %s inline, only [[%s]] tables can be edited here", filepath.Base(config.Path), table.name, table.name)
+		}
+	}
+
+	rootDir := config.rootDir
+	if rootDir == "" {
+		rootDir = filepath.Dir(config.Path)
+	}
+	edit := &ConfigEdit{
+		Path:          config.Path,
+		Name:          stringValue(raw.Name),
+		LocalHttpPort: stringValue(raw.LocalHttpPort),
+		NamedHops:     raw.Hops,
+		Extends:       stringValue(raw.Extends),
+		rootDir:       rootDir,
+		source:        source,
+		exists:        true,
+	}
+	for i, hop := range raw.SSHHops {
+		port := ""
+		if hop.Port != nil {
+			port = strconv.Itoa(*hop.Port)
+		}
+		order := 0
+		if hop.Order != nil {
+			order = *hop.Order
+		}
+		edit.Hops = append(edit.Hops, HopEdit{
+			Index:          i,
+			Order:          order,
+			Host:           stringValue(hop.Host),
+			Port:           port,
+			User:           stringValue(hop.User),
+			AuthType:       stringValue(hop.AuthType),
+			PrivateKeyPath: stringValue(hop.PrivateKeyPath),
+			Passphrase:     stringValue(hop.Passphrase),
+		})
+	}
+	for i, service := range raw.SSHServices {
+		edit.Services = append(edit.Services, ServiceEdit{
+			Index:     i,
+			Host:      stringValue(service.Host),
+			Port:      stringValue(service.Port),
+			Subdomain: stringValue(service.Subdomain),
+			Alias:     stringValue(service.Alias),
+			UseTLS:    service.UseTLS != nil && *service.UseTLS,
+		})
+	}
+	return edit, nil
+}
+
+// IsNew 是否为尚未保存的新配置
+func (e *ConfigEdit) IsNew() bool {
+	return !e.exists
+}
+
+// SetFileName 设置新配置的文件名（相对配置目录，可包含子目录分组），缺少扩展名时补充 .toml
+func (e *ConfigEdit) SetFileName(name string) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		e.Path = ""
+		return
+	}
+	if filepath.Ext(name) != ".toml" {
+		name += ".toml"
+	}
+	e.Path = filepath.Join(e.rootDir, filepath.FromSlash(name))
+}
+
+// AddHop 追加一个 hop，order 接在已有 hop 之后
+func (e *ConfigEdit) AddHop() {
+	order := 0
+	for i, hop := range e.Hops {
+		order = max(order, hop.Order, i+1)
+	}
+	e.Hops = append(e.Hops, HopEdit{Index: -1, Order: order + 1, Port: "22", AuthType: validAuthTypes[0]})
+}
+
+// AddService 追加一个 service
+func (e *ConfigEdit) AddService() {
+	e.Services = append(e.Services, ServiceEdit{Index: -1, Host: "localhost"})
+}
+
+// Build 将编辑结果按加载流程展开并校验，返回展开后的配置（用于测试连接）与发现的问题
+func (e *ConfigEdit) Build() (*TomlConfig, ValidationErrors) {
+	file := filepath.Base(e.Path)
+	var errs ValidationErrors
+	if e.Path == "" {
+		return nil, ValidationErrors{{Field: "file", Message: "file name is required"}}
+	}
+	if relative, err := filepath.Rel(e.rootDir, e.Path); err != nil || !filepath.IsLocal(relative) {
+		errs = append(errs, ValidationError{File: file, Field: "file", Message: fmt.Sprintf("must be inside %s", displayPath(e.rootDir))})
+	}
+	if !e.exists {
+		if _, err := os.Stat(e.Path); err == nil {
+			errs = append(errs, ValidationError{File: file, Field: "file", Message: fmt.Sprintf("%s already exists", displayPath(e.Path))})
+		}
+	}
+	for i, hop := range e.Hops {
+		if hop.Port != "" {
+			if _, err := strconv.Atoi(hop.Port); err != nil {
+				errs = append(errs, ValidationError{File: file, Field: fmt.Sprintf("ssh_hops[%d].port", i), Message: fmt.Sprintf("%q is not a number", hop.Port)})
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	content := e.Render()
+	config := TomlConfig{Path: e.Path, source: content, rootDir: e.rootDir}
+	if _, err := toml.Decode(string(content), &config); err != nil {
+		return nil, decodeError(e.Path, err)
+	}
+	errs = append(expandConfig(&config, e.rootDir), config.Validate()...)
+	config.Errors = errs
+	return &config, errs
+}
+
+// Render 将编辑结果写回原始文本，只改写表单中的字段
+func (e *ConfigEdit) Render() []byte {
+	segments := parseSegments(e.source)
+
+	top := segments[0]
+	top.setKey("name", quotedOrEmpty(e.Name))
+	top.setKey("local_http_port", quotedOrEmpty(e.LocalHttpPort))
+
+	hopSegments := segmentsOf(segments, "ssh_hops")
+	keptHops := make(map[int]bool)
+	var newHops []*tomlSegment
+	for _, hop := range e.Hops {
+		segment := newArraySegment("ssh_hops")
+		if hop.Index >= 0 && hop.Index < len(hopSegments) {
+			segment = hopSegments[hop.Index]
+			keptHops[hop.Index] = true
+		} else {
+			if hop.Order > 0 {
+				segment.setKey("order", strconv.Itoa(hop.Order))
+			}
+			newHops = append(newHops, segment)
+		}
+		port := hop.Port
+		if _, err := strconv.Atoi(port); err != nil {
+			port = quotedOrEmpty(port)
+		}
+		segment.setKey("host", quotedOrEmpty(hop.Host))
+		segment.setKey("port", port)
+		segment.setKey("user", quotedOrEmpty(hop.User))
+		segment.setKey("auth_type", quotedOrEmpty(hop.AuthType))
+		segment.setKey("private_key_path", quotedOrEmpty(hop.PrivateKeyPath))
+		segment.setKey("passphrase", quotedOrEmpty(hop.Passphrase))
+	}
+
+	serviceSegments := segmentsOf(segments, "services")
+	keptServices := make(map[int]bool)
+	var newServices []*tomlSegment
+	for _, service := range e.Services {
+		segment := newArraySegment("services")
+		if service.Index >= 0 && service.Index < len(serviceSegments) {
+			segment = serviceSegments[service.Index]
+			keptServices[service.Index] = true
+		} else {
+			newServices = append(newServices, segment)
+		}
+		useTLS := ""
+		if service.UseTLS {
+			useTLS = "true"
+		}
+		segment.setKey("host", quotedOrEmpty(service.Host))
+		segment.setKey("port", quotedOrEmpty(service.Port))
+		segment.setKey("subdomain", quotedOrEmpty(service.Subdomain))
+		segment.setKey("alias", quotedOrEmpty(service.Alias))
+		segment.setKey("use_tls", useTLS)
+	}
+
+	for i, segment := range hopSegments {
+		segment.removed = !keptHops[i]
+	}
+	for i, segment := range serviceSegments {
+		segment.removed = !keptServices[i]
+	}
+	segments = insertAfterLast(segments, "ssh_hops", newHops)
+	segments = insertAfterLast(segments, "services", newServices)
+
+	var lines []string
+	for _, segment := range segments {
+		if segment.removed {
+			continue
+		}
+		// 新增的表与前文之间空一行
+		if segment.added && len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+			lines = append(lines, "")
+		}
+		lines = append(lines, segment.lines...)
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return setConfigVersion([]byte(strings.Join(lines, "\n")+"\n"), CurrentConfigVersion)
+}
+
+// Save 写入配置文件（先写临时文件再替换，新文件权限为 0600）
+func (e *ConfigEdit) Save() error {
+	if _, errs := e.Build(); len(errs) > 0 {
+		return errs
+	}
+
+	dir := filepath.Dir(e.Path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create %s: %w", displayPath(dir), err)
+	}
+	mode := os.FileMode(0600)
+	if info, err := os.Stat(e.Path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	content := e.Render()
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(e.Path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save %s: %w", displayPath(e.Path), err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to save %s: %w", displayPath(e.Path), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save %s: %w", displayPath(e.Path), err)
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return fmt.Errorf("failed to save %s: %w", displayPath(e.Path), err)
+	}
+	if err := os.Rename(tmp.Name(), e.Path); err != nil {
+		return fmt.Errorf("failed to save %s: %w", displayPath(e.Path), err)
+	}
+
+	// 保存后源文件中的表顺序与编辑结果一致
+	e.source = content
+	e.exists = true
+	for i := range e.Hops {
+		e.Hops[i].Index = i
+	}
+	for i := range e.Services {
+		e.Services[i].Index = i
+	}
+	pkg.Logger.Info().Str("file", e.Path).Msg("[ConfigLoader] 配置文件已保存")
+	return nil
+}
+
+// tomlSegment 原始文本中的一段：顶层字段、一个 [[ssh_hops]] / [[services]]（含其子表）或其他表
+type tomlSegment struct {
+	table   string // 顶层为空，其他表为 "-"
+	lines   []string
+	added   bool
+	removed bool
+}
+
+// parseSegments 按表头切分原始文本，表头前紧邻的注释归属于该表
+func parseSegments(source []byte) []*tomlSegment {
+	current := &tomlSegment{}
+	segments := []*tomlSegment{current}
+	text := strings.TrimSuffix(string(source), "\n")
+	if text == "" {
+		return segments
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		match := tableHeaderPattern.FindStringSubmatch(trimmed)
+		if match == nil {
+			current.lines = append(current.lines, line)
+			continue
+		}
+		name := match[1]
+		// 数组表的子表（如 [[services.routes]]）属于当前元素
+		if current.table != "" && current.table != "-" && strings.HasPrefix(name, current.table+".") {
+			current.lines = append(current.lines, line)
+			continue
+		}
+
+		table := "-"
+		if strings.HasPrefix(trimmed, "[[") && (name == "ssh_hops" || name == "services") {
+			table = name
+		}
+		next := &tomlSegment{table: table}
+		split := len(current.lines)
+		for split > 0 && strings.HasPrefix(strings.TrimSpace(current.lines[split-1]), "#") {
+			split--
+		}
+		next.lines = append(next.lines, current.lines[split:]...)
+		current.lines = current.lines[:split]
+		next.lines = append(next.lines, line)
+		segments = append(segments, next)
+		current = next
+	}
+	return segments
+}
+
+// newArraySegment 新增的数组表，末尾的空行与后面的表隔开
+func newArraySegment(table string) *tomlSegment {
+	return &tomlSegment{table: table, lines: []string{"[[" + table + "]]", ""}, added: true}
+}
+
+func segmentsOf(segments []*tomlSegment, table string) []*tomlSegment {
+	var matched []*tomlSegment
+	for _, segment := range segments {
+		if segment.table == table {
+			matched = append(matched, segment)
+		}
+	}
+	return matched
+}
+
+// insertAfterLast 将新增的表插入到同名表的最后一个之后，没有同名表时追加到末尾
+func insertAfterLast(segments []*tomlSegment, table string, added []*tomlSegment) []*tomlSegment {
+	if len(added) == 0 {
+		return segments
+	}
+	position := len(segments)
+	for i, segment := range segments {
+		if segment.table == table {
+			position = i + 1
+		}
+	}
+	result := make([]*tomlSegment, 0, len(segments)+len(added))
+	result = append(result, segments[:position]...)
+	result = append(result, added...)
+	return append(result, segments[position:]...)
+}
+
+// setKey 设置当前表（不含子表）中的字段，value 为已编码的 TOML 值，为空时删除该字段
+// 已有字段原地替换并保留行尾注释，新字段插入到最后一个字段之后
+func (s *tomlSegment) setKey(key, value string) {
+	start, end := s.bodyRange()
+	lastKey := -1
+	for i := start; i < end; i++ {
+		trimmed := strings.TrimSpace(s.lines[i])
+		match := keyPattern.FindStringSubmatch(trimmed)
+		if match == nil {
+			continue
+		}
+		lastKey = i
+		if match[1] != key {
+			continue
+		}
+		if value == "" {
+			s.lines = append(s.lines[:i], s.lines[i+1:]...)
+			return
+		}
+		indent := s.lines[i][:len(s.lines[i])-len(strings.TrimLeft(s.lines[i], " \t"))]
+		line := indent + key + " = " + value
+		if comment := trailingComment(trimmed); comment != "" {
+			line += " " + comment
+		}
+		s.lines[i] = line
+		return
+	}
+	if value == "" {
+		return
+	}
+
+	position := lastKey + 1
+	if lastKey < 0 {
+		position = start
+		if s.table == "" {
+			// 没有顶层字段时写在文件开头的注释之后
+			for position < end && strings.TrimSpace(s.lines[position]) != "" {
+				position++
+			}
+		}
+	}
+	line := key + " = " + value
+	s.lines = append(s.lines[:position], append([]string{line}, s.lines[position:]...)...)
+}
+
+// bodyRange 返回表头之后、第一个子表之前的行范围
+func (s *tomlSegment) bodyRange() (int, int) {
+	start := 0
+	if s.table != "" {
+		for i, line := range s.lines {
+			if tableHeaderPattern.MatchString(strings.TrimSpace(line)) {
+				start = i + 1
+				break
+			}
+		}
+	}
+	end := len(s.lines)
+	for i := start; i < len(s.lines); i++ {
+		if tableHeaderPattern.MatchString(strings.TrimSpace(s.lines[i])) {
+			end = i
+			break
+		}
+	}
+	return start, end
+}
+
+// trailingComment 返回 "key = value # comment" 中的行尾注释（忽略字符串中的 #）
+func trailingComment(line string) string {
+	_, value, _ := strings.Cut(line, "=")
+	var quote rune
+	escaped := false
+	for i, r := range value {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return value[i:]
+		}
+	}
+	return ""
+}
+
+// countArrayTables 统计 [[table]] 表头的数量
+func countArrayTables(source []byte, table string) int {
+	count := 0
+	for _, line := range strings.Split(string(source), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if match := tableHeaderPattern.FindStringSubmatch(trimmed); match != nil && strings.HasPrefix(trimmed, "[[") && match[1] == table {
+			count++
+		}
+	}
+	return count
+}
+
+// quotedOrEmpty 将字符串编码为 TOML 基本字符串，空字符串返回空（表示删除字段）
+func quotedOrEmpty(value string) string {
+	if value == "" {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// ============================================================
@@ -0,0 +1,351 @@
+package config_loader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const editorTestSource = `# 跳板机配置
+version = 2
+name = "dev" # 显示名称
+local_http_port = "8080"
+custom_key = "kept"
+
+# 第一跳
+[[ssh_hops]]
+order = 1
+host = "bastion.example.com" # 公网入口
+port = 22
+user = "ops"
+auth_type = "password"
+jump_note = "unknown key"
+
+[[ssh_hops]]
+order = 2
+host = "10.0.0.2"
+port = 22
+user = "root"
+auth_type = "privateKey"
+private_key_path = "~/.ssh/id_ed25519"
+
+[[services]]
+host = "localhost"
+port = "3000"
+alias = "web"
+
+  # 路由注释
+  [[services.routes]]
+  path_prefix = "/api"
+
+[proxy]
+# 代理设置
+type = "socks5"
+`
+
+func TestConfigEditRender(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(e *ConfigEdit)
+		want string
+	}{
+		{
+			name: "unchanged",
+			edit: func(e *ConfigEdit) {},
+			want: editorTestSource,
+		},
+		{
+			name: "name keeps trailing comment",
+			edit: func(e *ConfigEdit) { e.Name = "prod" },
+			want: `# 跳板机配置
+version = 2
+name = "prod" # 显示名称
+local_http_port = "8080"
+custom_key = "kept"
+
+# 第一跳
+[[ssh_hops]]
+order = 1
+host = "bastion.example.com" # 公网入口
+port = 22
+user = "ops"
+auth_type = "password"
+jump_note = "unknown key"
+
+[[ssh_hops]]
+order = 2
+host = "10.0.0.2"
+port = 22
+user = "root"
+auth_type = "privateKey"
+private_key_path = "~/.ssh/id_ed25519"
+
+[[services]]
+host = "localhost"
+port = "3000"
+alias = "web"
+
+  # 路由注释
+  [[services.routes]]
+  path_prefix = "/api"
+
+[proxy]
+# 代理设置
+type = "socks5"
+`,
+		},
+		{
+			name: "cleared local_http_port is removed",
+			edit: func(e *ConfigEdit) { e.LocalHttpPort = "" },
+			want: `# 跳板机配置
+version = 2
+name = "dev" # 显示名称
+custom_key = "kept"
+
+# 第一跳
+[[ssh_hops]]
+order = 1
+host = "bastion.example.com" # 公网入口
+port = 22
+user = "ops"
+auth_type = "password"
+jump_note = "unknown key"
+
+[[ssh_hops]]
+order = 2
+host = "10.0.0.2"
+port = 22
+user = "root"
+auth_type = "privateKey"
+private_key_path = "~/.ssh/id_ed25519"
+
+[[services]]
+host = "localhost"
+port = "3000"
+alias = "web"
+
+  # 路由注释
+  [[services.routes]]
+  path_prefix = "/api"
+
+[proxy]
+# 代理设置
+type = "socks5"
+`,
+		},
+		{
+			name: "hop fields keep comments and unknown keys",
+			edit: func(e *ConfigEdit) {
+				e.Hops[0].Host = "jump.example.com"
+				e.Hops[0].PrivateKeyPath = "~/.ssh/jump"
+			},
+			want: `# 跳板机配置
+version = 2
+name = "dev" # 显示名称
+local_http_port = "8080"
+custom_key = "kept"
+
+# 第一跳
+[[ssh_hops]]
+order = 1
+host = "jump.example.com" # 公网入口
+port = 22
+user = "ops"
+auth_type = "password"
+jump_note = "unknown key"
+private_key_path = "~/.ssh/jump"
+
+[[ssh_hops]]
+order = 2
+host = "10.0.0.2"
+port = 22
+user = "root"
+auth_type = "privateKey"
+private_key_path = "~/.ssh/id_ed25519"
+
+[[services]]
+host = "localhost"
+port = "3000"
+alias = "web"
+
+  # 路由注释
+  [[services.routes]]
+  path_prefix = "/api"
+
+[proxy]
+# 代理设置
+type = "socks5"
+`,
+		},
+		{
+			name: "removed hop takes its leading comment",
+			edit: func(e *ConfigEdit) { e.Hops = e.Hops[1:] },
+			want: `# 跳板机配置
+version = 2
+name = "dev" # 显示名称
+local_http_port = "8080"
+custom_key = "kept"
+
+[[ssh_hops]]
+order = 2
+host = "10.0.0.2"
+port = 22
+user = "root"
+auth_type = "privateKey"
+private_key_path = "~/.ssh/id_ed25519"
+
+[[services]]
+host = "localhost"
+port = "3000"
+alias = "web"
+
+  # 路由注释
+  [[services.routes]]
+  path_prefix = "/api"
+
+[proxy]
+# 代理设置
+type = "socks5"
+`,
+		},
+		{
+			name: "added hop goes after the last hop",
+			edit: func(e *ConfigEdit) {
+				e.AddHop()
+				e.Hops[2].Host = "10.0.0.3"
+				e.Hops[2].User = "app"
+			},
+			want: `# 跳板机配置
+version = 2
+name = "dev" # 显示名称
+local_http_port = "8080"
+custom_key = "kept"
+
+# 第一跳
+[[ssh_hops]]
+order = 1
+host = "bastion.example.com" # 公网入口
+port = 22
+user = "ops"
+auth_type = "password"
+jump_note = "unknown key"
+
+[[ssh_hops]]
+order = 2
+host = "10.0.0.2"
+port = 22
+user = "root"
+auth_type = "privateKey"
+private_key_path = "~/.ssh/id_ed25519"
+
+[[ssh_hops]]
+order = 3
+host = "10.0.0.3"
+port = 22
+user = "app"
+auth_type = "privateKey"
+
+[[services]]
+host = "localhost"
+port = "3000"
+alias = "web"
+
+  # 路由注释
+  [[services.routes]]
+  path_prefix = "/api"
+
+[proxy]
+# 代理设置
+type = "socks5"
+`,
+		},
+		{
+			name: "service edit keeps sub tables",
+			edit: func(e *ConfigEdit) {
+				e.Services[0].Port = "3001"
+				e.Services[0].UseTLS = true
+			},
+			want: `# 跳板机配置
+version = 2
+name = "dev" # 显示名称
+local_http_port = "8080"
+custom_key = "kept"
+
+# 第一跳
+[[ssh_hops]]
+order = 1
+host = "bastion.example.com" # 公网入口
+port = 22
+user = "ops"
+auth_type = "password"
+jump_note = "unknown key"
+
+[[ssh_hops]]
+order = 2
+host = "10.0.0.2"
+port = 22
+user = "root"
+auth_type = "privateKey"
+private_key_path = "~/.ssh/id_ed25519"
+
+[[services]]
+host = "localhost"
+port = "3001"
+alias = "web"
+use_tls = true
+
+  # 路由注释
+  [[services.routes]]
+  path_prefix = "/api"
+
+[proxy]
+# 代理设置
+type = "socks5"
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edit := loadTestConfigEdit(t, editorTestSource)
+			tt.edit(edit)
+			if got := string(edit.Render()); got != tt.want {
+				t.Fatalf("Render:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigEditRenderMigratesOldVersion(t *testing.T) {
+	edit := loadTestConfigEdit(t, `# 旧版本配置
+name = "dev"
+
+[[ssh_hops]]
+host = "bastion" # 入口
+authType = "password" # 登录方式
+`)
+	edit.Name = "prod"
+	want := `# 旧版本配置
+version = 2
+name = "prod"
+
+[[ssh_hops]]
+host = "bastion" # 入口
+auth_type = "password" # 登录方式
+`
+	if got := string(edit.Render()); got != want {
+		t.Fatalf("Render:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func loadTestConfigEdit(t *testing.T, source string) *ConfigEdit {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "dev.toml")
+	if err := os.WriteFile(path, []byte(source), 0600); err != nil {
+		t.Fatal(err)
+	}
+	edit, err := LoadConfigEdit(&TomlConfig{Path: path})
+	if err != nil {
+		t.Fatalf("LoadConfigEdit: %v", err)
+	}
+	return edit
+}
@@ -0,0 +1,228 @@
+package config_loader
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"ssh-messer/internal/ssh_proxy"
+)
+
+// Config 共享 hop 与继承
+// ------------------------------------------------------------
+
+// HopLibraryFileName 配置目录中的共享 hop 定义文件，不作为独立配置加载
+const HopLibraryFileName = "hops.toml"
+
+// hopLibrary hops.toml 的结构：[hops.<name>] 定义命名 hop
+type hopLibrary struct {
+	Version *int                              `toml:"version,omitempty"`
+	Hops    map[string]ssh_proxy.SSHHopConfig `toml:"hops"`
+}
+
+// expandConfig 依次应用 extends 与命名 hop，返回发现的问题
+// extends 相对配置文件所在目录，hops.toml 从所在目录向上查找到 rootDir
+func expandConfig(config *TomlConfig, rootDir string) ValidationErrors {
+	dir := filepath.Dir(config.Path)
+	v := &validator{config: config}
+
+	if config.Extends != nil && *config.Extends != "" {
+		base, err := loadBaseConfig(dir, *config.Extends, map[string]bool{filepath.Base(config.Path): true})
+		if err != nil {
+			v.addf("", 0, "extends", "%v", err)
+		} else {
+			mergeConfig(config, base)
+		}
+	}
+
+	if len(config.Hops) > 0 {
+		v.expandNamedHops(dir, rootDir)
+	}
+	return v.errs
+}
+
+// loadBaseConfig 加载 extends 指向的配置（同目录，可继续 extends），visited 用于检测循环
+func loadBaseConfig(dir, name string, visited map[string]bool) (*TomlConfig, error) {
+	if filepath.Ext(name) == "" {
+		name += ".toml"
+	}
+	if visited[name] {
+		return nil, fmt.Errorf("circular extends via %s", name)
+	}
+	visited[name] = true
+
+	base := TomlConfig{Path: filepath.Join(dir, name)}
+	source, err := os.ReadFile(base.Path)
+	if err == nil {
+		base.source, err = decodeConfig(source, &base)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load base config %s: %w", name, err)
+	}
+	if base.Extends != nil && *base.Extends != "" {
+		parent, err := loadBaseConfig(dir, *base.Extends, visited)
+		if err != nil {
+			return nil, err
+		}
+		mergeConfig(&base, parent)
+	}
+	return &base, nil
+}
+
+// mergeConfig 用 base 填充 config 中未设置的顶层字段（config 中设置的字段整体覆盖 base，数组不合并）
+// 并记录继承的字段来自哪个文件
+func mergeConfig(config, base *TomlConfig) {
+	target := reflect.ValueOf(config).Elem()
+	source := reflect.ValueOf(base).Elem()
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Type().Field(i)
+		name := tomlFieldName(field)
+		if !field.IsExported() || name == "-" || field.Name == "Extends" || field.Name == "Version" {
+			continue
+		}
+		if target.Field(i).IsZero() && !source.Field(i).IsZero() {
+			target.Field(i).Set(source.Field(i))
+			if config.origins == nil {
+				config.origins = make(map[string]*configSource)
+			}
+			config.origins[name] = base.sourceOf(name)
+		}
+	}
+}
+
+// configSource 定义字段的文件，用于将问题定位到该文件中的行
+type configSource struct {
+	file  string
+	lines *lineLocator
+}
+
+// hopSource 展开后的 hop 的定义位置：[[ssh_hops]] 的下标，或 hops.toml 中的 [hops.<name>]
+type hopSource struct {
+	source *configSource
+	table  string
+	index  int    // 命名 hop 为 -1
+	name   string // 命名 hop 的名称，用于查找 hop_overrides
+}
+
+// sourceOf 顶层字段所在的文件，继承的字段为基础配置
+func (c *TomlConfig) sourceOf(field string) *configSource {
+	if source, exists := c.origins[field]; exists {
+		return source
+	}
+	return &configSource{file: filepath.Base(c.Path), lines: newLineLocator(c.source)}
+}
+
+// expandNamedHops 将 hops = ["name", ...] 展开为 SSHHopConfig，放在 [[ssh_hops]] 之前
+func (v *validator) expandNamedHops(dir, rootDir string) {
+	config := v.config
+	var library hopLibrary
+	libraryPath := findHopLibrary(dir, rootDir)
+	if libraryPath == "" {
+		v.addf("", 0, "hops", "named hops require %s in %s", HopLibraryFileName, displayPath(rootDir))
+		return
+	}
+	source, err := os.ReadFile(libraryPath)
+	if err == nil {
+		source, err = decodeConfig(source, &library)
+	}
+	if err != nil {
+		v.addf("", 0, "hops", "failed to load %s: %v", HopLibraryFileName, err)
+		return
+	}
+	librarySource := &configSource{file: HopLibraryFileName, lines: newLineLocator(source)}
+
+	for name := range config.HopOverrides {
+		if !containsString(config.Hops, name) {
+			v.addf("", 0, "hop_overrides", "override for %q, which is not listed in hops", name)
+		}
+	}
+
+	hops := make([]ssh_proxy.SSHHopConfig, 0, len(config.Hops)+len(config.SSHHops))
+	sources := make([]hopSource, 0, cap(hops))
+	for i, name := range config.Hops {
+		hop, exists := library.Hops[name]
+		if !exists {
+			v.addf("", 0, "hops", "unknown hop %q (not defined in %s)", name, HopLibraryFileName)
+			continue
+		}
+		if override, exists := config.HopOverrides[name]; exists {
+			overrideFields(&hop, &override)
+		}
+		if hop.Order == nil {
+			order := i + 1
+			hop.Order = &order
+		}
+		if hop.Alias == nil {
+			alias := name
+			hop.Alias = &alias
+		}
+		hops = append(hops, hop)
+		sources = append(sources, hopSource{source: librarySource, table: "hops." + name, index: -1, name: name})
+	}
+
+	// 显式的 [[ssh_hops]] 接在命名 hop 之后
+	hopsSource := config.sourceOf("ssh_hops")
+	for i, hop := range config.SSHHops {
+		if hop.Order == nil {
+			order := len(hops) + 1
+			hop.Order = &order
+		}
+		hops = append(hops, hop)
+		sources = append(sources, hopSource{source: hopsSource, table: "ssh_hops", index: i})
+	}
+	config.SSHHops = hops
+	config.hopSources = sources
+}
+
+// findHopLibrary 从 dir 向上查找 hops.toml，不超出 rootDir，子目录分组可以覆盖上层的定义
+func findHopLibrary(dir, rootDir string) string {
+	for {
+		candidate := filepath.Join(dir, HopLibraryFileName)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+		if dir == rootDir || !strings.HasPrefix(dir, rootDir) {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// overrideFields 用 override 中已设置的字段覆盖 target
+func overrideFields[T any](target, override *T) {
+	targetValue := reflect.ValueOf(target).Elem()
+	overrideValue := reflect.ValueOf(override).Elem()
+	for i := 0; i < targetValue.NumField(); i++ {
+		if !overrideValue.Field(i).IsZero() {
+			targetValue.Field(i).Set(overrideValue.Field(i))
+		}
+	}
+}
+
+// hasTomlField 结构体中 toml 名为 key 的字段是否已设置
+func hasTomlField[T any](value T, key string) bool {
+	v := reflect.ValueOf(value)
+	for i := 0; i < v.NumField(); i++ {
+		if tomlFieldName(v.Type().Field(i)) == key {
+			return !v.Field(i).IsZero()
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================
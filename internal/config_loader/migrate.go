@@ -0,0 +1,354 @@
+package config_loader
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"ssh-messer/pkg"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config 版本迁移
+// ------------------------------------------------------------
+
+// CurrentConfigVersion 当前的配置格式版本，未写 version 的文件视为 1
+// v2 统一使用 snake_case 字段名（auth_type、private_key_path、timeout_sec、hop_order）
+const CurrentConfigVersion = 2
+
+// configMigration 升级到 to 版本的改写规则，只重命名字段，注释与行号保持不变
+type configMigration struct {
+	to      int
+	renames map[string]string
+}
+
+var configMigrations = []configMigration{
+	{to: 2, renames: map[string]string{
+		"authType":       "auth_type",
+		"privateKeyPath": "private_key_path",
+		"timeoutSec":     "timeout_sec",
+		"hopOrder":       "hop_order",
+	}},
+}
+
+// MigrationResult 一个文件的迁移结果
+type MigrationResult struct {
+	Path   string
+	From   int    // 迁移前的版本
+	Backup string // 备份文件路径，未改写时为空
+	Err    error
+}
+
+// configVersion 读取顶层的 version，未设置时为 1
+func configVersion(source []byte) (int, error) {
+	var header struct {
+		Version *int `toml:"version"`
+	}
+	if _, err := toml.Decode(string(source), &header); err != nil {
+		return 0, err
+	}
+	if header.Version == nil {
+		return 1, nil
+	}
+	return *header.Version, nil
+}
+
+// migrateSource 将配置文本升级到当前版本，返回升级后的文本与原版本
+// 无法解析的文本原样返回，由调用方解码时报告具体位置
+func migrateSource(source []byte) ([]byte, int, error) {
+	version, err := configVersion(source)
+	if err != nil {
+		return source, 0, nil
+	}
+	if version < 1 || version > CurrentConfigVersion {
+		return source, version, fmt.Errorf("unsupported config version %d (this build supports up to %d)", version, CurrentConfigVersion)
+	}
+	for _, migration := range configMigrations {
+		if migration.to > version {
+			source = renameKeys(source, migration.renames)
+		}
+	}
+	return source, version, nil
+}
+
+// decodeConfig 升级并解码配置文本，返回升级后的文本（用于定位错误所在行）
+func decodeConfig(source []byte, v any) ([]byte, error) {
+	migrated, version, err := migrateSource(source)
+	if err != nil {
+		return source, err
+	}
+	if version > 0 && version < CurrentConfigVersion {
+		pkg.Logger.Debug().Int("version", version).Msg("[ConfigLoader] 配置使用旧版本格式，已在内存中升级")
+	}
+	if _, err := toml.Decode(string(migrated), v); err != nil {
+		return migrated, err
+	}
+	return migrated, nil
+}
+
+// decodeConfigFile 读取、升级并解码配置文件
+func decodeConfigFile(path string, v any) error {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	_, err = decodeConfig(source, v)
+	return err
+}
+
+// renameKeys 重命名键（包括点分键、内联表中的键与带引号的键），跳过字符串、注释中的内容
+func renameKeys(source []byte, renames map[string]string) []byte {
+	text := string(source)
+	var b strings.Builder
+	// previous 为上一个有意义的字符，行首、'{'、','、'.' 之后才可能是键
+	previous := byte('\n')
+	isKey := func(end int) bool {
+		if previous != '\n' && previous != '{' && previous != ',' && previous != '.' {
+			return false
+		}
+		rest := strings.TrimLeft(text[end:], " \t")
+		return strings.HasPrefix(rest, "=") || strings.HasPrefix(rest, ".")
+	}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '\n':
+			b.WriteByte(c)
+			previous = '\n'
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			b.WriteByte(c)
+			i++
+		case c == '#':
+			end := strings.IndexByte(text[i:], '\n')
+			if end < 0 {
+				end = len(text) - i
+			}
+			b.WriteString(text[i : i+end])
+			i += end
+		case strings.HasPrefix(text[i:], `"""`) || strings.HasPrefix(text[i:], "'''"):
+			end := multilineStringEnd(text, i)
+			b.WriteString(text[i:end])
+			previous = c
+			i = end
+		case c == '"' || c == '\'':
+			end := stringEnd(text, i)
+			if end-i >= 2 && isKey(end) {
+				if renamed, exists := renames[text[i+1:end-1]]; exists {
+					b.WriteString(string(c) + renamed + string(c))
+					previous = c
+					i = end
+					continue
+				}
+			}
+			b.WriteString(text[i:end])
+			previous = c
+			i = end
+		case isBareKeyChar(c):
+			end := i
+			for end < len(text) && isBareKeyChar(text[end]) {
+				end++
+			}
+			word := text[i:end]
+			if renamed, exists := renames[word]; exists && isKey(end) {
+				word = renamed
+			}
+			b.WriteString(word)
+			previous = text[end-1]
+			i = end
+		default:
+			b.WriteByte(c)
+			previous = c
+			i++
+		}
+	}
+	return []byte(b.String())
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+// stringEnd 返回单行字符串结束引号之后的位置，未闭合时到行尾
+func stringEnd(text string, start int) int {
+	quote := text[start]
+	for i := start + 1; i < len(text); i++ {
+		switch {
+		case text[i] == '\n':
+			return i
+		case quote == '"' && text[i] == '\\':
+			i++
+		case text[i] == quote:
+			return i + 1
+		}
+	}
+	return len(text)
+}
+
+// multilineStringEnd 返回多行字符串结束引号之后的位置
+func multilineStringEnd(text string, start int) int {
+	delimiter := text[start : start+3]
+	for i := start + 3; i < len(text); i++ {
+		if delimiter == `"""` && text[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(text[i:], delimiter) {
+			end := i + 3
+			// 结尾最多可以再多两个引号属于内容
+			for end < len(text) && end < i+5 && text[end] == delimiter[0] {
+				end++
+			}
+			return end
+		}
+	}
+	return len(text)
+}
+
+// setConfigVersion 在顶层写入 version（已有时替换并保留行尾注释），新写入时作为第一个字段
+func setConfigVersion(source []byte, version int) []byte {
+	var lines []string
+	if len(source) > 0 {
+		lines = strings.Split(strings.TrimSuffix(string(source), "\n"), "\n")
+	}
+	line := "version = " + strconv.Itoa(version)
+
+	firstKey := -1
+	for i, current := range lines {
+		trimmed := strings.TrimSpace(current)
+		if tableHeaderPattern.MatchString(trimmed) {
+			break
+		}
+		match := keyPattern.FindStringSubmatch(trimmed)
+		if match == nil {
+			continue
+		}
+		if match[1] == "version" {
+			if comment := trailingComment(trimmed); comment != "" {
+				line += " " + comment
+			}
+			lines[i] = line
+			return []byte(strings.Join(lines, "\n") + "\n")
+		}
+		if firstKey < 0 {
+			firstKey = i
+		}
+	}
+
+	added := []string{line}
+	insert := firstKey
+	if insert < 0 {
+		// 没有顶层字段时写在文件开头的注释之后，与前后内容空行隔开
+		insert = 0
+		for insert < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[insert]), "#") {
+			insert++
+		}
+		if insert > 0 {
+			added = append([]string{""}, added...)
+		}
+		if insert < len(lines) && strings.TrimSpace(lines[insert]) != "" {
+			added = append(added, "")
+		}
+	}
+	lines = append(lines[:insert], append(added, lines[insert:]...)...)
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
+
+// MigrateConfigFiles 将所有配置来源中的旧版本配置与 hops.toml 改写为当前版本
+// 改写前在同目录保存 <file>.v<version>.bak 备份，dryRun 时只返回需要迁移的文件
+func MigrateConfigFiles(dryRun bool) []MigrationResult {
+	var results []MigrationResult
+	for _, path := range migratableFiles() {
+		source, err := os.ReadFile(path)
+		if err != nil {
+			results = append(results, MigrationResult{Path: path, Err: err})
+			continue
+		}
+		version, err := configVersion(source)
+		var migrated []byte
+		if err == nil {
+			migrated, _, err = migrateSource(source)
+		}
+		if err != nil {
+			results = append(results, MigrationResult{Path: path, From: version, Err: err})
+			continue
+		}
+		if version == CurrentConfigVersion {
+			continue
+		}
+
+		result := MigrationResult{Path: path, From: version}
+		if !dryRun {
+			result.Backup, result.Err = migrateFile(path, source, setConfigVersion(migrated, CurrentConfigVersion), version)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// migrateFile 写入备份后替换原文件，保留原文件权限
+func migrateFile(path string, source, migrated []byte, version int) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if _, err := toml.Decode(string(migrated), &map[string]any{}); err != nil {
+		return "", fmt.Errorf("migrated file cannot be parsed: %w", err)
+	}
+
+	backup := fmt.Sprintf("%s.v%d.bak", path, version)
+	for i := 2; ; i++ {
+		if _, err := os.Stat(backup); os.IsNotExist(err) {
+			break
+		}
+		backup = fmt.Sprintf("%s.v%d-%d.bak", path, version, i)
+	}
+	if err := os.WriteFile(backup, source, info.Mode().Perm()); err != nil {
+		return "", fmt.Errorf("failed to write backup %s: %w", displayPath(backup), err)
+	}
+	if err := writeFileAtomic(path, migrated, info.Mode().Perm()); err != nil {
+		return backup, err
+	}
+	pkg.Logger.Info().Str("file", path).Str("backup", backup).Int("from", version).Int("to", CurrentConfigVersion).Msg("[ConfigLoader] 配置文件已迁移")
+	return backup, nil
+}
+
+// migratableFiles 所有来源中的配置文件与 hops.toml
+func migratableFiles() []string {
+	var paths []string
+	for _, file := range discoverConfigFiles() {
+		paths = append(paths, file.path)
+		if file.name == ProjectConfigName {
+			if library := filepath.Join(file.rootDir, HopLibraryFileName); fileExists(library) {
+				paths = append(paths, library)
+			}
+		}
+	}
+	for _, dir := range ConfigDirs() {
+		filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if entry.IsDir() && path != dir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if !entry.IsDir() && entry.Name() == HopLibraryFileName {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+	}
+	return paths
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// ============================================================
@@ -0,0 +1,114 @@
+package config_loader
+
+import (
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestRenameKeys(t *testing.T) {
+	renames := configMigrations[0].renames
+
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			name:   "bare keys",
+			source: "[[ssh_hops]]\nauthType = \"password\"\n  timeoutSec=5\n",
+			want:   "[[ssh_hops]]\nauth_type = \"password\"\n  timeout_sec=5\n",
+		},
+		{
+			name:   "quoted keys keep their quotes",
+			source: "\"privateKeyPath\" = \"~/.ssh/id\"\n'hopOrder' = 2\n",
+			want:   "\"private_key_path\" = \"~/.ssh/id\"\n'hop_order' = 2\n",
+		},
+		{
+			name:   "dotted keys",
+			source: "hop_overrides.bastion.timeoutSec = 10\n",
+			want:   "hop_overrides.bastion.timeout_sec = 10\n",
+		},
+		{
+			name:   "inline tables",
+			source: "hop_overrides = { bastion = { authType = \"password\", timeoutSec = 3 } }\n",
+			want:   "hop_overrides = { bastion = { auth_type = \"password\", timeout_sec = 3 } }\n",
+		},
+		{
+			name:   "values are not keys",
+			source: "alias = \"authType\"\nuser = 'timeoutSec = 1'\n",
+			want:   "alias = \"authType\"\nuser = 'timeoutSec = 1'\n",
+		},
+		{
+			name:   "escaped quotes inside strings",
+			source: "alias = \"a \\\" authType = 1\"\nauthType = \"x\"\n",
+			want:   "alias = \"a \\\" authType = 1\"\nauth_type = \"x\"\n",
+		},
+		{
+			name:   "multiline strings",
+			source: "body = \"\"\"\nauthType = \"x\"\n\"\"\"\nlit = '''\ntimeoutSec = 1\n'''\nhopOrder = 1\n",
+			want:   "body = \"\"\"\nauthType = \"x\"\n\"\"\"\nlit = '''\ntimeoutSec = 1\n'''\nhop_order = 1\n",
+		},
+		{
+			name:   "comments",
+			source: "# authType = \"password\"\nauthType = \"password\" # timeoutSec = 1\n",
+			want:   "# authType = \"password\"\nauth_type = \"password\" # timeoutSec = 1\n",
+		},
+		{
+			name:   "array values",
+			source: "hops = [\"authType\", \"hopOrder\"]\n",
+			want:   "hops = [\"authType\", \"hopOrder\"]\n",
+		},
+		{
+			name:   "table headers and unknown keys",
+			source: "[authType]\nauthTypes = 1\nmy_authType = 2\n",
+			want:   "[authType]\nauthTypes = 1\nmy_authType = 2\n",
+		},
+		{
+			name:   "crlf line endings",
+			source: "authType = \"password\"\r\ntimeoutSec = 5\r\n",
+			want:   "auth_type = \"password\"\r\ntimeout_sec = 5\r\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(renameKeys([]byte(tt.source), renames))
+			if got != tt.want {
+				t.Fatalf("renameKeys:\n got: %q\nwant: %q", got, tt.want)
+			}
+			// 改写后仍是合法的 TOML
+			if _, err := toml.Decode(got, &map[string]any{}); err != nil {
+				t.Fatalf("result does not parse: %v", err)
+			}
+		})
+	}
+}
+
+func TestMigrateSource(t *testing.T) {
+	tests := []struct {
+		name        string
+		source      string
+		wantVersion int
+		wantRenamed bool
+		wantErr     bool
+	}{
+		{name: "v1 without version", source: "[[ssh_hops]]\nauthType = \"password\"\n", wantVersion: 1, wantRenamed: true},
+		{name: "explicit v1", source: "version = 1\n[[ssh_hops]]\nauthType = \"password\"\n", wantVersion: 1, wantRenamed: true},
+		{name: "current version is not rewritten", source: "version = 2\n[[ssh_hops]]\nauthType = \"password\"\n", wantVersion: 2},
+		{name: "future version", source: "version = 99\n", wantVersion: 99, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrated, version, err := migrateSource([]byte(tt.source))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if version != tt.wantVersion {
+				t.Errorf("version = %d, want %d", version, tt.wantVersion)
+			}
+			if renamed := string(migrated) != tt.source; renamed != tt.wantRenamed {
+				t.Errorf("renamed = %v, want %v:\n%s", renamed, tt.wantRenamed, migrated)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package config_loader
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"ssh-messer/internal/secrets"
+	"ssh-messer/internal/ssh_proxy"
+	"ssh-messer/pkg"
+)
+
+// Config 引用解析
+// ------------------------------------------------------------
+
+// FieldError 解析某个字段的引用失败
+type FieldError struct {
+	Field string // TOML 字段路径，如 "ssh_hops[0].passphrase"
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Resolve 返回解析了密钥字段中 "${NAME}"、"env:"、"file:"、"cmd:"、"keyring:" 引用的配置副本
+// 只解析 hop 的 passphrase 与 private_key_path、services 与 [proxy] 中的 header 值、basic_auth、token 与证书/私钥路径，
+// 其他字段（如 mock body、host 正则）中的 "${" 与 "cmd:" 按字面量处理
+// 在连接时调用，原配置保持不变（界面与热更新比较使用未解析的值），解析结果不写入日志
+func (c *TomlConfig) Resolve() (*TomlConfig, error) {
+	return c.resolve(true, true)
+}
+
+// ResolveHops 只解析 hop 中的引用，services 保持未解析
+func (c *TomlConfig) ResolveHops() (*TomlConfig, error) {
+	return c.resolve(true, false)
+}
+
+// ResolveServices 只解析 services 与 [proxy] 中的引用，热更新 services 时不会重新执行 hop 的 cmd: 或读取钥匙串
+func (c *TomlConfig) ResolveServices() (*TomlConfig, error) {
+	return c.resolve(false, true)
+}
+
+func (c *TomlConfig) resolve(hops, services bool) (*TomlConfig, error) {
+	config := *c
+	var err error
+	if hops {
+		config.SSHHops, err = resolveHops(c.SSHHops)
+	}
+	if err == nil && services {
+		config.SSHServices, err = resolveServices(c.SSHServices)
+		if err == nil {
+			config.Proxy, err = resolveProxySettings(c.Proxy)
+		}
+	}
+	if err != nil {
+		pkg.Logger.Error().Err(err).Str("file", c.Path).Msg("[ConfigLoader] 解析配置引用失败")
+		return nil, err
+	}
+	return &config, nil
+}
+
+// resolveHops 返回解析后的 hop 副本
+func resolveHops(hops []ssh_proxy.SSHHopConfig) ([]ssh_proxy.SSHHopConfig, error) {
+	if hops == nil {
+		return nil, nil
+	}
+	resolved := make([]ssh_proxy.SSHHopConfig, len(hops))
+	copy(resolved, hops)
+	for i := range resolved {
+		hop := &resolved[i]
+		path := fmt.Sprintf("ssh_hops[%d]", i)
+		if err := resolveStrings(path,
+			stringField{"passphrase", &hop.Passphrase},
+			stringField{"private_key_path", &hop.PrivateKeyPath},
+		); err != nil {
+			return nil, err
+		}
+	}
+	return resolved, nil
+}
+
+// resolveServices 返回解析后的 service 副本
+func resolveServices(services []ssh_proxy.SSHService) ([]ssh_proxy.SSHService, error) {
+	if services == nil {
+		return nil, nil
+	}
+	resolved := make([]ssh_proxy.SSHService, len(services))
+	copy(resolved, services)
+	for i := range resolved {
+		service := &resolved[i]
+		path := fmt.Sprintf("services[%d]", i)
+		if err := resolveStrings(path,
+			stringField{"ca_file", &service.CAFile},
+			stringField{"client_cert", &service.ClientCert},
+			stringField{"client_key", &service.ClientKey},
+		); err != nil {
+			return nil, err
+		}
+
+		if service.RequestHeaders != nil {
+			headers := maps.Clone(service.RequestHeaders)
+			for _, name := range slices.Sorted(maps.Keys(headers)) {
+				value := headers[name]
+				if !secrets.IsReference(value) {
+					continue
+				}
+				resolvedValue, err := secrets.Resolve(value)
+				if err != nil {
+					return nil, &FieldError{Field: path + ".request_headers." + name, Err: err}
+				}
+				headers[name] = resolvedValue
+			}
+			service.RequestHeaders = headers
+		}
+
+		basicAuth, err := resolveBasicAuth(path+".basic_auth", service.BasicAuth)
+		if err != nil {
+			return nil, err
+		}
+		service.BasicAuth = basicAuth
+	}
+	return resolved, nil
+}
+
+// resolveProxySettings 返回解析后的 [proxy] 副本
+func resolveProxySettings(settings *ssh_proxy.ServiceProxySettings) (*ssh_proxy.ServiceProxySettings, error) {
+	if settings == nil {
+		return nil, nil
+	}
+	resolved := *settings
+	if err := resolveStrings("proxy",
+		stringField{"tls_cert", &resolved.TLSCert},
+		stringField{"tls_key", &resolved.TLSKey},
+	); err != nil {
+		return nil, err
+	}
+
+	if settings.Access != nil {
+		access := *settings.Access
+		if err := resolveStrings("proxy.access", stringField{"token", &access.Token}); err != nil {
+			return nil, err
+		}
+		basicAuth, err := resolveBasicAuth("proxy.access.basic_auth", access.BasicAuth)
+		if err != nil {
+			return nil, err
+		}
+		access.BasicAuth = basicAuth
+		resolved.Access = &access
+	}
+	return &resolved, nil
+}
+
+func resolveBasicAuth(path string, basicAuth *ssh_proxy.ServiceBasicAuth) (*ssh_proxy.ServiceBasicAuth, error) {
+	if basicAuth == nil {
+		return nil, nil
+	}
+	resolved := *basicAuth
+	if err := resolveStrings(path,
+		stringField{"username", &resolved.Username},
+		stringField{"password", &resolved.Password},
+	); err != nil {
+		return nil, err
+	}
+	return &resolved, nil
+}
+
+// stringField 需要解析的字段，value 指向配置副本中的字段
+type stringField struct {
+	name  string
+	value **string
+}
+
+// resolveStrings 按顺序解析字段中的引用，解析后的值替换为新指针，原配置不受影响
+func resolveStrings(path string, fields ...stringField) error {
+	for _, field := range fields {
+		value := *field.value
+		if value == nil || !secrets.IsReference(*value) {
+			continue
+		}
+		resolved, err := secrets.Resolve(*value)
+		if err != nil {
+			return &FieldError{Field: joinFieldPath(path, field.name), Err: err}
+		}
+		*field.value = &resolved
+	}
+	return nil
+}
+
+// tomlFieldName 返回字段在 TOML 中的名称
+func tomlFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("toml"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// ============================================================
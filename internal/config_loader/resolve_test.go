@@ -0,0 +1,147 @@
+package config_loader
+
+import (
+	"errors"
+	"testing"
+
+	"ssh-messer/internal/ssh_proxy"
+)
+
+func TestResolve(t *testing.T) {
+	t.Setenv("MESSER_TEST_SECRET", "s3cret")
+	t.Setenv("MESSER_TEST_USER", "admin")
+
+	tests := []struct {
+		name      string
+		config    TomlConfig
+		get       func(c *TomlConfig) string
+		want      string
+		wantField string // 期望解析失败的字段
+	}{
+		{
+			name:   "hop passphrase",
+			config: TomlConfig{SSHHops: []ssh_proxy.SSHHopConfig{{Passphrase: strPtr("${MESSER_TEST_SECRET}")}}},
+			get:    func(c *TomlConfig) string { return *c.SSHHops[0].Passphrase },
+			want:   "s3cret",
+		},
+		{
+			name:   "interpolation inside a value",
+			config: TomlConfig{SSHHops: []ssh_proxy.SSHHopConfig{{PrivateKeyPath: strPtr("/keys/${MESSER_TEST_USER}/id")}}},
+			get:    func(c *TomlConfig) string { return *c.SSHHops[0].PrivateKeyPath },
+			want:   "/keys/admin/id",
+		},
+		{
+			name:   "escaped interpolation",
+			config: TomlConfig{SSHHops: []ssh_proxy.SSHHopConfig{{Passphrase: strPtr("a$${MESSER_TEST_SECRET}b")}}},
+			get:    func(c *TomlConfig) string { return *c.SSHHops[0].Passphrase },
+			want:   "a${MESSER_TEST_SECRET}b",
+		},
+		{
+			name:   "request header",
+			config: TomlConfig{SSHServices: []ssh_proxy.SSHService{{RequestHeaders: map[string]string{"Authorization": "Bearer ${MESSER_TEST_SECRET}"}}}},
+			get:    func(c *TomlConfig) string { return c.SSHServices[0].RequestHeaders["Authorization"] },
+			want:   "Bearer s3cret",
+		},
+		{
+			name:   "service basic auth",
+			config: TomlConfig{SSHServices: []ssh_proxy.SSHService{{BasicAuth: &ssh_proxy.ServiceBasicAuth{Username: strPtr("env:MESSER_TEST_USER")}}}},
+			get:    func(c *TomlConfig) string { return *c.SSHServices[0].BasicAuth.Username },
+			want:   "admin",
+		},
+		{
+			name:   "proxy access token",
+			config: TomlConfig{Proxy: &ssh_proxy.ServiceProxySettings{Access: &ssh_proxy.AccessSettings{Token: strPtr("env:MESSER_TEST_SECRET")}}},
+			get:    func(c *TomlConfig) string { return *c.Proxy.Access.Token },
+			want:   "s3cret",
+		},
+		{
+			name:   "mock body is not resolved",
+			config: TomlConfig{SSHServices: []ssh_proxy.SSHService{{Mocks: []ssh_proxy.ServiceMock{{Body: strPtr("`${MESSER_TEST_UNSET}`")}}}}},
+			get:    func(c *TomlConfig) string { return *c.SSHServices[0].Mocks[0].Body },
+			want:   "`${MESSER_TEST_UNSET}`",
+		},
+		{
+			name:   "other fields are not run as commands",
+			config: TomlConfig{SSHServices: []ssh_proxy.SSHService{{Hosts: []string{"cmd:exit 1"}, Host: strPtr("cmd:exit 1")}}},
+			get:    func(c *TomlConfig) string { return *c.SSHServices[0].Host },
+			want:   "cmd:exit 1",
+		},
+		{
+			name:      "unset variable",
+			config:    TomlConfig{SSHServices: []ssh_proxy.SSHService{{}, {RequestHeaders: map[string]string{"X-Token": "${MESSER_TEST_UNSET}"}}}},
+			wantField: "services[1].request_headers.X-Token",
+		},
+		{
+			name:      "unset variable in proxy basic auth",
+			config:    TomlConfig{Proxy: &ssh_proxy.ServiceProxySettings{Access: &ssh_proxy.AccessSettings{BasicAuth: &ssh_proxy.ServiceBasicAuth{Password: strPtr("env:MESSER_TEST_UNSET")}}}},
+			wantField: "proxy.access.basic_auth.password",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := tt.config.Resolve()
+			if tt.wantField != "" {
+				var fieldErr *FieldError
+				if !errors.As(err, &fieldErr) || fieldErr.Field != tt.wantField {
+					t.Fatalf("err = %v, want a FieldError for %s", err, tt.wantField)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Resolve: %v", err)
+			}
+			if got := tt.get(resolved); got != tt.want {
+				t.Errorf("resolved = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveKeepsOriginal(t *testing.T) {
+	t.Setenv("MESSER_TEST_SECRET", "s3cret")
+	config := &TomlConfig{
+		SSHHops:     []ssh_proxy.SSHHopConfig{{Passphrase: strPtr("env:MESSER_TEST_SECRET")}},
+		SSHServices: []ssh_proxy.SSHService{{RequestHeaders: map[string]string{"X-Token": "env:MESSER_TEST_SECRET"}, BasicAuth: &ssh_proxy.ServiceBasicAuth{Password: strPtr("env:MESSER_TEST_SECRET")}}},
+		Proxy:       &ssh_proxy.ServiceProxySettings{Access: &ssh_proxy.AccessSettings{Token: strPtr("env:MESSER_TEST_SECRET")}},
+	}
+	if _, err := config.Resolve(); err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	for field, value := range map[string]string{
+		"passphrase":     *config.SSHHops[0].Passphrase,
+		"request header": config.SSHServices[0].RequestHeaders["X-Token"],
+		"basic auth":     *config.SSHServices[0].BasicAuth.Password,
+		"access token":   *config.Proxy.Access.Token,
+	} {
+		if value != "env:MESSER_TEST_SECRET" {
+			t.Errorf("original %s was modified: %q", field, value)
+		}
+	}
+}
+
+func TestResolveSections(t *testing.T) {
+	t.Setenv("MESSER_TEST_SECRET", "s3cret")
+	config := &TomlConfig{
+		// 解析 hop 时命令会失败
+		SSHHops:     []ssh_proxy.SSHHopConfig{{Passphrase: strPtr("cmd:exit 1")}},
+		SSHServices: []ssh_proxy.SSHService{{RequestHeaders: map[string]string{"X-Token": "env:MESSER_TEST_SECRET"}}},
+	}
+
+	resolved, err := config.ResolveServices()
+	if err != nil {
+		t.Fatalf("ResolveServices: %v", err)
+	}
+	if got := resolved.SSHServices[0].RequestHeaders["X-Token"]; got != "s3cret" {
+		t.Errorf("request header = %q, want %q", got, "s3cret")
+	}
+	if got := *resolved.SSHHops[0].Passphrase; got != "cmd:exit 1" {
+		t.Errorf("hop passphrase = %q, want it unresolved", got)
+	}
+
+	var fieldErr *FieldError
+	if _, err := config.ResolveHops(); !errors.As(err, &fieldErr) || fieldErr.Field != "ssh_hops[0].passphrase" {
+		t.Errorf("ResolveHops err = %v, want a FieldError for ssh_hops[0].passphrase", err)
+	}
+}
+
+func strPtr(value string) *string { return &value }
@@ -0,0 +1,253 @@
+package config_loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+
+	"ssh-messer/internal/ssh_proxy"
+)
+
+// Config JSON Schema
+// ------------------------------------------------------------
+
+const (
+	// ConfigSchemaFileName 配置文件的 JSON Schema，供 Taplo / Even Better TOML 补全与校验
+	ConfigSchemaFileName = "config.schema.json"
+	// HopLibrarySchemaFileName hops.toml 的 JSON Schema
+	HopLibrarySchemaFileName = "hops.schema.json"
+	// TaploConfigFileName Taplo 的配置文件，按文件名关联上面的 schema
+	TaploConfigFileName = ".taplo.toml"
+)
+
+// schemaDescriptions 字段说明，键为 "结构体名.toml 字段名"
+var schemaDescriptions = map[string]string{
+	"TomlConfig.version":               fmt.Sprintf("Config format version. Files without it use version 1; run `migrate` to upgrade them to %d.", CurrentConfigVersion),
+	"TomlConfig.name":                  "Display name in the config list, defaults to the file name.",
+	"TomlConfig.ssh_hops":              "SSH hops in connection order, the last hop reaches the services.",
+	"TomlConfig.services":              "Remote HTTP services exposed locally through the SSH tunnel.",
+	"TomlConfig.local_http_port":       "Local port of the HTTP proxy, services are served at <subdomain>.localhost:<port>.",
+	"TomlConfig.local_docker_port":     "Local port forwarded to the remote Docker socket.",
+	"TomlConfig.health_check_interval": "Seconds between SSH connection health checks.",
+	"TomlConfig.proxy":                 "Settings of the local service proxy.",
+	"TomlConfig.extends":               "Another config in the same directory to inherit from; top-level fields set here replace the inherited ones.",
+	"TomlConfig.hops":                  "Named hops from hops.toml, placed before [[ssh_hops]].",
+	"TomlConfig.hop_overrides":         "Per-name overrides of fields of the named hops.",
+
+	"SSHHopConfig.order":            "Position of the hop in the chain, starting at 1.",
+	"SSHHopConfig.host":             "SSH server host name or address.",
+	"SSHHopConfig.port":             "SSH server port, usually 22.",
+	"SSHHopConfig.auth_type":        "How to authenticate on this hop.",
+	"SSHHopConfig.private_key_path": "Private key file, required for key authentication.",
+	"SSHHopConfig.passphrase":       "Key passphrase or password. Supports ${ENV}, env:, file:, cmd: and keyring:[service/]account references.",
+	"SSHHopConfig.user":             "SSH user name.",
+	"SSHHopConfig.alias":            "Name of the hop shown in the TUI.",
+	"SSHHopConfig.timeout_sec":      "Connection timeout in seconds.",
+
+	"SSHService.host":                 "Remote host of the service as seen from the hop.",
+	"SSHService.port":                 "Remote port of the service.",
+	"SSHService.subdomain":            "Local subdomain, the service is served at <subdomain>.localhost.",
+	"SSHService.alias":                "Name of the service shown in the TUI.",
+	"SSHService.use_tls":              "Connect to the upstream with TLS.",
+	"SSHService.tls_server_name":      "Server name used for TLS verification, defaults to host.",
+	"SSHService.remote_host":          "Host header sent upstream, defaults to host.",
+	"SSHService.pages":                "Shortcut pages listed for the service.",
+	"SSHService.hop_order":            "Hop used to reach the service, defaults to the last hop.",
+	"SSHService.hosts":                "Extra Host values to match: exact, \"*.dev.localhost\" wildcards or regular expressions starting with \"~\".",
+	"SSHService.routes":               "Path prefixes routed to this service.",
+	"SSHService.default":              "Forward requests that match no route to this service.",
+	"SSHService.rewrite_html":         "Rewrite absolute links in uncompressed HTML responses under the route prefix. Responses larger than 4 MiB are forwarded unchanged.",
+	"SSHService.forwarded_prefix":     "Send X-Forwarded-Prefix upstream.",
+	"SSHService.request_headers":      "Headers set before forwarding, values support ${ENV}, env:, file:, cmd: and keyring:[service/]account references.",
+	"SSHService.remove_headers":       "Headers removed before forwarding.",
+	"SSHService.basic_auth":           "Basic authentication added to upstream requests. Username and password support the same references as passphrase.",
+	"SSHService.ca_file":              "Private CA certificate (PEM) added to the system roots.",
+	"SSHService.client_cert":          "mTLS client certificate (PEM).",
+	"SSHService.client_key":           "mTLS client private key (PEM).",
+	"SSHService.min_tls_version":      "Minimum upstream TLS version.",
+	"SSHService.insecure_skip_verify": "Skip upstream certificate verification, for testing only.",
+	"SSHService.targets":              "Multiple upstream targets, replaces host and port.",
+	"SSHService.balance":              "How requests are spread over targets.",
+	"SSHService.health_check":         "Active health check, without it health is inferred from requests.",
+	"SSHService.retry":                "Retries of idempotent requests without body when connecting to the upstream fails (timeouts are not retried).",
+	"SSHService.circuit_breaker":      "Return 503 for a while after consecutive failures.",
+	"SSHService.faults":               "Traffic shaping and fault injection.",
+	"SSHService.mocks":                "Local mock responses, matched in order without using the tunnel.",
+	"SSHService.protocol":             "Upstream protocol: http1, h2 (TLS with ALPN) or h2c (cleartext HTTP/2, e.g. gRPC).",
+	"SSHService.compression":          "Request compressed responses from the upstream.",
+	"SSHService.cache":                "Local HTTP cache following Cache-Control and ETag.",
+
+	"ServiceBasicAuth.username": "Basic authentication user name. Supports ${ENV}, env:, file:, cmd: and keyring:[service/]account references.",
+	"ServiceBasicAuth.password": "Basic authentication password. Supports ${ENV}, env:, file:, cmd: and keyring:[service/]account references.",
+
+	"AccessSettings.token":       "Token accepted from the X-Messer-Token header, the messer_token cookie or ?messer_token=. Supports ${ENV}, env:, file:, cmd: and keyring:[service/]account references.",
+	"AccessSettings.basic_auth":  "Basic authentication required to use the local proxy.",
+	"AccessSettings.allow_cidrs": "Client address ranges allowed to use the local proxy, e.g. \"127.0.0.1/32\".",
+
+	"hopLibrary.version": "Format version of hops.toml.",
+	"hopLibrary.hops":    "Named hops referenced by hops = [\"name\"] in configs.",
+}
+
+// schemaEnums 字段的可选值，键与 schemaDescriptions 相同
+var schemaEnums = map[string][]any{
+	"TomlConfig.version":         {CurrentConfigVersion},
+	"hopLibrary.version":         {CurrentConfigVersion},
+	"SSHHopConfig.auth_type":     toAnySlice(validAuthTypes),
+	"SSHService.balance":         {ssh_proxy.BalanceRoundRobin, ssh_proxy.BalanceFailover},
+	"SSHService.protocol":        {ssh_proxy.ProtocolHTTP1, ssh_proxy.ProtocolH2, ssh_proxy.ProtocolH2C},
+	"SSHService.min_tls_version": {"1.0", "1.1", "1.2", "1.3"},
+}
+
+// ConfigSchema 生成配置文件的 JSON Schema（draft-07）
+func ConfigSchema() ([]byte, error) {
+	return newSchemaGenerator().document("ssh-messer config", reflect.TypeOf(TomlConfig{}))
+}
+
+// HopLibrarySchema 生成 hops.toml 的 JSON Schema（draft-07）
+func HopLibrarySchema() ([]byte, error) {
+	return newSchemaGenerator().document("ssh-messer hops.toml", reflect.TypeOf(hopLibrary{}))
+}
+
+// InstallSchemas 将 schema 与关联它们的 .taplo.toml 写入 dir，已有的 .taplo.toml 不覆盖
+// 返回写入的文件
+func InstallSchemas(dir string) ([]string, error) {
+	configSchema, err := ConfigSchema()
+	if err != nil {
+		return nil, err
+	}
+	hopSchema, err := HopLibrarySchema()
+	if err != nil {
+		return nil, err
+	}
+
+	files := []struct {
+		name    string
+		content []byte
+	}{
+		{ConfigSchemaFileName, configSchema},
+		{HopLibrarySchemaFileName, hopSchema},
+		{TaploConfigFileName, []byte(taploConfig())},
+	}
+	var written []string
+	for _, file := range files {
+		path := filepath.Join(dir, file.name)
+		if file.name == TaploConfigFileName && fileExists(path) {
+			continue
+		}
+		if err := writeFileAtomic(path, file.content, 0644); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	return written, nil
+}
+
+// taploConfig Taplo 的规则：hops.toml 使用 hops.schema.json，其他 .toml 使用 config.schema.json
+func taploConfig() string {
+	return fmt.Sprintf(`# Generated by ssh-messer: associates the config files in this directory with their JSON Schema.
+include = ["**/*.toml"]
+
+[[rule]]
+include = ["**/%[1]s"]
+schema = { path = "./%[2]s" }
+
+[[rule]]
+include = ["**/*.toml"]
+exclude = ["**/%[1]s", "**/%[4]s"]
+schema = { path = "./%[3]s" }
+`, HopLibraryFileName, HopLibrarySchemaFileName, ConfigSchemaFileName, TaploConfigFileName)
+}
+
+// schemaGenerator 通过反射 toml tag 生成 schema，结构体放在 definitions 中按名称引用
+type schemaGenerator struct {
+	definitions map[string]any
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{definitions: make(map[string]any)}
+}
+
+func (g *schemaGenerator) document(title string, root reflect.Type) ([]byte, error) {
+	schema := g.structSchema(root)
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = title
+	if len(g.definitions) > 0 {
+		schema["definitions"] = g.definitions
+	}
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// structSchema 结构体的 schema，未知字段视为错误以便提示拼写错误与旧版本字段名
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]any {
+	properties := make(map[string]any)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := tomlFieldName(field)
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		key := t.Name() + "." + name
+		property := g.typeSchema(field.Type)
+		if _, isRef := property["$ref"]; isRef {
+			// draft-07 中 $ref 旁边的关键字会被忽略，说明放在外层
+			property = map[string]any{"allOf": []any{property}}
+		}
+		if description, exists := schemaDescriptions[key]; exists {
+			property["description"] = description
+		}
+		if enum, exists := schemaEnums[key]; exists {
+			property["enum"] = enum
+		}
+		properties[name] = property
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+}
+
+func (g *schemaGenerator) typeSchema(t reflect.Type) map[string]any {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": g.typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.typeSchema(t.Elem())}
+	case reflect.Struct:
+		name := t.Name()
+		if _, exists := g.definitions[name]; !exists {
+			// 先占位，避免递归引用时重复生成
+			g.definitions[name] = nil
+			g.definitions[name] = g.structSchema(t)
+		}
+		return map[string]any{"$ref": "#/definitions/" + name}
+	}
+	return map[string]any{}
+}
+
+func toAnySlice(values []string) []any {
+	result := make([]any, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+	return result
+}
+
+// ============================================================
@@ -0,0 +1,196 @@
+package config_loader
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"ssh-messer/pkg"
+)
+
+// Config 来源
+// ------------------------------------------------------------
+const (
+	configDirEnv       = "MESSER_CONFIG_DIR" // 配置目录，多个目录用系统路径分隔符分隔
+	xdgConfigFolder    = "ssh_messer"        // $XDG_CONFIG_HOME/ssh_messer
+	ProjectConfigName  = ".ssh_messer.toml"  // 从当前目录向上查找的项目配置
+	projectSourceLabel = "project"
+)
+
+var (
+	configDirMu       sync.RWMutex
+	configDirOverride string
+)
+
+// SetConfigDir 使用指定目录替代默认的配置来源（命令行 --config-dir）
+func SetConfigDir(dir string) {
+	configDirMu.Lock()
+	defer configDirMu.Unlock()
+	configDirOverride = dir
+}
+
+// ConfigDirs 返回按优先级排序的配置目录：
+// --config-dir > MESSER_CONFIG_DIR > $XDG_CONFIG_HOME/ssh_messer 与 ~/.ssh_messer
+// 默认目录即使不存在也会返回 ~/.ssh_messer（作为新配置的写入位置）
+func ConfigDirs() []string {
+	configDirMu.RLock()
+	override := configDirOverride
+	configDirMu.RUnlock()
+
+	if override != "" {
+		return []string{expandConfigPath(override)}
+	}
+	if value := os.Getenv(configDirEnv); value != "" {
+		var dirs []string
+		for _, dir := range filepath.SplitList(value) {
+			if dir != "" {
+				dirs = append(dirs, expandConfigPath(dir))
+			}
+		}
+		if len(dirs) > 0 {
+			return dirs
+		}
+	}
+
+	var dirs []string
+	if xdgDir := xdgConfigDir(); xdgDir != "" {
+		if info, err := os.Stat(xdgDir); err == nil && info.IsDir() {
+			dirs = append(dirs, xdgDir)
+		}
+	}
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(homeDir, homeConfigFolder))
+	}
+	return dirs
+}
+
+func xdgConfigDir() string {
+	if xdgHome := os.Getenv("XDG_CONFIG_HOME"); xdgHome != "" {
+		return filepath.Join(xdgHome, xdgConfigFolder)
+	}
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		return filepath.Join(homeDir, ".config", xdgConfigFolder)
+	}
+	return ""
+}
+
+// configFile 发现的配置文件
+type configFile struct {
+	path    string // 完整路径
+	name    string // 在 AppState.Configs 中的名称（相对来源目录的路径）
+	rootDir string // 来源目录，共享 hops.toml 在文件所在目录到来源目录之间查找
+	group   string // 子目录分组，根目录下的配置为空
+	source  string // 展示用的来源描述
+}
+
+// discoverConfigFiles 列出所有来源中的配置文件，同名配置以优先级高的来源为准
+func discoverConfigFiles() []configFile {
+	var files []configFile
+	seen := make(map[string]string)
+
+	add := func(file configFile) {
+		if previous, exists := seen[file.name]; exists {
+			pkg.Logger.Warn().Str("config", file.name).Str("used", previous).Str("ignored", file.path).Msg("[ConfigLoader] 配置名称重复，忽略优先级较低的来源")
+			return
+		}
+		seen[file.name] = file.path
+		files = append(files, file)
+	}
+
+	if projectFile := findProjectConfig(); projectFile != "" {
+		add(configFile{
+			path:    projectFile,
+			name:    ProjectConfigName,
+			rootDir: filepath.Dir(projectFile),
+			source:  projectSourceLabel + " " + displayPath(projectFile),
+		})
+	}
+
+	for _, dir := range ConfigDirs() {
+		filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if entry.IsDir() {
+				// 跳过隐藏目录（如备份、.git）
+				if path != dir && strings.HasPrefix(entry.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			// 隐藏文件（如 .taplo.toml）不是配置
+			if filepath.Ext(path) != ".toml" || entry.Name() == HopLibraryFileName || strings.HasPrefix(entry.Name(), ".") {
+				return nil
+			}
+
+			relative, err := filepath.Rel(dir, path)
+			if err != nil {
+				return nil
+			}
+			group := filepath.ToSlash(filepath.Dir(relative))
+			if group == "." {
+				group = ""
+			}
+			add(configFile{
+				path:    path,
+				name:    filepath.ToSlash(relative),
+				rootDir: dir,
+				group:   group,
+				source:  displayPath(filepath.Dir(path)),
+			})
+			return nil
+		})
+	}
+	return files
+}
+
+// findProjectConfig 从当前目录向上查找 .ssh_messer.toml，到达用户主目录或根目录为止
+func findProjectConfig() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	homeDir, _ := os.UserHomeDir()
+
+	for {
+		candidate := filepath.Join(dir, ProjectConfigName)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir || dir == homeDir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// expandConfigPath 展开 "~" 并转换为绝对路径
+func expandConfigPath(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			path = homeDir + strings.TrimPrefix(path, "~")
+		}
+	}
+	if absolute, err := filepath.Abs(path); err == nil {
+		return absolute
+	}
+	return path
+}
+
+// displayPath 将主目录替换为 "~" 用于展示
+func displayPath(path string) string {
+	if homeDir, err := os.UserHomeDir(); err == nil && homeDir != "" {
+		if path == homeDir {
+			return "~"
+		}
+		if strings.HasPrefix(path, homeDir+string(filepath.Separator)) {
+			return "~" + strings.TrimPrefix(path, homeDir)
+		}
+	}
+	return path
+}
+
+// ============================================================
@@ -6,8 +6,6 @@ import (
 	"path/filepath"
 
 	"ssh-messer/pkg"
-
-	"github.com/BurntSushi/toml"
 )
 
 const homeConfigFolder = ".ssh_messer"
@@ -22,6 +20,11 @@ func LoadTomlProxyConfig(filename string, dir ...string) (*TomlConfig, error) {
 		fullPath = filepath.Join("configs", filename)
 	}
 
+	return loadConfigFile(fullPath, filepath.Dir(fullPath))
+}
+
+// loadConfigFile 加载、展开并校验配置文件，rootDir 为所属的配置目录
+func loadConfigFile(fullPath, rootDir string) (*TomlConfig, error) {
 	pkg.Logger.Debug().Str("file", fullPath).Msg("[ConfigLoader] 开始加载配置文件")
 
 	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
@@ -29,55 +32,72 @@ func LoadTomlProxyConfig(filename string, dir ...string) (*TomlConfig, error) {
 		return nil, fmt.Errorf("配置文件不存在: %s", fullPath)
 	}
 
-	var proxyConfig TomlConfig
+	source, err := os.ReadFile(fullPath)
+	if err != nil {
+		pkg.Logger.Error().Err(err).Str("file", fullPath).Msg("[ConfigLoader] 配置文件读取失败")
+		return nil, fmt.Errorf("failed to read TOML file %s: %w", fullPath, err)
+	}
+
+	proxyConfig := TomlConfig{Path: fullPath, rootDir: rootDir}
 
-	// 加载 TOML 文件
-	if _, err := toml.DecodeFile(fullPath, &proxyConfig); err != nil {
+	// 加载 TOML 文件，旧版本格式先升级（只改写键名，行号不变，用于定位错误）
+	proxyConfig.source, err = decodeConfig(source, &proxyConfig)
+	if err != nil {
 		pkg.Logger.Error().Err(err).Str("file", fullPath).Msg("[ConfigLoader] 配置文件加载失败")
 		return nil, fmt.Errorf("failed to decode TOML file %s: %w", fullPath, err)
 	}
 
+	// 先展开 extends 与命名 hop，再校验展开后的配置
+	expandErrs := expandConfig(&proxyConfig, rootDir)
+	if errs := append(expandErrs, proxyConfig.Validate()...); len(errs) > 0 {
+		proxyConfig.Errors = errs
+		pkg.Logger.Warn().Str("file", fullPath).Int("error_count", len(errs)).Msg("[ConfigLoader] 配置文件校验未通过")
+		return &proxyConfig, errs
+	}
+
 	pkg.Logger.Info().Str("file", fullPath).Msg("[ConfigLoader] 配置文件加载成功")
 	return &proxyConfig, nil
 }
 
-// LoadTomlConfigsFromHomeDir 从用户主目录加载所有 TOML 配置
-func LoadTomlConfigsFromHomeDir() (map[string]*TomlConfig, error) {
-	pkg.Logger.Debug().Msg("[ConfigLoader] 开始从主目录加载配置")
-
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		pkg.Logger.Error().Err(err).Msg("[ConfigLoader] 获取用户主目录失败")
-		return nil, err
+// PrimaryConfigDir 返回优先级最高的配置目录（新配置的写入位置）
+func PrimaryConfigDir() (string, error) {
+	dirs := ConfigDirs()
+	if len(dirs) == 0 {
+		return "", fmt.Errorf("no config directory available")
 	}
+	return dirs[0], nil
+}
 
-	configDir := filepath.Join(homeDir, homeConfigFolder)
-	configs := make(map[string]*TomlConfig)
-
-	if _, err := os.Stat(configDir); os.IsNotExist(err) {
-		pkg.Logger.Debug().Str("dir", configDir).Msg("[ConfigLoader] 配置目录不存在")
-		return configs, nil
-	}
+// LoadTomlConfigs 从所有配置来源（配置目录及其子目录分组、项目配置）加载 TOML 配置
+func LoadTomlConfigs() (map[string]*TomlConfig, error) {
+	pkg.Logger.Debug().Strs("dirs", ConfigDirs()).Msg("[ConfigLoader] 开始加载配置")
 
-	files, err := filepath.Glob(filepath.Join(configDir, "*.toml"))
-	if err != nil {
-		pkg.Logger.Error().Err(err).Str("dir", configDir).Msg("[ConfigLoader] 查找配置文件失败")
-		return configs, nil
-	}
+	configs := make(map[string]*TomlConfig)
+	files := discoverConfigFiles()
 
-	pkg.Logger.Debug().Int("file_count", len(files)).Str("dir", configDir).Msg("[ConfigLoader] 找到的配置文件数量")
+	pkg.Logger.Debug().Int("file_count", len(files)).Msg("[ConfigLoader] 找到的配置文件数量")
 
 	successCount := 0
 	for _, file := range files {
-		config, err := LoadTomlProxyConfig(filepath.Base(file), configDir)
+		config, err := loadConfigFile(file.path, file.rootDir)
 		if err != nil {
-			// 记录错误但不中断加载过程
-			pkg.Logger.Warn().Err(err).Str("file", file).Msg("[ConfigLoader] 加载配置文件失败")
-			continue
+			// 记录错误但不中断加载过程，无效的配置带着错误一起返回以便在列表中展示
+			pkg.Logger.Warn().Err(err).Str("file", file.path).Msg("[ConfigLoader] 加载配置文件失败")
+			if config == nil {
+				config = &TomlConfig{Path: file.path, Errors: decodeError(file.path, err)}
+			}
+		} else {
+			successCount++
 		}
 
-		configs[filepath.Base(file)] = config
-		successCount++
+		config.Source = file.source
+		config.Group = file.group
+		if file.name == ProjectConfigName && config.Name == nil {
+			// 项目配置默认使用所在目录名
+			name := filepath.Base(filepath.Dir(file.path))
+			config.Name = &name
+		}
+		configs[file.name] = config
 	}
 
 	pkg.Logger.Info().Int("success_count", successCount).Int("total_count", len(files)).Msg("[ConfigLoader] 成功加载的配置数量")
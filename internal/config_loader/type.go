@@ -6,10 +6,29 @@ import (
 
 // TomlConfig TOML 配置结构（保留原有结构）
 type TomlConfig struct {
-	Name                    *string                  `toml:"name,omitempty"`
-	SSHHops                 []ssh_proxy.SSHHopConfig `toml:"ssh_hops"`
-	SSHServices             []ssh_proxy.SSHService   `toml:"services"`
-	LocalHttpPort           *string                  `toml:"local_http_port,omitempty"`
-	LocalDockerPort         *string                  `toml:"local_docker_port,omitempty"`
-	HealthCheckIntervalSecs *int                     `toml:"health_check_interval,omitempty"`
+	// 配置格式版本，未设置时视为 1，加载时自动升级到 CurrentConfigVersion
+	Version                 *int                            `toml:"version,omitempty"`
+	Name                    *string                         `toml:"name,omitempty"`
+	SSHHops                 []ssh_proxy.SSHHopConfig        `toml:"ssh_hops"`
+	SSHServices             []ssh_proxy.SSHService          `toml:"services"`
+	LocalHttpPort           *string                         `toml:"local_http_port,omitempty"`
+	LocalDockerPort         *string                         `toml:"local_docker_port,omitempty"`
+	HealthCheckIntervalSecs *int                            `toml:"health_check_interval,omitempty"`
+	Proxy                   *ssh_proxy.ServiceProxySettings `toml:"proxy,omitempty"`
+	// 继承同目录中的另一个配置，本文件设置的顶层字段整体覆盖被继承的值
+	Extends *string `toml:"extends,omitempty"`
+	// 引用 hops.toml 中的命名 hop（按顺序，排在 [[ssh_hops]] 之前），hop_overrides 按名称覆盖字段
+	Hops         []string                          `toml:"hops,omitempty"`
+	HopOverrides map[string]ssh_proxy.SSHHopConfig `toml:"hop_overrides,omitempty"`
+
+	// 以下字段不来自 TOML
+	Path    string           `toml:"-"` // 配置文件路径
+	Source  string           `toml:"-"` // 配置来源（所在目录或项目配置），在配置列表中展示
+	Group   string           `toml:"-"` // 配置目录下的子目录分组
+	Errors  ValidationErrors `toml:"-"` // 解析或校验发现的问题，非空时不能连接
+	source  []byte           // 原始文本，用于定位错误所在行
+	rootDir string           // 所属的配置目录，编辑器校验时用于查找 hops.toml
+	// 从 extends 继承的顶层字段（toml 字段名）所在的文件，以及展开后每个 hop 的定义位置，用于定位问题
+	origins    map[string]*configSource
+	hopSources []hopSource
 }
@@ -0,0 +1,374 @@
+package config_loader
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"ssh-messer/internal/secrets"
+	"ssh-messer/pkg"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config 校验
+// ------------------------------------------------------------
+
+// 支持的 SSH 认证类型
+var validAuthTypes = []string{"privateKey", "privateKeyWithPassphrase", "password"}
+
+// ValidationError 单个配置问题，Line 为 0 表示无法定位到具体行
+type ValidationError struct {
+	File    string
+	Line    int
+	Field   string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	location := e.File
+	if e.Line > 0 {
+		location = fmt.Sprintf("%s:%d", e.File, e.Line)
+	}
+	if e.Field == "" {
+		return fmt.Sprintf("%s: %s", location, e.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, e.Field, e.Message)
+}
+
+// ValidationErrors 一个配置文件中的所有问题
+type ValidationErrors []ValidationError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n")
+}
+
+// Validate 检查配置中的所有问题（不会在第一个错误处停止），结果同时保存在 Errors 中
+func (c *TomlConfig) Validate() ValidationErrors {
+	v := &validator{config: c}
+
+	v.validateHops()
+	v.validateServices()
+	v.validatePorts()
+
+	c.Errors = v.errs
+	return v.errs
+}
+
+// IsValid 配置是否通过校验
+func (c *TomlConfig) IsValid() bool {
+	return len(c.Errors) == 0
+}
+
+// decodeError 将 TOML 解析错误转换为带行号的校验错误
+func decodeError(path string, err error) ValidationErrors {
+	validationErr := ValidationError{File: filepath.Base(path), Message: err.Error()}
+	var parseErr toml.ParseError
+	if errors.As(err, &parseErr) {
+		validationErr.Line = parseErr.Position.Line
+		validationErr.Message = parseErr.Message
+	}
+	return ValidationErrors{validationErr}
+}
+
+type validator struct {
+	config *TomlConfig
+	errs   ValidationErrors
+}
+
+// addf 记录问题，table 为 "ssh_hops" / "services" 等数组表名（顶层字段为空），index 为数组下标
+// 问题定位到定义该字段的文件：继承的字段指向基础配置，命名 hop 指向 hops.toml
+func (v *validator) addf(table string, index int, key string, format string, args ...any) {
+	source, table, index := v.locate(table, index, key)
+	field := key
+	if table != "" {
+		field = table
+		if index >= 0 {
+			field = fmt.Sprintf("%s[%d]", table, index)
+		}
+		if key != "" {
+			field += "." + key
+		}
+	}
+	v.errs = append(v.errs, ValidationError{
+		File:    source.file,
+		Line:    source.lines.find(table, index, key),
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// locate 将展开后配置中的位置换算为定义所在的文件与表，index 为 -1 表示普通表
+func (v *validator) locate(table string, index int, key string) (*configSource, string, int) {
+	if table == "" {
+		return v.config.sourceOf(key), table, index
+	}
+	if table == "ssh_hops" && index < len(v.config.hopSources) {
+		hop := v.config.hopSources[index]
+		if hop.name != "" && key != "" && hasTomlField(v.config.HopOverrides[hop.name], key) {
+			return v.config.sourceOf("hop_overrides"), "hop_overrides." + hop.name, -1
+		}
+		return hop.source, hop.table, hop.index
+	}
+	return v.config.sourceOf(table), table, index
+}
+
+func (v *validator) validateHops() {
+	hops := v.config.SSHHops
+	if len(hops) == 0 {
+		v.addf("", 0, "ssh_hops", "at least one [[ssh_hops]] entry is required")
+		return
+	}
+
+	orders := make(map[int]int)
+	for i, hop := range hops {
+		if isBlank(hop.Host) {
+			v.addf("ssh_hops", i, "host", "host is required")
+		}
+		if isBlank(hop.User) {
+			v.addf("ssh_hops", i, "user", "user is required")
+		}
+		if hop.Port != nil && (*hop.Port < 1 || *hop.Port > 65535) {
+			v.addf("ssh_hops", i, "port", "port %d is out of range 1-65535", *hop.Port)
+		}
+		if hop.Order != nil {
+			if previous, exists := orders[*hop.Order]; exists {
+				v.addf("ssh_hops", i, "order", "order %d is already used by ssh_hops[%d]", *hop.Order, previous)
+			} else {
+				orders[*hop.Order] = i
+			}
+		}
+
+		switch {
+		case isBlank(hop.AuthType):
+			v.addf("ssh_hops", i, "auth_type", "auth_type is required (one of %s)", strings.Join(validAuthTypes, ", "))
+		case *hop.AuthType == "password":
+			if hop.Passphrase == nil {
+				v.addf("ssh_hops", i, "passphrase", "passphrase is required for password auth")
+			}
+		case *hop.AuthType == "privateKey" || *hop.AuthType == "privateKeyWithPassphrase":
+			if isBlank(hop.PrivateKeyPath) {
+				v.addf("ssh_hops", i, "private_key_path", "private_key_path is required for %s auth", *hop.AuthType)
+			} else if err := checkFileReadable(*hop.PrivateKeyPath); err != nil && !secrets.IsReference(*hop.PrivateKeyPath) {
+				v.addf("ssh_hops", i, "private_key_path", "%v", err)
+			}
+			if *hop.AuthType == "privateKeyWithPassphrase" && hop.Passphrase == nil {
+				v.addf("ssh_hops", i, "passphrase", "passphrase is required for privateKeyWithPassphrase auth")
+			}
+		default:
+			v.addf("ssh_hops", i, "auth_type", "unknown auth_type %q (expected one of %s)", *hop.AuthType, strings.Join(validAuthTypes, ", "))
+		}
+	}
+}
+
+func (v *validator) validateServices() {
+	hopCount := len(v.config.SSHHops)
+	subdomains := make(map[string]int)
+	defaults := -1
+
+	for i, service := range v.config.SSHServices {
+		if len(service.Targets) == 0 {
+			if isBlank(service.Host) {
+				v.addf("services", i, "host", "host is required (or configure [[services.targets]])")
+			}
+			if isBlank(service.Port) {
+				v.addf("services", i, "port", "port is required (or configure [[services.targets]])")
+			}
+		}
+		if service.Port != nil && *service.Port != "" && !validPort(*service.Port) {
+			v.addf("services", i, "port", "port %q is not a valid port number", *service.Port)
+		}
+
+		if isBlank(service.Subdomain) {
+			if len(service.Routes) == 0 && len(service.Hosts) == 0 && (service.Default == nil || !*service.Default) {
+				v.addf("services", i, "subdomain", "subdomain is required unless hosts, routes or default are configured")
+			}
+		} else {
+			subdomain := strings.ToLower(*service.Subdomain)
+			if previous, exists := subdomains[subdomain]; exists {
+				v.addf("services", i, "subdomain", "duplicate subdomain %q (already used by services[%d])", *service.Subdomain, previous)
+			} else {
+				subdomains[subdomain] = i
+			}
+		}
+
+		if service.Default != nil && *service.Default {
+			if defaults >= 0 {
+				v.addf("services", i, "default", "only one service can be the default (services[%d] is already default)", defaults)
+			} else {
+				defaults = i
+			}
+		}
+
+		if service.HopOrder != nil && (*service.HopOrder < 0 || *service.HopOrder > hopCount) {
+			v.addf("services", i, "hop_order", "hop_order %d is out of range 0-%d (number of ssh_hops)", *service.HopOrder, hopCount)
+		}
+		for j, target := range service.Targets {
+			if isBlank(target.Host) {
+				v.addf("services", i, "targets", "targets[%d]: host is required", j)
+			}
+			if isBlank(target.Port) {
+				v.addf("services", i, "targets", "targets[%d]: port is required", j)
+			} else if !validPort(*target.Port) {
+				v.addf("services", i, "targets", "targets[%d]: port %q is not a valid port number", j, *target.Port)
+			}
+			if target.HopOrder != nil && (*target.HopOrder < 0 || *target.HopOrder > hopCount) {
+				v.addf("services", i, "targets", "targets[%d]: hop_order %d is out of range 0-%d (number of ssh_hops)", j, *target.HopOrder, hopCount)
+			}
+		}
+
+		for _, file := range []struct {
+			key  string
+			path *string
+		}{
+			{"ca_file", service.CAFile},
+			{"client_cert", service.ClientCert},
+			{"client_key", service.ClientKey},
+		} {
+			if !isBlank(file.path) && !secrets.IsReference(*file.path) {
+				if err := checkFileReadable(*file.path); err != nil {
+					v.addf("services", i, file.key, "%v", err)
+				}
+			}
+		}
+	}
+}
+
+func (v *validator) validatePorts() {
+	ports := make(map[string]string)
+	for _, port := range []struct {
+		key   string
+		value *string
+	}{
+		{"local_http_port", v.config.LocalHttpPort},
+		{"local_docker_port", v.config.LocalDockerPort},
+	} {
+		if isBlank(port.value) {
+			continue
+		}
+		if !validPort(*port.value) {
+			v.addf("", 0, port.key, "%q is not a valid port number", *port.value)
+			continue
+		}
+		if previous, exists := ports[*port.value]; exists {
+			v.addf("", 0, port.key, "port %s conflicts with %s", *port.value, previous)
+			continue
+		}
+		ports[*port.value] = port.key
+	}
+}
+
+func isBlank(value *string) bool {
+	return value == nil || strings.TrimSpace(*value) == ""
+}
+
+// validPort 校验端口号（端口不解析 "${PORT}" 等引用）
+func validPort(value string) bool {
+	port, err := strconv.Atoi(value)
+	return err == nil && port >= 1 && port <= 65535
+}
+
+// checkFileReadable 检查配置引用的文件是否存在（支持 ~ 开头的路径）
+func checkFileReadable(path string) error {
+	expanded, err := pkg.ExpandHome(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(expanded)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("file %s does not exist", path)
+		}
+		return fmt.Errorf("cannot access %s: %v", path, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory, expected a file", path)
+	}
+	return nil
+}
+
+// lineLocator 通过扫描原始文本定位字段所在行
+// ------------------------------------------------------------
+var (
+	tableHeaderPattern = regexp.MustCompile(`^\[\[?\s*([A-Za-z0-9_."-]+)\s*\]\]?`)
+	keyPattern         = regexp.MustCompile(`^"?([A-Za-z0-9_-]+)"?\s*=`)
+)
+
+type lineLocator struct {
+	lines []string
+}
+
+func newLineLocator(source []byte) *lineLocator {
+	return &lineLocator{lines: strings.Split(string(source), "\n")}
+}
+
+// find 返回字段所在行（从 1 开始），找不到字段时返回所在数组表的表头行，都找不到时返回 0
+// index 为 -1 时 table 是普通表（如 [hops.bastion]）
+func (l *lineLocator) find(table string, index int, key string) int {
+	headerLine := 0
+	count := -1
+	inTarget := table == ""
+
+	for i, raw := range l.lines {
+		line := strings.TrimSpace(raw)
+		if match := tableHeaderPattern.FindStringSubmatch(line); match != nil {
+			name := match[1]
+			isArrayTable := strings.HasPrefix(line, "[[")
+			// 顶层字段以表的形式出现（如 [hop_overrides.x]）
+			if table == "" && key != "" && (name == key || strings.HasPrefix(name, key+".")) {
+				return i + 1
+			}
+			switch {
+			case name == table && index < 0:
+				inTarget = true
+				headerLine = i + 1
+			case name == table && isArrayTable:
+				count++
+				inTarget = count == index
+				if inTarget {
+					headerLine = i + 1
+				}
+			case table != "" && strings.HasPrefix(name, table+"."):
+				// 子表（如 [services.cache]）仍属于当前数组元素，但其中的 key 不是目标字段
+				if inTarget && key != "" && strings.TrimPrefix(name, table+".") == key {
+					return i + 1
+				}
+			default:
+				if inTarget && headerLine > 0 {
+					return headerLine
+				}
+				inTarget = false
+			}
+			continue
+		}
+
+		if !inTarget || key == "" {
+			continue
+		}
+		if match := keyPattern.FindStringSubmatch(line); match != nil && match[1] == key && !l.inSubTable(i, table) {
+			return i + 1
+		}
+	}
+	return headerLine
+}
+
+// inSubTable 判断该行是否位于 [table.xxx] 子表中
+func (l *lineLocator) inSubTable(lineIndex int, table string) bool {
+	for i := lineIndex - 1; i >= 0; i-- {
+		line := strings.TrimSpace(l.lines[i])
+		if match := tableHeaderPattern.FindStringSubmatch(line); match != nil {
+			return table != "" && match[1] != table
+		}
+	}
+	return false
+}
+
+// ============================================================
@@ -0,0 +1,147 @@
+package config_loader
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"ssh-messer/internal/pubsub"
+	"ssh-messer/internal/ssh_proxy"
+	"ssh-messer/pkg"
+)
+
+// Config 目录监听
+// ------------------------------------------------------------
+const DefaultWatchInterval = 2 * time.Second
+
+var configChangeBroker = pubsub.NewBroker[ConfigChangeEvent]()
+
+// GetConfigChangeBroker 获取配置文件变更 broker
+func GetConfigChangeBroker() *pubsub.Broker[ConfigChangeEvent] {
+	return configChangeBroker
+}
+
+// ConfigChangeEvent 配置目录中的 TOML 文件发生变化（新增、修改或删除）
+type ConfigChangeEvent struct {
+	Files []string // 变化的文件路径
+}
+
+// fileState 用于判断文件是否变化
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// WatchConfigSources 轮询所有配置来源中的 TOML 文件（包括 hops.toml），变化时发布 ConfigChangeEvent，直到 ctx 取消
+// 使用轮询而不是文件系统通知，兼容编辑器的原子保存（写临时文件后 rename）
+func WatchConfigSources(ctx context.Context, interval time.Duration) {
+	pkg.Logger.Debug().Strs("dirs", ConfigDirs()).Dur("interval", interval).Msg("[ConfigWatcher] 开始监听配置来源")
+	previous := scanConfigSources()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			pkg.Logger.Debug().Msg("[ConfigWatcher] 停止监听配置来源")
+			return
+		case <-ticker.C:
+			current := scanConfigSources()
+			if changed := changedFiles(previous, current); len(changed) > 0 {
+				pkg.Logger.Info().Strs("files", changed).Msg("[ConfigWatcher] 配置文件发生变化")
+				configChangeBroker.Publish(pubsub.UpdatedEvent, ConfigChangeEvent{Files: changed})
+			}
+			previous = current
+		}
+	}
+}
+
+// scanConfigSources 记录所有配置来源中 TOML 文件的状态，以完整路径为键
+func scanConfigSources() map[string]fileState {
+	states := make(map[string]fileState)
+	record := func(path string) {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			states[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+
+	if projectFile := findProjectConfig(); projectFile != "" {
+		record(projectFile)
+	}
+	for _, dir := range ConfigDirs() {
+		filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if entry.IsDir() {
+				if path != dir && strings.HasPrefix(entry.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if filepath.Ext(path) == ".toml" {
+				record(path)
+			}
+			return nil
+		})
+	}
+	return states
+}
+
+func changedFiles(previous, current map[string]fileState) []string {
+	var changed []string
+	for name, state := range current {
+		if old, exists := previous[name]; !exists || old != state {
+			changed = append(changed, name)
+		}
+	}
+	for name := range previous {
+		if _, exists := current[name]; !exists {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// ConfigDiff 同一配置重新加载前后的差异
+type ConfigDiff struct {
+	HopsChanged     bool // ssh_hops 变化，需要重新连接
+	ServicesChanged bool // services、本地端口或代理设置变化，可以热更新
+}
+
+// Diff 比较重新加载前后的配置
+func Diff(old, new *TomlConfig) ConfigDiff {
+	if old == nil || new == nil {
+		return ConfigDiff{}
+	}
+	return ConfigDiff{
+		HopsChanged: !reflect.DeepEqual(sortedHops(old.SSHHops), sortedHops(new.SSHHops)),
+		ServicesChanged: !reflect.DeepEqual(old.SSHServices, new.SSHServices) ||
+			!reflect.DeepEqual(old.LocalHttpPort, new.LocalHttpPort) ||
+			!reflect.DeepEqual(old.Proxy, new.Proxy),
+	}
+}
+
+// sortedHops 按 order 排序后的副本（连接时会对 hop 原地排序，比较前需统一顺序）
+func sortedHops(hops []ssh_proxy.SSHHopConfig) []ssh_proxy.SSHHopConfig {
+	sorted := append([]ssh_proxy.SSHHopConfig(nil), hops...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		orderI, orderJ := 0, 0
+		if sorted[i].Order != nil {
+			orderI = *sorted[i].Order
+		}
+		if sorted[j].Order != nil {
+			orderJ = *sorted[j].Order
+		}
+		return orderI < orderJ
+	})
+	return sorted
+}
+
+// ============================================================
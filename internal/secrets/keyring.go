@@ -0,0 +1,298 @@
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// 系统钥匙串
+// ------------------------------------------------------------
+const (
+	keyringPrefix         = "keyring:"       // 从系统钥匙串读取，如 "keyring:prod-bastion" 或 "keyring:my-service/deploy"
+	DefaultKeyringService = "ssh-messer"     // 未指定 service 时使用的钥匙串 service 名称
+	keyringBackendEnv     = "MESSER_KEYRING" // 设置为 "file" 时强制使用文件存储
+	keyringFileName       = "keyring.json"
+)
+
+// ErrSecretNotFound 钥匙串中没有对应的密钥
+var ErrSecretNotFound = errors.New("secret not found in keyring")
+
+// MissingSecretError 引用的密钥不存在，TUI 据此提示用户输入
+type MissingSecretError struct {
+	Service string
+	Account string
+	Backend string
+}
+
+func (e *MissingSecretError) Error() string {
+	return fmt.Sprintf("secret %s/%s not found in %s", e.Service, e.Account, e.Backend)
+}
+
+func (e *MissingSecretError) Unwrap() error {
+	return ErrSecretNotFound
+}
+
+// Keyring 密钥存储后端
+type Keyring interface {
+	Name() string
+	Get(service, account string) (string, error)
+	Set(service, account, secret string) error
+}
+
+var (
+	keyringMu      sync.Mutex
+	currentKeyring Keyring
+	fileKeyringDir string                    // 文件存储所在目录，为空时使用 ~/.ssh_messer
+	sessionSecrets = make(map[string]string) // 用户输入但未保存的密钥，仅在本次运行中有效
+)
+
+// ParseKeyringReference 解析 "keyring:[service/]account"
+func ParseKeyringReference(value string) (service, account string, ok bool) {
+	if !strings.HasPrefix(value, keyringPrefix) {
+		return "", "", false
+	}
+	reference := strings.TrimPrefix(value, keyringPrefix)
+	service, account, found := strings.Cut(reference, "/")
+	if !found {
+		service, account = DefaultKeyringService, reference
+	}
+	if service == "" || account == "" {
+		return "", "", false
+	}
+	return service, account, true
+}
+
+// GetKeyring 返回当前平台可用的钥匙串：macOS Keychain、Secret Service（secret-tool），否则使用文件存储
+func GetKeyring() Keyring {
+	keyringMu.Lock()
+	defer keyringMu.Unlock()
+
+	if currentKeyring == nil {
+		currentKeyring = detectKeyring()
+	}
+	return currentKeyring
+}
+
+// SetKeyring 替换钥匙串后端（用于测试）
+func SetKeyring(keyring Keyring) {
+	keyringMu.Lock()
+	defer keyringMu.Unlock()
+	currentKeyring = keyring
+}
+
+// RememberSessionSecret 在本次运行中记住用户输入的密钥，不写入任何存储
+func RememberSessionSecret(service, account, secret string) {
+	keyringMu.Lock()
+	defer keyringMu.Unlock()
+	sessionSecrets[service+"/"+account] = secret
+}
+
+func detectKeyring() Keyring {
+	if os.Getenv(keyringBackendEnv) != "file" {
+		switch runtime.GOOS {
+		case "darwin":
+			if _, err := exec.LookPath("security"); err == nil {
+				return macKeychain{}
+			}
+		case "linux", "freebsd", "openbsd":
+			if _, err := exec.LookPath("secret-tool"); err == nil {
+				return secretService{}
+			}
+		}
+	}
+	return newFileKeyring()
+}
+
+// resolveKeyring 解析 keyring: 引用，会话中输入过的密钥优先
+func resolveKeyring(value string) (string, error) {
+	service, account, ok := ParseKeyringReference(value)
+	if !ok {
+		return "", fmt.Errorf("invalid keyring reference %q, expected keyring:[service/]account", value)
+	}
+
+	keyringMu.Lock()
+	secret, remembered := sessionSecrets[service+"/"+account]
+	keyringMu.Unlock()
+	if remembered {
+		return secret, nil
+	}
+
+	keyring := GetKeyring()
+	secret, err := keyring.Get(service, account)
+	if errors.Is(err, ErrSecretNotFound) {
+		return "", &MissingSecretError{Service: service, Account: account, Backend: keyring.Name()}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s/%s from %s: %w", service, account, keyring.Name(), err)
+	}
+	return secret, nil
+}
+
+// macKeychain 通过 security 命令访问 macOS 钥匙串
+type macKeychain struct{}
+
+func (macKeychain) Name() string {
+	return "macOS Keychain"
+}
+
+func (macKeychain) Get(service, account string) (string, error) {
+	output, err := exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w").Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		// exit code 44: The specified item could not be found in the keychain
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 44 {
+			return "", ErrSecretNotFound
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(output), "\n"), nil
+}
+
+func (macKeychain) Set(service, account, secret string) error {
+	if strings.ContainsAny(secret, "\r\n") {
+		return fmt.Errorf("secret must not contain line breaks")
+	}
+	// security -i 从 stdin 读取命令，密钥不会出现在进程列表中；-U 更新已存在的条目
+	command := fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", securityQuote(service), securityQuote(account), securityQuote(secret))
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(command)
+	output, err := cmd.CombinedOutput()
+	if err == nil && strings.Contains(string(output), "security: ") {
+		// 交互模式下命令失败时 security 仍以 0 退出，错误只出现在输出中
+		err = errors.New("command failed")
+	}
+	if err != nil {
+		return fmt.Errorf("security add-generic-password: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// securityQuote 按 security 交互模式的规则为参数加引号
+func securityQuote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
+
+// secretService 通过 secret-tool 访问 Secret Service（GNOME Keyring、KWallet）
+type secretService struct{}
+
+func (secretService) Name() string {
+	return "Secret Service"
+}
+
+func (secretService) Get(service, account string) (string, error) {
+	output, err := exec.Command("secret-tool", "lookup", "service", service, "account", account).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		// secret-tool 找不到条目时以 1 退出且没有输出
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && len(output) == 0 {
+			return "", ErrSecretNotFound
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(output), "\n"), nil
+}
+
+func (secretService) Set(service, account, secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label", service+" "+account, "service", service, "account", account)
+	// 通过 stdin 传递密钥，避免出现在进程列表中
+	cmd.Stdin = strings.NewReader(secret)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("secret-tool store: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// fileKeyring 文件存储（主配置目录下的 keyring.json，权限 0600），用于没有系统钥匙串的环境与测试
+// 内容未加密，仅依赖文件权限保护
+type fileKeyring struct {
+	path string
+	mu   sync.Mutex
+}
+
+// newFileKeyring 调用方需持有 keyringMu
+func newFileKeyring() *fileKeyring {
+	dir := fileKeyringDir
+	if dir == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, ".ssh_messer")
+		}
+	}
+	return &fileKeyring{path: filepath.Join(dir, keyringFileName)}
+}
+
+// SetFileKeyringDir 设置文件存储所在的目录（主配置目录），需在首次使用钥匙串前调用
+func SetFileKeyringDir(dir string) {
+	keyringMu.Lock()
+	defer keyringMu.Unlock()
+	fileKeyringDir = dir
+}
+
+// NewFileKeyring 创建使用指定文件的文件存储
+func NewFileKeyring(path string) Keyring {
+	return &fileKeyring{path: path}
+}
+
+func (k *fileKeyring) Name() string {
+	return "file keyring (" + k.path + ")"
+}
+
+func (k *fileKeyring) Get(service, account string) (string, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	entries, err := k.load()
+	if err != nil {
+		return "", err
+	}
+	secret, ok := entries[service+"/"+account]
+	if !ok {
+		return "", ErrSecretNotFound
+	}
+	return secret, nil
+}
+
+func (k *fileKeyring) Set(service, account, secret string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	entries, err := k.load()
+	if err != nil {
+		return err
+	}
+	entries[service+"/"+account] = secret
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(k.path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(k.path, data, 0o600)
+}
+
+func (k *fileKeyring) load() (map[string]string, error) {
+	entries := make(map[string]string)
+	data, err := os.ReadFile(k.path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid keyring file %s: %w", k.path, err)
+	}
+	return entries, nil
+}
+
+// ============================================================
@@ -0,0 +1,140 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"ssh-messer/pkg"
+)
+
+// 密钥引用前缀
+const (
+	envPrefix  = "env:"  // 从环境变量读取，如 "env:API_TOKEN"
+	filePrefix = "file:" // 从文件读取（去除首尾空白），如 "file:~/.config/token"
+	cmdPrefix  = "cmd:"  // 执行命令并读取标准输出（去除首尾空白），如 "cmd:pass show db/password"
+)
+
+// commandTimeout 执行 cmd: 引用的超时时间（密码管理器可能需要用户解锁）
+const commandTimeout = 60 * time.Second
+
+// interpolationPattern 匹配 "${NAME}"，"$${" 表示字面量 "${"
+var interpolationPattern = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// IsReference 判断配置值是否为密钥引用或包含 "${NAME}" 插值
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, envPrefix) || strings.HasPrefix(value, filePrefix) ||
+		strings.HasPrefix(value, cmdPrefix) || strings.HasPrefix(value, keyringPrefix) || strings.Contains(value, "${")
+}
+
+// IsExternalReference 判断解析时是否会执行命令（cmd:）或读取本地文件（file:）
+// 来自他人的配置（如导入的加密包）中出现时需要用户确认
+func IsExternalReference(value string) bool {
+	return strings.HasPrefix(value, cmdPrefix) || strings.HasPrefix(value, filePrefix)
+}
+
+// Resolve 解析配置值中的密钥引用，非引用值原样返回
+// 解析结果不应写入日志
+func Resolve(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, envPrefix):
+		name := strings.TrimPrefix(value, envPrefix)
+		if name == "" {
+			return "", fmt.Errorf("empty environment variable name in %q", value)
+		}
+		resolved, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return resolved, nil
+
+	case strings.HasPrefix(value, filePrefix):
+		path, err := Interpolate(strings.TrimPrefix(value, filePrefix))
+		if err != nil {
+			return "", err
+		}
+		if path == "" {
+			return "", fmt.Errorf("empty secret file path")
+		}
+		path, err = pkg.ExpandHome(path)
+		if err != nil {
+			return "", err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file %s: %w", path, err)
+		}
+		return strings.TrimSpace(string(data)), nil
+
+	case strings.HasPrefix(value, cmdPrefix):
+		return runCommand(strings.TrimPrefix(value, cmdPrefix))
+
+	case strings.HasPrefix(value, keyringPrefix):
+		return resolveKeyring(value)
+
+	default:
+		return Interpolate(value)
+	}
+}
+
+// Interpolate 将 "${NAME}" 替换为环境变量的值，变量未设置时返回错误
+func Interpolate(value string) (string, error) {
+	if !strings.Contains(value, "${") {
+		return value, nil
+	}
+
+	var missing []string
+	result := interpolationPattern.ReplaceAllStringFunc(value, func(match string) string {
+		if strings.HasPrefix(match, "$$") {
+			return match[1:]
+		}
+		name := match[2 : len(match)-1]
+		resolved, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return resolved
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %s is not set", strings.Join(missing, ", "))
+	}
+	return result, nil
+}
+
+// runCommand 通过 shell 执行命令并返回标准输出，错误中只包含 stderr，不包含输出内容
+func runCommand(command string) (string, error) {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return "", fmt.Errorf("empty command in cmd: reference")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("command %q timed out after %s", command, commandTimeout)
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("command %q failed: %v: %s", command, err, message)
+		}
+		return "", fmt.Errorf("command %q failed: %v", command, err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
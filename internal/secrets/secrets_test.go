@@ -0,0 +1,48 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	t.Setenv("MESSER_TEST_SECRET", "s3cret")
+	secretFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(secretFile, []byte("  from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain value", value: "password", want: "password"},
+		{name: "env", value: "env:MESSER_TEST_SECRET", want: "s3cret"},
+		{name: "unset env", value: "env:MESSER_TEST_UNSET", wantErr: true},
+		{name: "empty env name", value: "env:", wantErr: true},
+		{name: "interpolation", value: "Bearer ${MESSER_TEST_SECRET}", want: "Bearer s3cret"},
+		{name: "escaped interpolation", value: "$${MESSER_TEST_SECRET}", want: "${MESSER_TEST_SECRET}"},
+		{name: "escaped and resolved", value: "$${A} ${MESSER_TEST_SECRET}", want: "${A} s3cret"},
+		{name: "unset interpolation", value: "${MESSER_TEST_UNSET}", wantErr: true},
+		{name: "unterminated interpolation", value: "${MESSER_TEST_SECRET", want: "${MESSER_TEST_SECRET"},
+		{name: "file is trimmed", value: "file:" + secretFile, want: "from-file"},
+		{name: "missing file", value: "file:" + secretFile + ".missing", wantErr: true},
+		{name: "command output is trimmed", value: "cmd:echo ' out '", want: "out"},
+		{name: "failing command", value: "cmd:exit 3", wantErr: true},
+		{name: "empty command", value: "cmd: ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Resolve(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package ssh_proxy
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"time"
+	"unicode/utf8"
+)
+
+// HAR 导出（HTTP Archive 1.2）
+// ------------------------------------------------------------
+
+type harLog struct {
+	Log harContent `json:"log"`
+}
+
+type harContent struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+	Comment     string         `json:"comment,omitempty"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harBody        `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int64          `json:"bodySize"`
+	Comment     string         `json:"comment,omitempty"`
+}
+
+type harBody struct {
+	Size     int64  `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// BuildHAR 将捕获的请求转换为 HAR 文档
+func BuildHAR(exchanges []*CapturedExchange, creatorVersion string) ([]byte, error) {
+	har := harLog{Log: harContent{
+		Version: "1.2",
+		Creator: harCreator{Name: "ssh-messer", Version: creatorVersion},
+		Entries: make([]harEntry, 0, len(exchanges)),
+	}}
+
+	for _, exchange := range exchanges {
+		if exchange == nil {
+			continue
+		}
+		har.Log.Entries = append(har.Log.Entries, buildHAREntry(exchange))
+	}
+
+	return json.MarshalIndent(har, "", "  ")
+}
+
+// WriteHARFile 将捕获的请求导出为 HAR 文件
+func WriteHARFile(path string, exchanges []*CapturedExchange, creatorVersion string) error {
+	data, err := BuildHAR(exchanges, creatorVersion)
+	if err != nil {
+		return fmt.Errorf("failed to build HAR: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write HAR file %s: %w", path, err)
+	}
+	return nil
+}
+
+func buildHAREntry(exchange *CapturedExchange) harEntry {
+	durationMs := float64(exchange.Duration) / float64(time.Millisecond)
+	entry := harEntry{
+		StartedDateTime: exchange.StartedAt.Format(time.RFC3339Nano),
+		Time:            durationMs,
+		Timings:         harTimings{Send: 0, Wait: durationMs, Receive: 0},
+	}
+
+	request := exchange.Request
+	entry.Request = harRequest{
+		Method:      request.Method,
+		URL:         request.URL,
+		HTTPVersion: request.Proto,
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(request.Header),
+		QueryString: []harNameValue{},
+		HeadersSize: -1,
+		BodySize:    request.BodySize,
+	}
+	if parsed, err := url.Parse(request.URL); err == nil {
+		entry.Request.QueryString = harQuery(parsed.Query())
+	}
+	if request.BodySize > 0 {
+		text, encoding := harText(request.Body)
+		entry.Request.PostData = &harPostData{
+			MimeType: request.Header.Get("Content-Type"),
+			Text:     text,
+			Encoding: encoding,
+		}
+		if request.BodyTruncated {
+			entry.Request.Comment = fmt.Sprintf("body truncated to %d of %d bytes", len(request.Body), request.BodySize)
+		}
+	}
+
+	response := exchange.Response
+	if response == nil {
+		// HAR 要求 response 字段存在，失败的请求使用 status 0 表示
+		entry.Response = harResponse{
+			Cookies:     []harNameValue{},
+			Headers:     []harNameValue{},
+			Content:     harBody{MimeType: "x-unknown"},
+			HeadersSize: -1,
+			BodySize:    -1,
+		}
+		entry.Comment = "no response received"
+		return entry
+	}
+
+	body := response.DecodedBody()
+	text, encoding := harText(body)
+	entry.Response = harResponse{
+		Status:      response.StatusCode,
+		StatusText:  http.StatusText(response.StatusCode),
+		HTTPVersion: response.Proto,
+		Cookies:     []harNameValue{},
+		Headers:     harHeaders(response.Header),
+		Content: harBody{
+			Size:     int64(len(body)),
+			MimeType: response.Header.Get("Content-Type"),
+			Text:     text,
+			Encoding: encoding,
+		},
+		RedirectURL: response.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    response.BodySize,
+	}
+	if response.BodyTruncated {
+		entry.Response.Comment = fmt.Sprintf("body truncated to %d of %d bytes", len(response.Body), response.BodySize)
+	}
+	return entry
+}
+
+// harHeaders 将 header 转换为按名称排序的列表
+func harHeaders(header http.Header) []harNameValue {
+	values := make([]harNameValue, 0, len(header))
+	for name, list := range header {
+		for _, value := range list {
+			values = append(values, harNameValue{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(values, func(i, j int) bool { return values[i].Name < values[j].Name })
+	return values
+}
+
+func harQuery(query url.Values) []harNameValue {
+	values := make([]harNameValue, 0, len(query))
+	for name, list := range query {
+		for _, value := range list {
+			values = append(values, harNameValue{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(values, func(i, j int) bool { return values[i].Name < values[j].Name })
+	return values
+}
+
+// harText 文本内容原样输出，二进制内容使用 base64
+func harText(body []byte) (string, string) {
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return base64.StdEncoding.EncodeToString(body), "base64"
+}
+
+// ============================================================
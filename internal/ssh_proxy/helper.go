@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"net"
 	"os"
-	"strings"
 	"time"
 
 	"ssh-messer/pkg"
@@ -93,14 +92,10 @@ func parsePrivateKey(sshHopConfig SSHHopConfig) (ssh.Signer, error) {
 	pkg.Logger.Debug().Str("alias", aliasName).Str("key_path", *sshHopConfig.PrivateKeyPath).Msg("[SSHHelper] 开始解析私钥")
 
 	// 展开 ~ 符号
-	keyPath := *sshHopConfig.PrivateKeyPath
-	if strings.HasPrefix(keyPath, "~") {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			pkg.Logger.Error().Err(err).Str("alias", aliasName).Msg("[SSHHelper] 私钥解析失败: 无法获取用户主目录")
-			return nil, fmt.Errorf("failed to get user home directory: %v", err)
-		}
-		keyPath = strings.Replace(keyPath, "~", homeDir, 1)
+	keyPath, err := pkg.ExpandHome(*sshHopConfig.PrivateKeyPath)
+	if err != nil {
+		pkg.Logger.Error().Err(err).Str("alias", aliasName).Msg("[SSHHelper] 私钥解析失败: 无法获取用户主目录")
+		return nil, err
 	}
 
 	privateKey, err := os.ReadFile(keyPath)
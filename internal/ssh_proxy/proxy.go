@@ -25,6 +25,7 @@ func GetStatusBroker() *pubsub.Broker[SSHStatusUpdateEvent] {
 	return statusBroker
 }
 
+// publishStatus 调用方需持有 statusMu，持锁发布保证事件顺序与状态变化一致
 func (p *SSHHopsProxy) publishStatus() {
 	update := SSHStatusUpdateEvent{
 		ConfigName: p.configName,
@@ -35,10 +36,19 @@ func (p *SSHHopsProxy) publishStatus() {
 
 // updateStatus 封装状态更新和发布逻辑
 func (p *SSHHopsProxy) updateStatus(updater func(*SSHProxyStatus)) {
+	p.statusMu.Lock()
+	defer p.statusMu.Unlock()
 	updater(&p.Status)
 	p.publishStatus()
 }
 
+// GetStatus 返回当前状态的副本
+func (p *SSHHopsProxy) GetStatus() SSHProxyStatus {
+	p.statusMu.Lock()
+	defer p.statusMu.Unlock()
+	return p.Status
+}
+
 // ============================================================
 
 // SSHHopsProxy 函数
@@ -69,27 +79,43 @@ func NewSSHHopsProxy(configName string, hopsConfigs []SSHHopConfig, healthCheckI
 		healthCheckInterval: healthCheckInterval,
 	}
 
+	proxy.statusMu.Lock()
 	proxy.publishStatus()
+	proxy.statusMu.Unlock()
 
 	return proxy
 }
 
+// SetProxySettings 设置本地服务代理的全局设置，在 StartServices 时生效
+func (p *SSHHopsProxy) SetProxySettings(settings *ServiceProxySettings) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.proxySettings = settings
+	if p.serviceProxy != nil {
+		p.serviceProxy.SetSettings(settings)
+	}
+}
+
 func (p *SSHHopsProxy) GetHopsConfigs() []SSHHopConfig {
+	p.hopsMu.RLock()
+	defer p.hopsMu.RUnlock()
 	return p.hopsConfigs
 }
 
 // GetClient 获取 SSH 客户端（用于测试和端口转发）
 func (p *SSHHopsProxy) GetClient() *ssh.Client {
+	p.hopClientsMu.RLock()
+	defer p.hopClientsMu.RUnlock()
 	return p.client
 }
 
 // GetClientForHopOrder 根据 hopOrder 获取对应的 SSH 客户端
 // 如果 hopOrder 为 0 或不存在，返回默认的最后一个 hop 的 client
 func (p *SSHHopsProxy) GetClientForHopOrder(hopOrder int) *ssh.Client {
-	if hopOrder <= 0 {
-		return p.client
-	}
-	if client, exists := p.hopClients[hopOrder]; exists {
+	// 请求处理中调用，只读 hopClientsMu 而不等待可能正在建立连接的 mu
+	p.hopClientsMu.RLock()
+	defer p.hopClientsMu.RUnlock()
+	if client, exists := p.hopClients[hopOrder]; exists && hopOrder > 0 {
 		return client
 	}
 	// 如果指定的 hopOrder 不存在，返回默认的最后一个 hop 的 client
@@ -111,7 +137,25 @@ func GetHopDisplayName(hopConfig SSHHopConfig) string {
 // SSH Hops 跳板连接
 // ------------------------------------------------------------
 func (p *SSHHopsProxy) Connect() {
-	pkg.Logger.Debug().Str("config_name", p.configName).Int("hops_count", len(p.hopsConfigs)).Msg("[SSHHopsProxy] 连接开始")
+	p.mu.Lock()
+	generation := p.connectGen
+	p.mu.Unlock()
+	p.connect(generation)
+}
+
+// connect 建立连接，返回是否连接成功
+// generation 为开始连接时的 connectGen，期间调用了 Disconnect 时丢弃新建立的连接
+func (p *SSHHopsProxy) connect(generation int) bool {
+	// 使用开始连接时的 hop 配置，连接期间的变化在连接完成后重连应用
+	p.hopsMu.Lock()
+	// 排序副本后替换，GetHopsConfigs 返回的切片不会被原地修改
+	hopsConfigs := append([]SSHHopConfig(nil), p.hopsConfigs...)
+	sortHopsByOrder(hopsConfigs)
+	p.hopsConfigs = hopsConfigs
+	p.hopsChanged = false
+	p.hopsMu.Unlock()
+
+	pkg.Logger.Debug().Str("config_name", p.configName).Int("hops_count", len(hopsConfigs)).Msg("[SSHHopsProxy] 连接开始")
 	p.updateStatus(func(s *SSHProxyStatus) {
 		s.IsConnecting = true
 		s.IsConnected = false
@@ -119,121 +163,51 @@ func (p *SSHHopsProxy) Connect() {
 		s.LastError = nil
 	})
 
-	sort.Slice(p.hopsConfigs, func(i, j int) bool {
-		orderI := 0
-		orderJ := 0
-		if p.hopsConfigs[i].Order != nil {
-			orderI = *p.hopsConfigs[i].Order
-		}
-		if p.hopsConfigs[j].Order != nil {
-			orderJ = *p.hopsConfigs[j].Order
-		}
-		return orderI < orderJ
+	currentClient, failedHop, err := dialHops(hopsConfigs, len(hopsConfigs), func(i int) {
+		pkg.Logger.Debug().Str("config_name", p.configName).Int("hop_index", i+1).Int("total_hops", len(hopsConfigs)).Str("alias", GetHopDisplayName(hopsConfigs[i])).Msg("[SSHHopsProxy] 正在连接 hop")
+		p.updateStatus(func(s *SSHProxyStatus) {
+			s.CurrentInfo = fmt.Sprintf("正在连接到 SSH 跳板 %d/%d: %s", i+1, len(hopsConfigs), GetHopDisplayName(hopsConfigs[i]))
+		})
 	})
-
-	var currentClient *ssh.Client
-	for i, hopConfig := range p.hopsConfigs {
-		port := 22
-		if hopConfig.Port != nil {
-			port = *hopConfig.Port
-		}
-		sshAddress := *hopConfig.Host + ":" + strconv.Itoa(port)
-
-		var aliasName string
-		if hopConfig.Alias != nil && *hopConfig.Alias != "" {
-			aliasName = *hopConfig.Alias
-		} else {
-			aliasName = *hopConfig.Host
+	if err != nil {
+		if p.isCancelled(generation) {
+			p.setDisconnectedStatus()
+			return false
 		}
-
-		pkg.Logger.Debug().Str("config_name", p.configName).Int("hop_index", i+1).Int("total_hops", len(p.hopsConfigs)).Str("alias", aliasName).Str("address", sshAddress).Msg("[SSHHopsProxy] 正在连接 hop")
-
-		sshClientConfig, err := transformSSHHopsConfigToSSHClientConfig(hopConfig)
-		if err != nil {
-			// 关闭已建立的所有连接
-			if currentClient != nil {
-				currentClient.Close()
-			}
-			pkg.Logger.Error().Err(err).Str("config_name", p.configName).Int("hop_index", i+1).Str("alias", aliasName).Msg("[SSHHopsProxy] 配置 SSH 跳板失败")
-			p.updateStatus(func(s *SSHProxyStatus) {
-				s.LastError = err
-				s.CurrentInfo = fmt.Sprintf("配置 SSH 跳板 %s 失败: %v", aliasName, err)
-				s.IsConnecting = false
-				s.IsConnected = false
-			})
-			return
+		aliasName := ""
+		if failedHop >= 0 {
+			aliasName = GetHopDisplayName(hopsConfigs[failedHop])
 		}
-
-		if i == 0 {
-			// 第一个 hop：直接连接到第一台服务器
-			p.updateStatus(func(s *SSHProxyStatus) {
-				s.CurrentInfo = fmt.Sprintf("正在连接到 SSH 跳板 %d/%d: %s", i+1, len(p.hopsConfigs), aliasName)
-			})
-
-			currentClient, err = ssh.Dial("tcp", sshAddress, sshClientConfig)
-			if err != nil {
-				pkg.Logger.Error().Err(err).Str("config_name", p.configName).Int("hop_index", i+1).Str("alias", aliasName).Str("address", sshAddress).Msg("[SSHHopsProxy] 连接 hop 失败")
-				p.updateStatus(func(s *SSHProxyStatus) {
-					s.LastError = err
-					s.CurrentInfo = fmt.Sprintf("连接到 SSH 跳板 %d/%d: %s 失败", i+1, len(p.hopsConfigs), aliasName)
-					s.IsConnecting = false
-					s.IsConnected = false
-				})
-				return
-			}
-			pkg.Logger.Info().Str("config_name", p.configName).Int("hop_index", i+1).Str("alias", aliasName).Str("address", sshAddress).Msg("[SSHHopsProxy] hop 连接成功")
-		} else {
-			// 后续 hop：通过前一个 client 的 Dial 方法连接到下一台服务器
-			p.updateStatus(func(s *SSHProxyStatus) {
-				s.CurrentInfo = fmt.Sprintf("正在连接到 SSH 跳板 %d/%d: %s", i+1, len(p.hopsConfigs), aliasName)
-			})
-
-			// 通过前一个 client 建立到下一个 hop 的 TCP 连接
-			conn, err := currentClient.Dial("tcp", sshAddress)
-			if err != nil {
-				// 关闭已建立的所有连接
-				if currentClient != nil {
-					currentClient.Close()
-				}
-				pkg.Logger.Error().Err(err).Str("config_name", p.configName).Int("hop_index", i+1).Str("alias", aliasName).Str("address", sshAddress).Msg("[SSHHopsProxy] 通过前一个 hop 连接失败")
-				p.updateStatus(func(s *SSHProxyStatus) {
-					s.LastError = err
-					s.CurrentInfo = fmt.Sprintf("连接到 SSH 跳板 %d/%d: %s 失败", i+1, len(p.hopsConfigs), aliasName)
-					s.IsConnecting = false
-					s.IsConnected = false
-				})
-				return
-			}
-
-			// 基于这个 TCP 连接创建新的 SSH 客户端连接
-			nconn, chans, reqs, err := ssh.NewClientConn(conn, sshAddress, sshClientConfig)
-			if err != nil {
-				conn.Close()
-				if currentClient != nil {
-					currentClient.Close()
-				}
-				pkg.Logger.Error().Err(err).Str("config_name", p.configName).Int("hop_index", i+1).Str("alias", aliasName).Str("address", sshAddress).Msg("[SSHHopsProxy] 创建 SSH 客户端连接失败")
-				p.updateStatus(func(s *SSHProxyStatus) {
-					s.LastError = err
-					s.CurrentInfo = fmt.Sprintf("创建 SSH 客户端连接 %d/%d: %s 失败", i+1, len(p.hopsConfigs), aliasName)
-					s.IsConnecting = false
-					s.IsConnected = false
-				})
-				return
+		pkg.Logger.Error().Err(err).Str("config_name", p.configName).Int("hop_index", failedHop+1).Str("alias", aliasName).Msg("[SSHHopsProxy] 连接 hop 失败")
+		p.updateStatus(func(s *SSHProxyStatus) {
+			s.LastError = err
+			if failedHop >= 0 {
+				s.CurrentInfo = fmt.Sprintf("连接到 SSH 跳板 %d/%d: %s 失败", failedHop+1, len(hopsConfigs), aliasName)
+			} else {
+				s.CurrentInfo = fmt.Sprintf("连接 SSH 跳板失败: %v", err)
 			}
-
-			// 创建新的 SSH 客户端（前一个 client 会自动通过连接链保持）
-			// 注意：不要关闭 currentClient，因为它被新 client 使用
-			currentClient = ssh.NewClient(nconn, chans, reqs)
-			pkg.Logger.Info().Str("config_name", p.configName).Int("hop_index", i+1).Str("alias", aliasName).Str("address", sshAddress).Msg("[SSHHopsProxy] hop 连接成功")
-		}
+			s.IsConnecting = false
+			s.IsConnected = false
+		})
+		return false
 	}
 
+	p.mu.Lock()
+	if p.connectGen != generation {
+		// 连接期间调用了 Disconnect
+		p.mu.Unlock()
+		currentClient.Close()
+		pkg.Logger.Info().Str("config_name", p.configName).Msg("[SSHHopsProxy] 连接已取消，关闭新建立的连接")
+		p.setDisconnectedStatus()
+		return false
+	}
 	// 保存最终的 client（最后一个 hop 的 client）
+	p.hopClientsMu.Lock()
 	p.client = currentClient
+	p.hopClientsMu.Unlock()
 
 	// 所有跳板连接成功
-	pkg.Logger.Info().Str("config_name", p.configName).Int("total_hops", len(p.hopsConfigs)).Msg("[SSHHopsProxy] 所有 hop 连接成功")
+	pkg.Logger.Info().Str("config_name", p.configName).Int("total_hops", len(hopsConfigs)).Msg("[SSHHopsProxy] 所有 hop 连接成功")
 
 	// 为每个不同的 hopOrder 创建对应的 client
 	p.createHopOrderClients()
@@ -251,13 +225,27 @@ func (p *SSHHopsProxy) Connect() {
 
 	// 如果配置了 services 和 localPort，自动启动 services 代理
 	if len(p.services) > 0 && p.localPort != "" {
-		if err := p.StartServices(p.services, p.localPort); err != nil {
+		if err := p.startServices(p.services, p.localPort); err != nil {
 			pkg.Logger.Error().Err(err).Str("config_name", p.configName).Msg("[SSHHopsProxy] 连接后启动服务代理失败")
 		}
 	}
+	p.mu.Unlock()
+
+	// 连接期间 hop 配置发生了变化：重连循环中由 Reconnect 自行处理，否则发起重连
+	if p.hasHopsChanged() && !p.isReconnecting() {
+		go p.reconnect(generation)
+	}
+	return true
+}
+
+// isCancelled 开始连接后是否调用了 Disconnect
+func (p *SSHHopsProxy) isCancelled(generation int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.connectGen != generation
 }
 
-// createHopOrderClients 为每个不同的 hopOrder 创建对应的 SSH client
+// createHopOrderClients 为每个不同的 hopOrder 创建对应的 SSH client，调用方需持有 mu
 func (p *SSHHopsProxy) createHopOrderClients() {
 	// 收集所有服务中不同的 hopOrder 值
 	hopOrders := make(map[int]bool)
@@ -265,12 +253,26 @@ func (p *SSHHopsProxy) createHopOrderClients() {
 		if service.HopOrder != nil && *service.HopOrder > 0 {
 			hopOrders[*service.HopOrder] = true
 		}
+		for _, target := range service.Targets {
+			if target.HopOrder != nil && *target.HopOrder > 0 {
+				hopOrders[*target.HopOrder] = true
+			}
+		}
 	}
 
 	// 为每个 hopOrder 创建对应的 client
+	hopsConfigs := p.GetHopsConfigs()
 	for hopOrder := range hopOrders {
-		if hopOrder > len(p.hopsConfigs) {
-			pkg.Logger.Warn().Str("config_name", p.configName).Int("hopOrder", hopOrder).Int("total_hops", len(p.hopsConfigs)).Msg("[SSHHopsProxy] hopOrder 超出 hops 数量，跳过")
+		// 热更新服务时只为新增的 hopOrder 建立连接
+		p.hopClientsMu.RLock()
+		_, exists := p.hopClients[hopOrder]
+		p.hopClientsMu.RUnlock()
+		if exists {
+			continue
+		}
+
+		if hopOrder > len(hopsConfigs) {
+			pkg.Logger.Warn().Str("config_name", p.configName).Int("hopOrder", hopOrder).Int("total_hops", len(hopsConfigs)).Msg("[SSHHopsProxy] hopOrder 超出 hops 数量，跳过")
 			continue
 		}
 
@@ -280,20 +282,57 @@ func (p *SSHHopsProxy) createHopOrderClients() {
 			continue
 		}
 
+		p.hopClientsMu.Lock()
 		p.hopClients[hopOrder] = client
+		p.hopClientsMu.Unlock()
 		pkg.Logger.Info().Str("config_name", p.configName).Int("hopOrder", hopOrder).Msg("[SSHHopsProxy] hopOrder client 创建成功")
 	}
 }
 
 // connectHops 连接指定数量的 hops，返回最终的 SSH client
 func (p *SSHHopsProxy) connectHops(numHops int) (*ssh.Client, error) {
-	if numHops <= 0 || numHops > len(p.hopsConfigs) {
-		return nil, fmt.Errorf("invalid hop count: %d (total hops: %d)", numHops, len(p.hopsConfigs))
+	client, _, err := dialHops(p.GetHopsConfigs(), numHops, nil)
+	return client, err
+}
+
+// TestHops 依次连接所有 hop 后立即断开，用于在保存配置前检查连接是否可用
+func TestHops(hopsConfigs []SSHHopConfig) error {
+	hops := make([]SSHHopConfig, len(hopsConfigs))
+	copy(hops, hopsConfigs)
+	sortHopsByOrder(hops)
+
+	client, _, err := dialHops(hops, len(hops), nil)
+	if err != nil {
+		pkg.Logger.Warn().Err(err).Strs("hops", hopDisplayNames(hops)).Msg("[SSHHopsProxy] 测试连接失败")
+		return err
+	}
+	pkg.Logger.Info().Strs("hops", hopDisplayNames(hops)).Msg("[SSHHopsProxy] 测试连接成功")
+	return client.Close()
+}
+
+// dialHops 依次通过前一个 hop 连接下一个 hop，返回第 numHops 个 hop 的 SSH client
+// onHop 非空时在连接每个 hop 前调用；失败时返回出错 hop 的下标（hop 数量无效时为 -1），已建立的连接会被关闭
+func dialHops(hopsConfigs []SSHHopConfig, numHops int, onHop func(index int)) (*ssh.Client, int, error) {
+	if numHops <= 0 || numHops > len(hopsConfigs) {
+		return nil, -1, fmt.Errorf("invalid hop count: %d (total hops: %d)", numHops, len(hopsConfigs))
 	}
 
 	var currentClient *ssh.Client
+	fail := func(i int, err error) (*ssh.Client, int, error) {
+		if currentClient != nil {
+			currentClient.Close()
+		}
+		return nil, i, err
+	}
 	for i := 0; i < numHops; i++ {
-		hopConfig := p.hopsConfigs[i]
+		hopConfig := hopsConfigs[i]
+		if onHop != nil {
+			onHop(i)
+		}
+		// 配置未经校验时避免解引用空 host
+		if hopConfig.Host == nil || *hopConfig.Host == "" {
+			return fail(i, fmt.Errorf("host is required for ssh hop %d", i+1))
+		}
 		port := 22
 		if hopConfig.Port != nil {
 			port = *hopConfig.Port
@@ -302,68 +341,58 @@ func (p *SSHHopsProxy) connectHops(numHops int) (*ssh.Client, error) {
 
 		sshClientConfig, err := transformSSHHopsConfigToSSHClientConfig(hopConfig)
 		if err != nil {
-			if currentClient != nil {
-				currentClient.Close()
-			}
-			return nil, fmt.Errorf("配置 SSH 跳板失败: %v", err)
+			return fail(i, fmt.Errorf("配置 SSH 跳板 %d 失败: %w", i+1, err))
 		}
 
 		if i == 0 {
 			// 第一个 hop：直接连接到第一台服务器
 			currentClient, err = ssh.Dial("tcp", sshAddress, sshClientConfig)
 			if err != nil {
-				return nil, fmt.Errorf("连接 hop %d 失败: %v", i+1, err)
+				return fail(i, fmt.Errorf("连接 hop %d (%s) 失败: %w", i+1, sshAddress, err))
 			}
 		} else {
 			// 后续 hop：通过前一个 client 的 Dial 方法连接到下一台服务器
 			conn, err := currentClient.Dial("tcp", sshAddress)
 			if err != nil {
-				if currentClient != nil {
-					currentClient.Close()
-				}
-				return nil, fmt.Errorf("通过前一个 hop 连接失败: %v", err)
+				return fail(i, fmt.Errorf("通过 hop %d 连接 hop %d (%s) 失败: %w", i, i+1, sshAddress, err))
 			}
 
 			// 基于这个 TCP 连接创建新的 SSH 客户端连接
 			nconn, chans, reqs, err := ssh.NewClientConn(conn, sshAddress, sshClientConfig)
 			if err != nil {
 				conn.Close()
-				if currentClient != nil {
-					currentClient.Close()
-				}
-				return nil, fmt.Errorf("创建 SSH 客户端连接失败: %v", err)
+				return fail(i, fmt.Errorf("创建 hop %d (%s) 的 SSH 客户端连接失败: %w", i+1, sshAddress, err))
 			}
 
-			// 创建新的 SSH 客户端
+			// 创建新的 SSH 客户端（前一个 client 会自动通过连接链保持）
+			// 注意：不要关闭前一个 client，因为它被新 client 使用
 			currentClient = ssh.NewClient(nconn, chans, reqs)
 		}
+		pkg.Logger.Debug().Int("hop_index", i+1).Str("alias", GetHopDisplayName(hopConfig)).Str("address", sshAddress).Msg("[SSHHopsProxy] hop 连接成功")
 	}
 
-	return currentClient, nil
+	return currentClient, -1, nil
 }
 
 func (p *SSHHopsProxy) Disconnect() {
 	pkg.Logger.Debug().Str("config_name", p.configName).Msg("[SSHHopsProxy] 断开连接")
-	// 停止健康检查
-	p.stopHealthCheck()
+	// 停止重连重试
+	p.stopReconnect()
 
+	p.mu.Lock()
+	// 正在进行的连接完成后不再安装 client
+	p.connectGen++
+	p.stopHealthCheck()
 	// 停止 services 代理
 	p.StopServices()
+	p.closeClients()
+	p.mu.Unlock()
 
-	// 关闭所有 hopOrder 的 client
-	for hopOrder, client := range p.hopClients {
-		if client != nil {
-			client.Close()
-			pkg.Logger.Debug().Str("config_name", p.configName).Int("hopOrder", hopOrder).Msg("[SSHHopsProxy] 关闭 hopOrder client")
-		}
-	}
-	p.hopClients = make(map[int]*ssh.Client)
-
-	if p.client != nil {
-		p.client.Close()
-		p.client = nil
-	}
+	p.setDisconnectedStatus()
+}
 
+// setDisconnectedStatus 将状态重置为未连接
+func (p *SSHHopsProxy) setDisconnectedStatus() {
 	p.updateStatus(func(s *SSHProxyStatus) {
 		s.IsConnecting = false
 		s.IsConnected = false
@@ -379,6 +408,7 @@ func (p *SSHHopsProxy) Disconnect() {
 
 // SSH Client 连接状态检查。
 // ------------------------------------------------------------
+// StartHealthCheck 启动健康检查循环，调用方需持有 mu
 func (p *SSHHopsProxy) StartHealthCheck() {
 	if p.healthStop != nil {
 		return // 已经在运行
@@ -386,16 +416,16 @@ func (p *SSHHopsProxy) StartHealthCheck() {
 
 	pkg.Logger.Debug().Str("config_name", p.configName).Dur("interval", p.healthCheckInterval).Msg("[SSHHopsProxy] 启动健康检查")
 	p.healthStop = make(chan struct{})
-	go p.healthCheckLoop()
+	go p.healthCheckLoop(p.healthStop)
 }
 
-func (p *SSHHopsProxy) healthCheckLoop() {
+func (p *SSHHopsProxy) healthCheckLoop(stop chan struct{}) {
 	ticker := time.NewTicker(p.healthCheckInterval)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-p.healthStop:
+		case <-stop:
 			return
 		case <-ticker.C:
 			p.CheckHealth()
@@ -404,7 +434,11 @@ func (p *SSHHopsProxy) healthCheckLoop() {
 }
 
 func (p *SSHHopsProxy) CheckHealth() {
-	if p.client == nil {
+	p.mu.Lock()
+	client := p.client
+	generation := p.connectGen
+	p.mu.Unlock()
+	if client == nil {
 		return
 	}
 
@@ -414,7 +448,7 @@ func (p *SSHHopsProxy) CheckHealth() {
 	})
 
 	// 检查连接是否正常
-	conn := p.client.Conn
+	conn := client.Conn
 	if conn == nil {
 		pkg.Logger.Debug().Str("config_name", p.configName).Msg("[SSHHopsProxy] 健康检查失败: SSH 连接已断开")
 		p.updateStatus(func(s *SSHProxyStatus) {
@@ -425,12 +459,12 @@ func (p *SSHHopsProxy) CheckHealth() {
 			s.IsConnected = false
 		})
 		// 触发重连
-		go p.Reconnect()
+		go p.reconnect(generation)
 		return
 	}
 
 	// 尝试创建SSH会话
-	session, err := p.client.NewSession()
+	session, err := client.NewSession()
 	if err != nil {
 		pkg.Logger.Debug().Err(err).Str("config_name", p.configName).Msg("[SSHHopsProxy] 健康检查失败: 无法创建 SSH 会话")
 		p.updateStatus(func(s *SSHProxyStatus) {
@@ -441,7 +475,7 @@ func (p *SSHHopsProxy) CheckHealth() {
 			s.IsConnected = false
 		})
 		// 触发重连
-		go p.Reconnect()
+		go p.reconnect(generation)
 		return
 	}
 	defer session.Close()
@@ -458,7 +492,7 @@ func (p *SSHHopsProxy) CheckHealth() {
 			s.IsConnected = false
 		})
 		// 触发重连
-		go p.Reconnect()
+		go p.reconnect(generation)
 		return
 	}
 
@@ -472,6 +506,7 @@ func (p *SSHHopsProxy) CheckHealth() {
 	})
 }
 
+// stopHealthCheck 调用方需持有 mu
 func (p *SSHHopsProxy) stopHealthCheck() {
 	if p.healthStop != nil {
 		select {
@@ -486,35 +521,141 @@ func (p *SSHHopsProxy) stopHealthCheck() {
 
 // ============================================================
 
-// Reconnect 重连逻辑（立即重连，无退避策略）
+// 重连失败后的退避间隔，每次失败翻倍
+const (
+	reconnectInitialBackoff = time.Second
+	reconnectMaxBackoff     = time.Minute
+)
+
+// Reconnect 关闭当前连接并重连，失败时按指数退避重试，直到成功或 Disconnect
 func (p *SSHHopsProxy) Reconnect() {
-	if p.Status.IsConnecting {
+	p.mu.Lock()
+	generation := p.connectGen
+	p.mu.Unlock()
+	p.reconnect(generation)
+}
+
+// reconnect generation 为触发重连时的 connectGen，之后调用了 Disconnect 时不再重连
+func (p *SSHHopsProxy) reconnect(generation int) {
+	p.reconnectMu.Lock()
+	if p.GetStatus().IsConnecting || p.reconnectStop != nil {
+		p.reconnectMu.Unlock()
 		return // 已经在重连中
 	}
+	stop := make(chan struct{})
+	p.reconnectStop = stop
+	p.reconnectMu.Unlock()
+
+	defer func() {
+		p.reconnectMu.Lock()
+		if p.reconnectStop == stop {
+			p.reconnectStop = nil
+		}
+		p.reconnectMu.Unlock()
+	}()
 
-	p.updateStatus(func(s *SSHProxyStatus) {
-		s.ReconnectAttempts++
-		s.LastReconnectAttempt = time.Now()
-		s.CurrentInfo = fmt.Sprintf("正在重连 (尝试 %d)...", s.ReconnectAttempts)
-	})
+	p.mu.Lock()
+	if p.connectGen != generation {
+		p.mu.Unlock()
+		return // 已断开连接
+	}
+	// 保持 services 代理监听，重连期间返回 503 提示页而不是让请求挂起
+	if p.serviceProxy != nil {
+		p.serviceProxy.SetReconnecting(true)
+	}
+	// 关闭当前连接
+	p.closeClients()
+	p.mu.Unlock()
 
-	pkg.Logger.Info().Str("config_name", p.configName).Int("attempt", p.Status.ReconnectAttempts+1).Msg("[SSHHopsProxy] 开始重连")
+	backoff := reconnectInitialBackoff
+	for {
+		attempt := 0
+		p.updateStatus(func(s *SSHProxyStatus) {
+			s.ReconnectAttempts++
+			s.LastReconnectAttempt = time.Now()
+			s.CurrentInfo = fmt.Sprintf("正在重连 (尝试 %d)...", s.ReconnectAttempts)
+			attempt = s.ReconnectAttempts
+		})
+		pkg.Logger.Info().Str("config_name", p.configName).Int("attempt", attempt).Msg("[SSHHopsProxy] 开始重连")
 
-	// 停止当前的 services 代理
-	p.StopServices()
+		// 重新连接（connect 会自动恢复 services 代理）
+		connected := p.connect(generation)
+		if !connected && p.isCancelled(generation) {
+			return
+		}
+		p.mu.Lock()
+		hopsChanged := connected && p.hasHopsChanged()
+		if hopsChanged {
+			// 连接期间 hop 配置又发生了变化，立即使用新配置重连
+			p.closeClients()
+		}
+		p.mu.Unlock()
+		if hopsChanged {
+			pkg.Logger.Info().Str("config_name", p.configName).Msg("[SSHHopsProxy] 重连期间 hop 配置变化，使用新配置重新连接")
+			backoff = reconnectInitialBackoff
+			continue
+		}
+		if connected {
+			return
+		}
+
+		pkg.Logger.Warn().Err(p.GetStatus().LastError).Str("config_name", p.configName).Dur("backoff", backoff).Msg("[SSHHopsProxy] 重连失败，稍后重试")
+		p.updateStatus(func(s *SSHProxyStatus) {
+			s.CurrentInfo = fmt.Sprintf("重连失败，%s 后重试: %v", backoff, s.LastError)
+		})
+		select {
+		case <-stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, reconnectMaxBackoff)
+	}
+}
+
+// isReconnecting 是否处于重连循环中（含退避等待）
+func (p *SSHHopsProxy) isReconnecting() bool {
+	p.reconnectMu.Lock()
+	defer p.reconnectMu.Unlock()
+	return p.reconnectStop != nil
+}
+
+// closeClients 关闭所有 SSH client，调用方需持有 mu
+func (p *SSHHopsProxy) closeClients() {
+	p.hopClientsMu.Lock()
+	for hopOrder, client := range p.hopClients {
+		if client != nil {
+			client.Close()
+			pkg.Logger.Debug().Str("config_name", p.configName).Int("hopOrder", hopOrder).Msg("[SSHHopsProxy] 关闭 hopOrder client")
+		}
+	}
+	p.hopClients = make(map[int]*ssh.Client)
 
-	// 关闭当前连接
 	if p.client != nil {
 		p.client.Close()
 		p.client = nil
 	}
+	p.hopClientsMu.Unlock()
+}
 
-	// 重新连接（Connect() 会自动启动 services 代理）
-	p.Connect()
+// stopReconnect 停止正在等待的重连重试
+func (p *SSHHopsProxy) stopReconnect() {
+	p.reconnectMu.Lock()
+	defer p.reconnectMu.Unlock()
+	if p.reconnectStop != nil {
+		close(p.reconnectStop)
+		p.reconnectStop = nil
+	}
 }
 
 // StartServices 启动 services 代理
 func (p *SSHHopsProxy) StartServices(services []SSHService, localPort string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.startServices(services, localPort)
+}
+
+// startServices 调用方需持有 mu
+func (p *SSHHopsProxy) startServices(services []SSHService, localPort string) error {
 	if p.client == nil {
 		return fmt.Errorf("SSH client is not connected")
 	}
@@ -523,6 +664,17 @@ func (p *SSHHopsProxy) StartServices(services []SSHService, localPort string) er
 	p.services = services
 	p.localPort = localPort
 
+	// 重连成功：复用仍在监听的 services 代理，只替换 client
+	if p.serviceProxy != nil && p.serviceProxy.IsReconnecting() && p.serviceProxy.localPort == localPort {
+		// 重连期间热更新的 services 与设置一并生效
+		p.serviceProxy.SetSettings(p.proxySettings)
+		p.serviceProxy.UpdateServices(services)
+		p.serviceProxy.SetHopNames(p.hopNames())
+		p.serviceProxy.ResumeWithClient(p.client)
+		pkg.Logger.Info().Str("config_name", p.configName).Msg("[SSHHopsProxy] 重连成功，services 代理恢复转发")
+		return nil
+	}
+
 	// 停止现有的 services 代理
 	p.StopServices()
 
@@ -531,13 +683,132 @@ func (p *SSHHopsProxy) StartServices(services []SSHService, localPort string) er
 		return p.GetClientForHopOrder(hopOrder)
 	}
 	sp := NewServiceProxyWithHopSelector(p.configName, localPort, services, p.client, getClientForHop)
+	sp.SetSettings(p.proxySettings)
+	sp.SetFaultsPaused(p.faultsPaused)
+	sp.SetHopNames(p.hopNames())
 	p.serviceProxy = sp
 
 	// 启动 services 代理
 	return sp.StartReverseProxy()
 }
 
-// StopServices 停止 services 代理
+// ApplyServiceConfig 热更新 services、本地端口与代理设置（配置文件变更时），不断开 SSH 连接
+// 本地端口变化时重建 services 代理，其余情况原地更新路由
+func (p *SSHHopsProxy) ApplyServiceConfig(services []SSHService, localPort string, settings *ServiceProxySettings) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.services = services
+	p.proxySettings = settings
+	if p.serviceProxy == nil || p.client == nil {
+		// 尚未启动，连接成功后按新配置启动
+		p.localPort = localPort
+		return nil
+	}
+
+	// 新增的服务可能引用新的 hopOrder
+	p.createHopOrderClients()
+
+	if localPort != p.localPort {
+		pkg.Logger.Info().Str("config_name", p.configName).Str("old_port", p.localPort).Str("new_port", localPort).Msg("[SSHHopsProxy] 本地端口变化，重启 services 代理")
+		return p.startServices(services, localPort)
+	}
+
+	p.serviceProxy.SetSettings(settings)
+	p.serviceProxy.UpdateServices(services)
+	pkg.Logger.Info().Str("config_name", p.configName).Int("services_count", len(services)).Msg("[SSHHopsProxy] services 配置已热更新")
+	return nil
+}
+
+// ReconnectWithHops 使用新的 hop 配置重新连接，services 代理在重连期间保持监听
+// 正在连接或重连时只记录新配置，当前连接完成后再使用新配置重连
+func (p *SSHHopsProxy) ReconnectWithHops(hopsConfigs []SSHHopConfig) {
+	p.hopsMu.Lock()
+	p.hopsConfigs = hopsConfigs
+	p.hopsChanged = true
+	p.hopsMu.Unlock()
+	pkg.Logger.Info().Str("config_name", p.configName).Int("hops_count", len(hopsConfigs)).Msg("[SSHHopsProxy] hop 配置变化，重新连接")
+	p.Reconnect()
+}
+
+// hopNames 按顺序返回 hop 的展示名称
+func (p *SSHHopsProxy) hopNames() []string {
+	return hopDisplayNames(p.GetHopsConfigs())
+}
+
+// hasHopsChanged 开始连接后 hop 配置是否又发生了变化
+func (p *SSHHopsProxy) hasHopsChanged() bool {
+	p.hopsMu.RLock()
+	defer p.hopsMu.RUnlock()
+	return p.hopsChanged
+}
+
+func hopDisplayNames(hopsConfigs []SSHHopConfig) []string {
+	hopNames := make([]string, 0, len(hopsConfigs))
+	for _, hopConfig := range hopsConfigs {
+		hopNames = append(hopNames, GetHopDisplayName(hopConfig))
+	}
+	return hopNames
+}
+
+// sortHopsByOrder 按 order 排序 hop（未设置 order 视为 0）
+func sortHopsByOrder(hopsConfigs []SSHHopConfig) {
+	sort.Slice(hopsConfigs, func(i, j int) bool {
+		orderI := 0
+		orderJ := 0
+		if hopsConfigs[i].Order != nil {
+			orderI = *hopsConfigs[i].Order
+		}
+		if hopsConfigs[j].Order != nil {
+			orderJ = *hopsConfigs[j].Order
+		}
+		return orderI < orderJ
+	})
+}
+
+// ToggleFaults 暂停或恢复故障注入，返回切换后是否处于生效状态
+// 没有服务配置 faults 时返回错误
+func (p *SSHHopsProxy) ToggleFaults() (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.serviceProxy == nil {
+		return false, fmt.Errorf("service proxy is not running")
+	}
+	if !p.serviceProxy.HasFaults() {
+		return false, fmt.Errorf("no service has [services.faults] configured")
+	}
+	p.faultsPaused = !p.faultsPaused
+	p.serviceProxy.SetFaultsPaused(p.faultsPaused)
+	pkg.Logger.Info().Str("config_name", p.configName).Bool("paused", p.faultsPaused).Msg("[SSHHopsProxy] 切换故障注入")
+	return !p.faultsPaused, nil
+}
+
+// PurgeCache 清空 services 代理的本地响应缓存，返回清除的条目数
+func (p *SSHHopsProxy) PurgeCache() (int, error) {
+	p.mu.Lock()
+	serviceProxy := p.serviceProxy
+	p.mu.Unlock()
+	if serviceProxy == nil {
+		return 0, fmt.Errorf("service proxy is not running")
+	}
+	count := serviceProxy.PurgeCache()
+	pkg.Logger.Info().Str("config_name", p.configName).Int("count", count).Msg("[SSHHopsProxy] 清空本地缓存")
+	return count, nil
+}
+
+// Replay 通过当前的 services 代理重放请求
+func (p *SSHHopsProxy) Replay(replay ReplayRequest) error {
+	p.mu.Lock()
+	serviceProxy := p.serviceProxy
+	p.mu.Unlock()
+	if serviceProxy == nil {
+		return fmt.Errorf("service proxy is not running")
+	}
+	pkg.Logger.Debug().Str("config_name", p.configName).Str("replay_of", replay.ReplayOf).Str("method", replay.Method).Msg("[SSHHopsProxy] 重放请求")
+	return serviceProxy.Replay(replay)
+}
+
+// StopServices 停止 services 代理，调用方需持有 mu
 func (p *SSHHopsProxy) StopServices() {
 	if p.serviceProxy != nil {
 		p.serviceProxy.StopReverseProxy()
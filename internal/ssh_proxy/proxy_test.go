@@ -0,0 +1,73 @@
+package ssh_proxy
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// 热更新替换 services 代理的同时切换故障注入、清空缓存与选择 client，用 go test -race 检查
+func TestApplyServiceConfigDuringToggle(t *testing.T) {
+	services := []SSHService{{
+		Alias:     strPtr("web"),
+		Subdomain: strPtr("web"),
+		Faults:    &ServiceFaults{LatencyMs: intPtr(1)},
+		Cache:     &ServiceCache{Enabled: boolPtr(true)},
+	}}
+	p := NewSSHHopsProxy("test", nil, time.Minute, services, "")
+	p.client = &ssh.Client{}
+	ports := []string{freePort(t), freePort(t)}
+	if err := p.StartServices(services, ports[0]); err != nil {
+		t.Fatalf("StartServices: %v", err)
+	}
+	t.Cleanup(func() {
+		p.mu.Lock()
+		p.StopServices()
+		p.mu.Unlock()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i <= 200; i++ {
+			// 交替切换端口，每次都会重建 services 代理
+			if err := p.ApplyServiceConfig(services, ports[i%2], nil); err != nil {
+				t.Errorf("ApplyServiceConfig: %v", err)
+			}
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		if _, err := p.ToggleFaults(); err != nil {
+			t.Fatalf("ToggleFaults: %v", err)
+		}
+		if _, err := p.PurgeCache(); err != nil {
+			t.Fatalf("PurgeCache: %v", err)
+		}
+		if p.GetClientForHopOrder(1) == nil {
+			t.Fatalf("GetClientForHopOrder returned nil")
+		}
+	}
+	<-done
+
+	// 重建后的 services 代理保留暂停状态
+	p.mu.Lock()
+	paused := p.faultsPaused
+	p.mu.Unlock()
+	active, err := p.ToggleFaults()
+	if err != nil || active != paused {
+		t.Errorf("ToggleFaults = %v, %v; want %v", active, err, paused)
+	}
+}
+
+func freePort(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+}
@@ -0,0 +1,205 @@
+package ssh_proxy
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"ssh-messer/internal/pubsub"
+)
+
+// 本地代理访问控制
+// ------------------------------------------------------------
+const (
+	accessTokenHeader = "X-Messer-Token"
+	accessTokenCookie = "messer_token"
+	accessTokenQuery  = "messer_token"
+	accessRealm       = "ssh-messer"
+	accessDeniedAlias = "access denied"
+)
+
+// accessControl 编译后的访问控制配置，未启用时为 nil
+type accessControl struct {
+	token      string
+	username   string
+	password   string
+	allowCIDRs []netip.Prefix
+}
+
+// buildAccessControl 根据设置构建访问控制，未配置任何规则时返回 nil
+func buildAccessControl(settings *AccessSettings) (*accessControl, error) {
+	if settings == nil {
+		return nil, nil
+	}
+
+	access := &accessControl{}
+	// token 与 basic_auth 中的引用已由 TomlConfig.Resolve 解析
+	if settings.Token != nil && *settings.Token != "" {
+		access.token = *settings.Token
+	}
+	if auth := settings.BasicAuth; auth != nil {
+		basicAuth, err := buildBasicAuth(auth)
+		if err != nil {
+			return nil, fmt.Errorf("access basic_auth: %w", err)
+		}
+		access.username = basicAuth.username
+		access.password = basicAuth.password
+	}
+	for _, cidr := range settings.AllowCIDRs {
+		prefix, err := parseAllowCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		access.allowCIDRs = append(access.allowCIDRs, prefix)
+	}
+
+	if access.token == "" && access.username == "" && len(access.allowCIDRs) == 0 {
+		return nil, nil
+	}
+	return access, nil
+}
+
+// parseAllowCIDR 解析 CIDR，单个 IP 视为 /32 或 /128
+func parseAllowCIDR(cidr string) (netip.Prefix, error) {
+	cidr = strings.TrimSpace(cidr)
+	if !strings.Contains(cidr, "/") {
+		addr, err := netip.ParseAddr(cidr)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid allow_cidrs entry %q: %w", cidr, err)
+		}
+		return netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid allow_cidrs entry %q: %w", cidr, err)
+	}
+	return prefix.Masked(), nil
+}
+
+// check 校验请求，返回拒绝时的状态码与原因；允许访问时状态码为 0
+// IP 白名单为必要条件，token 与 basic auth 任一通过即可
+func (a *accessControl) check(w http.ResponseWriter, r *http.Request) (int, string) {
+	if a == nil {
+		return 0, ""
+	}
+
+	if len(a.allowCIDRs) > 0 && !a.clientAllowed(r.RemoteAddr) {
+		return http.StatusForbidden, fmt.Sprintf("client %s is not in allow_cidrs", r.RemoteAddr)
+	}
+	if a.token == "" && a.username == "" {
+		return 0, ""
+	}
+
+	if a.token != "" {
+		if token := r.URL.Query().Get(accessTokenQuery); token != "" && secureEqual(token, a.token) {
+			// 通过 URL 携带 token 时写入 cookie，后续请求无需再带参数
+			http.SetCookie(w, &http.Cookie{
+				Name:     accessTokenCookie,
+				Value:    token,
+				Path:     "/",
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+			stripAccessToken(r)
+			return 0, ""
+		}
+		if secureEqual(r.Header.Get(accessTokenHeader), a.token) {
+			stripAccessToken(r)
+			return 0, ""
+		}
+		if cookie, err := r.Cookie(accessTokenCookie); err == nil && secureEqual(cookie.Value, a.token) {
+			stripAccessToken(r)
+			return 0, ""
+		}
+	}
+	if a.username != "" {
+		if username, password, ok := r.BasicAuth(); ok && secureEqual(username, a.username) && secureEqual(password, a.password) {
+			// 本地认证信息不转发到上游
+			r.Header.Del("Authorization")
+			stripAccessToken(r)
+			return 0, ""
+		}
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf("Basic realm=%q", accessRealm))
+	}
+	return http.StatusUnauthorized, "missing or invalid access credentials"
+}
+
+// stripAccessToken 移除请求中的访问 token（query、header 与 cookie），避免泄露给上游服务
+func stripAccessToken(r *http.Request) {
+	if query := r.URL.Query(); query.Has(accessTokenQuery) {
+		query.Del(accessTokenQuery)
+		r.URL.RawQuery = query.Encode()
+	}
+	r.Header.Del(accessTokenHeader)
+
+	cookies := r.Cookies()
+	if len(cookies) == 0 {
+		return
+	}
+	kept := make([]string, 0, len(cookies))
+	for _, cookie := range cookies {
+		if cookie.Name != accessTokenCookie {
+			kept = append(kept, cookie.String())
+		}
+	}
+	if len(kept) == len(cookies) {
+		return
+	}
+	r.Header.Del("Cookie")
+	if len(kept) > 0 {
+		r.Header.Set("Cookie", strings.Join(kept, "; "))
+	}
+}
+
+func (a *accessControl) clientAllowed(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range a.allowCIDRs {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func secureEqual(a, b string) bool {
+	return a != "" && subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// publishAccessDenied 将被拒绝的访问发布到日志面板
+func (sp *ServiceProxy) publishAccessDenied(r *http.Request, statusCode int, reason string) {
+	// 日志中不记录尝试使用的 token
+	target := *r.URL
+	query := target.Query()
+	if query.Has(accessTokenQuery) {
+		query.Del(accessTokenQuery)
+		target.RawQuery = query.Encode()
+	}
+
+	requestID := generateRequestID()
+	now := time.Now()
+	serviceProxyLogBroker.Publish(pubsub.UpdatedEvent, ServiceProxyLogEvent{
+		RequestID:    requestID,
+		ConfigName:   sp.configName,
+		ServiceAlias: accessDeniedAlias,
+		Method:       r.Method,
+		URL:          r.Host + target.RequestURI(),
+		StatusCode:   statusCode,
+		Timestamp:    now,
+		IsUpdate:     true,
+		ErrorMessage: truncateErrorMessage(fmt.Sprintf("access denied from %s: %s", r.RemoteAddr, reason)),
+	})
+}
+
+// ============================================================
@@ -0,0 +1,161 @@
+package ssh_proxy
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Service 多上游目标的负载均衡与故障转移
+// ------------------------------------------------------------
+const (
+	BalanceRoundRobin = "round_robin" // 轮询（默认）
+	BalanceFailover   = "failover"    // 按顺序使用第一个可用目标
+
+	backendDownDuration = 30 * time.Second // 连接失败的目标被标记为不可用的时长
+)
+
+// backend 单个上游目标
+type backend struct {
+	addr       string // host:port
+	hostHeader string // 未配置 remote_host 时使用目标 host 作为 Host header
+	serverName string // 未配置 tls_server_name / remote_host 时使用目标 host 作为 SNI
+	hopOrder   *int
+}
+
+// backendPool 服务的上游目标池，被动健康标记在连接失败时更新
+type backendPool struct {
+	backends  []backend
+	failover  bool
+	next      atomic.Uint64
+	mu        sync.Mutex
+	downUntil []time.Time
+}
+
+// backendDialError 通过 SSH 连接上游目标失败，此时请求尚未发送，可以安全地换目标重试
+type backendDialError struct {
+	addr string
+	err  error
+}
+
+func (e *backendDialError) Error() string {
+	return fmt.Sprintf("failed to dial remote service through SSH (target: %s): %v", e.addr, e.err)
+}
+
+func (e *backendDialError) Unwrap() error {
+	return e.err
+}
+
+// buildBackendPools 为配置了 targets 的服务构建目标池
+func buildBackendPools(services []SSHService) (map[*SSHService]*backendPool, []error) {
+	pools := make(map[*SSHService]*backendPool)
+	var errs []error
+
+	for i := range services {
+		service := &services[i]
+		if len(service.Targets) == 0 {
+			continue
+		}
+		name := ServiceDisplayName(service)
+
+		pool := &backendPool{}
+		if service.Balance != nil && *service.Balance != "" {
+			switch *service.Balance {
+			case BalanceRoundRobin:
+			case BalanceFailover:
+				pool.failover = true
+			default:
+				errs = append(errs, fmt.Errorf("service %s: unknown balance %q, using %s", name, *service.Balance, BalanceRoundRobin))
+			}
+		}
+
+		for j, target := range service.Targets {
+			if target.Host == nil || *target.Host == "" {
+				errs = append(errs, fmt.Errorf("service %s: target #%d is missing host", name, j+1))
+				continue
+			}
+			port := "80"
+			if target.Port != nil && *target.Port != "" {
+				port = *target.Port
+			}
+			b := backend{
+				addr:     net.JoinHostPort(*target.Host, port),
+				hopOrder: service.HopOrder,
+			}
+			if target.HopOrder != nil {
+				b.hopOrder = target.HopOrder
+			}
+			if service.RemoteHost == nil || *service.RemoteHost == "" {
+				b.hostHeader = *target.Host
+				if service.TLSServerName == nil || *service.TLSServerName == "" {
+					b.serverName = *target.Host
+				}
+			}
+			pool.backends = append(pool.backends, b)
+		}
+		if len(pool.backends) == 0 {
+			continue
+		}
+		pool.downUntil = make([]time.Time, len(pool.backends))
+		pools[service] = pool
+	}
+	return pools, errs
+}
+
+// candidates 返回本次请求尝试目标的顺序：可用目标在前，被标记不可用的目标兜底
+func (p *backendPool) candidates() []int {
+	count := len(p.backends)
+	start := 0
+	if !p.failover {
+		start = int(p.next.Add(1)-1) % count
+	}
+
+	now := time.Now()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	healthy := make([]int, 0, count)
+	var down []int
+	for k := 0; k < count; k++ {
+		i := (start + k) % count
+		if now.Before(p.downUntil[i]) {
+			down = append(down, i)
+		} else {
+			healthy = append(healthy, i)
+		}
+	}
+	return append(healthy, down...)
+}
+
+func (p *backendPool) markDown(i int) {
+	p.mu.Lock()
+	p.downUntil[i] = time.Now().Add(backendDownDuration)
+	p.mu.Unlock()
+}
+
+func (p *backendPool) markUp(i int) {
+	p.mu.Lock()
+	p.downUntil[i] = time.Time{}
+	p.mu.Unlock()
+}
+
+// addrs 返回所有目标地址，用于服务概览展示
+func (p *backendPool) addrs() string {
+	addrs := make([]string, 0, len(p.backends))
+	for _, b := range p.backends {
+		addrs = append(addrs, b.addr)
+	}
+	return strings.Join(addrs, ", ")
+}
+
+// isBackendDialError 判断错误是否为连接上游目标失败
+func isBackendDialError(err error) bool {
+	var dialErr *backendDialError
+	return errors.As(err, &dialErr)
+}
+
+// ============================================================
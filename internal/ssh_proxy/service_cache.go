@@ -0,0 +1,321 @@
+package ssh_proxy
+
+import (
+	"bytes"
+	"container/list"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Service 本地 HTTP 缓存
+// ------------------------------------------------------------
+const (
+	defaultCacheMaxEntries   = 200
+	defaultCacheMaxBodyBytes = 1024 * 1024
+
+	CacheStatusHit         = "HIT"         // 直接使用未过期的缓存
+	CacheStatusRevalidated = "REVALIDATED" // 上游返回 304，使用缓存内容
+	CacheStatusMiss        = "MISS"        // 未命中，响应已缓存或不可缓存
+)
+
+// cacheEntry 缓存的响应，放入缓存后不再修改（304 重新验证时替换为新的条目）
+type cacheEntry struct {
+	key        string
+	statusCode int
+	header     http.Header
+	body       []byte
+	storedAt   time.Time
+	expiresAt  time.Time
+	vary       map[string]string // Vary 中列出的请求 header 在存储时的值
+	public     bool              // Cache-Control: public，可以用于携带凭据的请求
+}
+
+func (e *cacheEntry) fresh(now time.Time) bool {
+	return now.Before(e.expiresAt)
+}
+
+// matches 请求是否可以使用该条目：Vary 中的请求 header 必须一致，携带凭据的请求只能使用 public 响应
+func (e *cacheEntry) matches(r *http.Request) bool {
+	if requestHasCredentials(r) && !e.public {
+		return false
+	}
+	for name, value := range e.vary {
+		if r.Header.Get(name) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// responseCache 单个服务的 LRU 缓存
+type responseCache struct {
+	maxEntries   int
+	maxBodyBytes int
+	mu           sync.Mutex
+	entries      map[string]*list.Element
+	lru          *list.List
+}
+
+// buildResponseCaches 为启用 cache 的服务创建缓存
+func buildResponseCaches(services []SSHService) map[*SSHService]*responseCache {
+	caches := make(map[*SSHService]*responseCache)
+	for i := range services {
+		settings := services[i].Cache
+		if settings == nil || settings.Enabled == nil || !*settings.Enabled {
+			continue
+		}
+		cache := &responseCache{
+			maxEntries:   defaultCacheMaxEntries,
+			maxBodyBytes: defaultCacheMaxBodyBytes,
+			entries:      make(map[string]*list.Element),
+			lru:          list.New(),
+		}
+		if settings.MaxEntries != nil && *settings.MaxEntries > 0 {
+			cache.maxEntries = *settings.MaxEntries
+		}
+		if settings.MaxBodyBytes != nil && *settings.MaxBodyBytes > 0 {
+			cache.maxBodyBytes = *settings.MaxBodyBytes
+		}
+		caches[&services[i]] = cache
+	}
+	return caches
+}
+
+// cacheKey 只缓存 GET；Accept-Encoding 不同的请求分别缓存，其他 Vary header 由 cacheEntry.matches 检查
+func cacheKey(r *http.Request) (string, bool) {
+	if r.Method != http.MethodGet || r.Header.Get("Range") != "" {
+		return "", false
+	}
+	return r.Host + " " + r.URL.RequestURI() + " " + r.Header.Get("Accept-Encoding"), true
+}
+
+func (c *responseCache) get(key string) *cacheEntry {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	c.lru.MoveToFront(element)
+	return element.Value.(*cacheEntry)
+}
+
+func (c *responseCache) put(entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[entry.key]; ok {
+		element.Value = entry
+		c.lru.MoveToFront(element)
+		return
+	}
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	for c.lru.Len() > c.maxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// refresh 304 后用新的过期时间与 header 生成新条目并替换旧条目，返回新条目
+// 旧条目可能正在被其他请求读取，因此不原地修改
+func (c *responseCache) refresh(entry *cacheEntry, resp *http.Response, now time.Time) *cacheEntry {
+	refreshed := *entry
+	refreshed.header = entry.header.Clone()
+	for _, name := range []string{"Cache-Control", "Expires", "Etag", "Last-Modified", "Date"} {
+		if value := resp.Header.Get(name); value != "" {
+			refreshed.header.Set(name, value)
+		}
+	}
+	refreshed.storedAt = now
+	refreshed.expiresAt = now.Add(freshnessLifetime(refreshed.header, now))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// 条目已被淘汰或替换时不再写回
+	if element, ok := c.entries[entry.key]; ok && element.Value == entry {
+		element.Value = &refreshed
+	}
+	return &refreshed
+}
+
+func (c *responseCache) purge() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	count := c.lru.Len()
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	return count
+}
+
+// parseCacheControl 解析 Cache-Control 指令
+func parseCacheControl(value string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name != "" {
+			directives[strings.ToLower(name)] = strings.Trim(arg, `"`)
+		}
+	}
+	return directives
+}
+
+// requestBypassesCache 客户端要求不使用缓存（如浏览器强制刷新）
+func requestBypassesCache(r *http.Request) bool {
+	directives := parseCacheControl(r.Header.Get("Cache-Control"))
+	_, noCache := directives["no-cache"]
+	_, noStore := directives["no-store"]
+	return noCache || noStore || r.Header.Get("Pragma") == "no-cache"
+}
+
+// freshnessLifetime 根据 Cache-Control 的 s-maxage / max-age 或 Expires 计算有效期
+func freshnessLifetime(header http.Header, now time.Time) time.Duration {
+	directives := parseCacheControl(header.Get("Cache-Control"))
+	if _, ok := directives["no-cache"]; ok {
+		return 0
+	}
+	for _, name := range []string{"s-maxage", "max-age"} {
+		if value, ok := directives[name]; ok {
+			if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+				return time.Duration(seconds) * time.Second
+			}
+			return 0
+		}
+	}
+	if expires, err := http.ParseTime(header.Get("Expires")); err == nil && expires.After(now) {
+		return expires.Sub(now)
+	}
+	return 0
+}
+
+// requestHasCredentials 客户端请求是否携带凭据（访问控制使用的凭据此时已被移除）
+func requestHasCredentials(r *http.Request) bool {
+	return r.Header.Get("Authorization") != "" || r.Header.Get("Cookie") != ""
+}
+
+// responseVary 返回 Vary 中列出的 header 在请求中的值，Vary: * 时不可缓存
+func responseVary(resp *http.Response, r *http.Request) (map[string]string, bool) {
+	var vary map[string]string
+	for _, value := range resp.Header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+			switch name {
+			case "":
+			case "*":
+				return nil, false
+			default:
+				if vary == nil {
+					vary = make(map[string]string)
+				}
+				vary[name] = r.Header.Get(name)
+			}
+		}
+	}
+	return vary, true
+}
+
+// responseCacheable 只缓存 200 且未禁止缓存的响应；有效期为 0 但带 ETag/Last-Modified 的响应缓存后每次重新验证
+// 携带 Authorization / Cookie 的请求只有响应明确为 public 时才缓存
+func responseCacheable(resp *http.Response, r *http.Request) bool {
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+	directives := parseCacheControl(resp.Header.Get("Cache-Control"))
+	if _, ok := directives["no-store"]; ok {
+		return false
+	}
+	if _, ok := directives["private"]; ok {
+		return false
+	}
+	if _, public := directives["public"]; !public && requestHasCredentials(r) {
+		return false
+	}
+	if _, ok := responseVary(resp, r); !ok {
+		return false
+	}
+	if resp.Header.Get("Set-Cookie") != "" {
+		return false
+	}
+	now := time.Now()
+	return freshnessLifetime(resp.Header, now) > 0 || resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+}
+
+// writeCachedResponse 将缓存内容写给客户端
+func writeCachedResponse(w http.ResponseWriter, r *http.Request, entry *cacheEntry, cacheStatus string) {
+	for name, values := range entry.header {
+		w.Header()[name] = append([]string(nil), values...)
+	}
+	w.Header().Set("Age", strconv.Itoa(int(time.Since(entry.storedAt).Seconds())))
+	w.Header().Set("X-Messer-Cache", cacheStatus)
+	w.WriteHeader(entry.statusCode)
+	if r.Method != http.MethodHead {
+		w.Write(entry.body)
+	}
+}
+
+// cachedResponse 将缓存内容转换为 http.Response（用于 304 重新验证后返回给客户端）
+func cachedResponse(entry *cacheEntry, req *http.Request) *http.Response {
+	header := entry.header.Clone()
+	header.Set("X-Messer-Cache", CacheStatusRevalidated)
+	return &http.Response{
+		Status:        strconv.Itoa(entry.statusCode) + " " + http.StatusText(entry.statusCode),
+		StatusCode:    entry.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(entry.body)),
+		ContentLength: int64(len(entry.body)),
+		Request:       req,
+	}
+}
+
+// cachingReadCloser 读取完整 body 后写入缓存，超过大小限制则放弃缓存
+type cachingReadCloser struct {
+	io.ReadCloser
+	buf    bytes.Buffer
+	limit  int
+	over   bool
+	onDone func(body []byte)
+}
+
+func (c *cachingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	if n > 0 && !c.over {
+		if c.buf.Len()+n > c.limit {
+			c.over = true
+			c.buf.Reset()
+		} else {
+			c.buf.Write(p[:n])
+		}
+	}
+	if err == io.EOF && !c.over && c.onDone != nil {
+		c.onDone(c.buf.Bytes())
+		c.onDone = nil
+	}
+	return n, err
+}
+
+// PurgeCache 清空所有服务的本地缓存，返回清除的条目数
+func (sp *ServiceProxy) PurgeCache() int {
+	sp.mu.RLock()
+	caches := sp.caches
+	sp.mu.RUnlock()
+
+	count := 0
+	for _, cache := range caches {
+		count += cache.purge()
+	}
+	return count
+}
+
+// ============================================================
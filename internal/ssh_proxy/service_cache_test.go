@@ -0,0 +1,245 @@
+package ssh_proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCacheKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		header   map[string]string
+		want     string
+		wantSkip bool
+	}{
+		{name: "get", method: "GET", url: "http://api.localhost/users?page=2", want: "api.localhost /users?page=2 "},
+		{name: "accept-encoding is part of the key", method: "GET", url: "http://api.localhost/", header: map[string]string{"Accept-Encoding": "gzip"}, want: "api.localhost / gzip"},
+		{name: "other headers are not", method: "GET", url: "http://api.localhost/", header: map[string]string{"Accept": "text/html"}, want: "api.localhost / "},
+		{name: "head is not cached", method: "HEAD", url: "http://api.localhost/", wantSkip: true},
+		{name: "post is not cached", method: "POST", url: "http://api.localhost/", wantSkip: true},
+		{name: "range is not cached", method: "GET", url: "http://api.localhost/", header: map[string]string{"Range": "bytes=0-9"}, wantSkip: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.url, nil)
+			for name, value := range tt.header {
+				r.Header.Set(name, value)
+			}
+			key, ok := cacheKey(r)
+			if ok == tt.wantSkip {
+				t.Fatalf("cacheable = %v, want %v", ok, !tt.wantSkip)
+			}
+			if key != tt.want {
+				t.Errorf("key = %q, want %q", key, tt.want)
+			}
+		})
+	}
+}
+
+func TestFreshnessLifetime(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   time.Duration
+	}{
+		{name: "no headers"},
+		{name: "max-age", header: map[string]string{"Cache-Control": "public, max-age=60"}, want: time.Minute},
+		{name: "s-maxage wins over max-age", header: map[string]string{"Cache-Control": "max-age=60, s-maxage=120"}, want: 2 * time.Minute},
+		{name: "no-cache", header: map[string]string{"Cache-Control": "no-cache, max-age=60"}},
+		{name: "invalid max-age", header: map[string]string{"Cache-Control": "max-age=abc"}},
+		{name: "max-age wins over expires", header: map[string]string{"Cache-Control": "max-age=0", "Expires": now.Add(time.Hour).Format(http.TimeFormat)}},
+		{name: "expires", header: map[string]string{"Expires": now.Add(time.Hour).Format(http.TimeFormat)}, want: time.Hour},
+		{name: "expires in the past", header: map[string]string{"Expires": now.Add(-time.Hour).Format(http.TimeFormat)}},
+		{name: "invalid expires", header: map[string]string{"Expires": "0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for name, value := range tt.header {
+				header.Set(name, value)
+			}
+			if got := freshnessLifetime(header, now); got != tt.want {
+				t.Errorf("freshnessLifetime = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResponseCacheRefresh(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	later := now.Add(10 * time.Minute)
+
+	tests := []struct {
+		name        string
+		notModified map[string]string // 304 响应的 header
+		evict       bool              // 重新验证前条目已被淘汰
+		wantHeader  map[string]string
+		wantExpires time.Time
+	}{
+		{
+			name:        "304 updates validators and lifetime",
+			notModified: map[string]string{"Cache-Control": "max-age=120", "Etag": `"v2"`},
+			wantHeader:  map[string]string{"Cache-Control": "max-age=120", "Etag": `"v2"`, "Content-Type": "text/plain"},
+			wantExpires: later.Add(2 * time.Minute),
+		},
+		{
+			name:        "headers missing from 304 are kept",
+			notModified: map[string]string{"Date": later.Format(http.TimeFormat)},
+			wantHeader:  map[string]string{"Cache-Control": "max-age=60", "Etag": `"v1"`, "Date": later.Format(http.TimeFormat)},
+			wantExpires: later.Add(time.Minute),
+		},
+		{
+			name:        "other 304 headers are ignored",
+			notModified: map[string]string{"Content-Type": "text/html", "Set-Cookie": "a=b"},
+			wantHeader:  map[string]string{"Content-Type": "text/plain", "Set-Cookie": ""},
+			wantExpires: later.Add(time.Minute),
+		},
+		{
+			name:        "evicted entry is not written back",
+			notModified: map[string]string{"Cache-Control": "max-age=120"},
+			evict:       true,
+			wantHeader:  map[string]string{"Cache-Control": "max-age=120"},
+			wantExpires: later.Add(2 * time.Minute),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := newTestResponseCache()
+			original := &cacheEntry{
+				key:        "api.localhost / ",
+				statusCode: http.StatusOK,
+				header:     http.Header{"Cache-Control": {"max-age=60"}, "Etag": {`"v1"`}, "Content-Type": {"text/plain"}},
+				body:       []byte("hello"),
+				storedAt:   now,
+				expiresAt:  now.Add(time.Minute),
+			}
+			cache.put(original)
+			if tt.evict {
+				cache.purge()
+			}
+
+			resp := &http.Response{StatusCode: http.StatusNotModified, Header: http.Header{}}
+			for name, value := range tt.notModified {
+				resp.Header.Set(name, value)
+			}
+			refreshed := cache.refresh(original, resp, later)
+
+			for name, want := range tt.wantHeader {
+				if got := refreshed.header.Get(name); got != want {
+					t.Errorf("header %s = %q, want %q", name, got, want)
+				}
+			}
+			if !refreshed.expiresAt.Equal(tt.wantExpires) || !refreshed.storedAt.Equal(later) {
+				t.Errorf("storedAt/expiresAt = %v/%v, want %v/%v", refreshed.storedAt, refreshed.expiresAt, later, tt.wantExpires)
+			}
+			if string(refreshed.body) != "hello" {
+				t.Errorf("body = %q, want the cached body", refreshed.body)
+			}
+
+			// 原条目可能正被其他请求读取，不能被修改
+			if got := original.header.Get("Etag"); got != `"v1"` {
+				t.Errorf("original entry was modified: Etag = %q", got)
+			}
+			if !original.expiresAt.Equal(now.Add(time.Minute)) {
+				t.Errorf("original entry was modified: expiresAt = %v", original.expiresAt)
+			}
+
+			stored := cache.get(original.key)
+			switch {
+			case tt.evict && stored != nil:
+				t.Errorf("evicted entry was written back")
+			case !tt.evict && stored != refreshed:
+				t.Errorf("cache holds %p, want the refreshed entry %p", stored, refreshed)
+			}
+		})
+	}
+}
+
+func TestResponseCacheRefreshReplacedEntry(t *testing.T) {
+	now := time.Now()
+	cache := newTestResponseCache()
+	stale := &cacheEntry{key: "k", header: http.Header{}, storedAt: now}
+	cache.put(stale)
+	replacement := &cacheEntry{key: "k", header: http.Header{}, storedAt: now}
+	cache.put(replacement)
+
+	cache.refresh(stale, &http.Response{StatusCode: http.StatusNotModified, Header: http.Header{}}, now)
+	if got := cache.get("k"); got != replacement {
+		t.Errorf("refresh of a replaced entry overwrote the newer response")
+	}
+}
+
+func TestResponseCacheable(t *testing.T) {
+	tests := []struct {
+		name          string
+		status        int
+		header        map[string]string
+		authorization bool
+		want          bool
+	}{
+		{name: "max-age", status: 200, header: map[string]string{"Cache-Control": "max-age=60"}, want: true},
+		{name: "etag only", status: 200, header: map[string]string{"ETag": `"v1"`}, want: true},
+		{name: "no validators or lifetime", status: 200},
+		{name: "not 200", status: 404, header: map[string]string{"Cache-Control": "max-age=60"}},
+		{name: "no-store", status: 200, header: map[string]string{"Cache-Control": "no-store, max-age=60"}},
+		{name: "private", status: 200, header: map[string]string{"Cache-Control": "private, max-age=60"}},
+		{name: "set-cookie", status: 200, header: map[string]string{"Cache-Control": "max-age=60", "Set-Cookie": "a=b"}},
+		{name: "vary star", status: 200, header: map[string]string{"Cache-Control": "max-age=60", "Vary": "*"}},
+		{name: "credentialed request", status: 200, header: map[string]string{"Cache-Control": "max-age=60"}, authorization: true},
+		{name: "credentialed request, public response", status: 200, header: map[string]string{"Cache-Control": "public, max-age=60"}, authorization: true, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "http://api.localhost/", nil)
+			if tt.authorization {
+				r.Header.Set("Authorization", "Bearer x")
+			}
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			for name, value := range tt.header {
+				resp.Header.Set(name, value)
+			}
+			if got := responseCacheable(resp, r); got != tt.want {
+				t.Errorf("responseCacheable = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheEntryMatches(t *testing.T) {
+	entry := &cacheEntry{vary: map[string]string{"Accept-Language": "en"}}
+	publicEntry := &cacheEntry{public: true}
+
+	tests := []struct {
+		name   string
+		entry  *cacheEntry
+		header map[string]string
+		want   bool
+	}{
+		{name: "same vary value", entry: entry, header: map[string]string{"Accept-Language": "en"}, want: true},
+		{name: "different vary value", entry: entry, header: map[string]string{"Accept-Language": "de"}},
+		{name: "missing vary header", entry: entry},
+		{name: "cookie needs a public entry", entry: entry, header: map[string]string{"Accept-Language": "en", "Cookie": "a=b"}},
+		{name: "public entry with cookie", entry: publicEntry, header: map[string]string{"Cookie": "a=b"}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "http://api.localhost/", nil)
+			for name, value := range tt.header {
+				r.Header.Set(name, value)
+			}
+			if got := tt.entry.matches(r); got != tt.want {
+				t.Errorf("matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func newTestResponseCache() *responseCache {
+	services := []SSHService{{Alias: strPtr("api"), Cache: &ServiceCache{Enabled: boolPtr(true)}}}
+	return buildResponseCaches(services)[&services[0]]
+}
@@ -0,0 +1,214 @@
+package ssh_proxy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Service 请求/响应捕获
+// ------------------------------------------------------------
+const (
+	defaultCaptureMaxBodyBytes = 64 * 1024 // 默认每个 body 最多捕获 64KiB
+	redactedHeaderValue        = "[REDACTED]"
+)
+
+// defaultRedactedHeaders 默认脱敏的 header
+var defaultRedactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", accessTokenHeader}
+
+// CapturedExchange 一次代理请求的捕获内容
+type CapturedExchange struct {
+	StartedAt time.Time
+	Duration  time.Duration
+	Request   CapturedRequest
+	Response  *CapturedResponse // 请求失败时为 nil
+}
+
+// CapturedRequest 捕获的请求（URL 为客户端访问代理时的完整地址）
+type CapturedRequest struct {
+	Method        string
+	URL           string
+	Proto         string
+	Header        http.Header
+	Body          []byte
+	BodySize      int64
+	BodyTruncated bool
+}
+
+// CapturedResponse 捕获的响应
+type CapturedResponse struct {
+	StatusCode    int
+	Proto         string
+	Header        http.Header
+	Body          []byte
+	BodySize      int64
+	BodyTruncated bool
+}
+
+// DecodedBody 返回解压后的 body（仅处理完整捕获的 gzip），无法解压时返回原始内容
+func (r *CapturedResponse) DecodedBody() []byte {
+	if r.BodyTruncated || !strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+		return r.Body
+	}
+	reader, err := gzip.NewReader(bytes.NewReader(r.Body))
+	if err != nil {
+		return r.Body
+	}
+	defer reader.Close()
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		return r.Body
+	}
+	return decoded
+}
+
+// captureConfig 编译后的捕获配置
+type captureConfig struct {
+	maxBodyBytes int
+	redact       map[string]bool
+}
+
+// buildCaptureConfig 根据设置构建捕获配置，未启用时返回 nil
+func buildCaptureConfig(settings *CaptureSettings) *captureConfig {
+	if settings == nil || settings.Enabled == nil || !*settings.Enabled {
+		return nil
+	}
+
+	config := &captureConfig{
+		maxBodyBytes: defaultCaptureMaxBodyBytes,
+		redact:       make(map[string]bool),
+	}
+	if settings.MaxBodyBytes != nil && *settings.MaxBodyBytes >= 0 {
+		config.maxBodyBytes = *settings.MaxBodyBytes
+	}
+	for _, name := range append(append([]string{}, defaultRedactedHeaders...), settings.RedactHeaders...) {
+		config.redact[http.CanonicalHeaderKey(name)] = true
+	}
+	return config
+}
+
+// redactHeader 复制 header 并对敏感字段脱敏
+func (c *captureConfig) redactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+	if redacted == nil {
+		redacted = http.Header{}
+	}
+	for name, values := range redacted {
+		if c.redact[http.CanonicalHeaderKey(name)] {
+			for i := range values {
+				values[i] = redactedHeaderValue
+			}
+		}
+	}
+	return redacted
+}
+
+// captureBuffer 只保留前 limit 字节，同时统计总大小
+type captureBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	size      int64
+	truncated bool
+}
+
+func (b *captureBuffer) Write(p []byte) (int, error) {
+	b.size += int64(len(p))
+	if remaining := b.limit - b.buf.Len(); remaining > 0 {
+		if len(p) > remaining {
+			b.buf.Write(p[:remaining])
+			b.truncated = true
+		} else {
+			b.buf.Write(p)
+		}
+	} else if len(p) > 0 {
+		b.truncated = true
+	}
+	return len(p), nil
+}
+
+func (b *captureBuffer) bytes() []byte {
+	return append([]byte(nil), b.buf.Bytes()...)
+}
+
+// teeReadCloser 读取请求 body 时同步写入捕获缓冲区
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// exchangeRecorder 记录单次请求的捕获过程
+type exchangeRecorder struct {
+	config      *captureConfig
+	startedAt   time.Time
+	request     CapturedRequest
+	requestBody *captureBuffer
+}
+
+// startCapture 开始捕获请求，未启用捕获时返回 nil
+// 必须在路由改写路径之前调用，以记录客户端访问的原始 URL
+func (c *captureConfig) startCapture(r *http.Request, startedAt time.Time) *exchangeRecorder {
+	if c == nil {
+		return nil
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	recorder := &exchangeRecorder{
+		config:    c,
+		startedAt: startedAt,
+		request: CapturedRequest{
+			Method: r.Method,
+			URL:    scheme + "://" + r.Host + r.URL.RequestURI(),
+			Proto:  r.Proto,
+			Header: c.redactHeader(r.Header),
+		},
+		requestBody: &captureBuffer{limit: c.maxBodyBytes},
+	}
+
+	if r.Body != nil && r.Body != http.NoBody {
+		r.Body = teeReadCloser{Reader: io.TeeReader(r.Body, recorder.requestBody), Closer: r.Body}
+	}
+	return recorder
+}
+
+// finish 结束捕获，rw 为 nil 表示请求未得到响应
+func (e *exchangeRecorder) finish(rw *responseWriter, duration time.Duration) *CapturedExchange {
+	if e == nil {
+		return nil
+	}
+
+	exchange := &CapturedExchange{
+		StartedAt: e.startedAt,
+		Duration:  duration,
+		Request:   e.request,
+	}
+	exchange.Request.Body = e.requestBody.bytes()
+	exchange.Request.BodySize = e.requestBody.size
+	exchange.Request.BodyTruncated = e.requestBody.truncated
+
+	if rw != nil {
+		response := &CapturedResponse{
+			StatusCode: rw.statusCode,
+			Proto:      rw.proto,
+			Header:     e.config.redactHeader(rw.Header()),
+		}
+		if response.Proto == "" {
+			response.Proto = "HTTP/1.1"
+		}
+		if rw.capture != nil {
+			response.Body = rw.capture.bytes()
+			response.BodySize = rw.capture.size
+			response.BodyTruncated = rw.capture.truncated
+		}
+		exchange.Response = response
+	}
+	return exchange
+}
+
+// ============================================================
@@ -0,0 +1,76 @@
+package ssh_proxy
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Service 上游压缩协商
+// ------------------------------------------------------------
+
+// acceptsEncoding 判断 Accept-Encoding 是否接受指定编码（忽略 q 值为 0 的情况）
+func acceptsEncoding(acceptEncoding, encoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) && strings.TrimSpace(name) != "*" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.ReplaceAll(params, " ", ""), "q="); ok && (q == "0" || strings.Trim(q, "0.") == "") {
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// compressionNegotiation 单个请求的压缩协商结果
+type compressionNegotiation struct {
+	decodeGzip bool // 客户端不接受 gzip，收到 gzip 响应后在本地解压
+}
+
+// negotiateUpstreamEncoding 让上游始终返回压缩内容以减少隧道传输量
+// 客户端支持 br 时一并请求 br（原样透传），客户端不支持 gzip 时在本地解压
+func negotiateUpstreamEncoding(req *http.Request, clientAcceptEncoding string) *compressionNegotiation {
+	encodings := []string{"gzip"}
+	if acceptsEncoding(clientAcceptEncoding, "br") {
+		encodings = append([]string{"br"}, encodings...)
+	}
+	req.Header.Set("Accept-Encoding", strings.Join(encodings, ", "))
+	return &compressionNegotiation{decodeGzip: !acceptsEncoding(clientAcceptEncoding, "gzip")}
+}
+
+// decodeResponse 客户端不接受 gzip 时解压上游响应
+func (n *compressionNegotiation) decodeResponse(resp *http.Response) error {
+	if n == nil || !n.decodeGzip || !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return nil
+	}
+	reader, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		if err == io.EOF {
+			// 空 body
+			resp.Header.Del("Content-Encoding")
+			return nil
+		}
+		return err
+	}
+	resp.Body = gzipReadCloser{Reader: reader, body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+type gzipReadCloser struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (g gzipReadCloser) Close() error {
+	g.Reader.Close()
+	return g.body.Close()
+}
+
+// ============================================================
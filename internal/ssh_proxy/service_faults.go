@@ -0,0 +1,213 @@
+package ssh_proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Service 流量整形与故障注入
+// ------------------------------------------------------------
+const defaultFaultErrorStatus = http.StatusServiceUnavailable
+
+// faultConfig 编译后的服务故障注入配置
+type faultConfig struct {
+	latency     time.Duration
+	jitter      time.Duration
+	bytesPerSec int64
+	errorRate   float64 // 0-1
+	errorStatus int
+}
+
+// buildFaultConfigs 为配置了 faults 的服务编译故障注入配置
+func buildFaultConfigs(services []SSHService) (map[*SSHService]*faultConfig, []error) {
+	configs := make(map[*SSHService]*faultConfig)
+	var errs []error
+
+	for i := range services {
+		service := &services[i]
+		settings := service.Faults
+		if settings == nil || (settings.Enabled != nil && !*settings.Enabled) {
+			continue
+		}
+
+		config := &faultConfig{errorStatus: defaultFaultErrorStatus}
+		if settings.LatencyMs != nil && *settings.LatencyMs > 0 {
+			config.latency = time.Duration(*settings.LatencyMs) * time.Millisecond
+		}
+		if settings.JitterMs != nil && *settings.JitterMs > 0 {
+			config.jitter = time.Duration(*settings.JitterMs) * time.Millisecond
+		}
+		if settings.BandwidthKbps != nil && *settings.BandwidthKbps > 0 {
+			config.bytesPerSec = int64(*settings.BandwidthKbps) * 1000 / 8
+		}
+		if settings.ErrorRate != nil {
+			rate := *settings.ErrorRate
+			if rate < 0 || rate > 100 {
+				errs = append(errs, fmt.Errorf("service %s: error_rate must be between 0 and 100, got %v", ServiceDisplayName(service), rate))
+				continue
+			}
+			config.errorRate = rate / 100
+		}
+		if settings.ErrorStatus != nil {
+			if *settings.ErrorStatus < 400 || *settings.ErrorStatus > 599 {
+				errs = append(errs, fmt.Errorf("service %s: error_status must be 4xx or 5xx, got %d", ServiceDisplayName(service), *settings.ErrorStatus))
+				continue
+			}
+			config.errorStatus = *settings.ErrorStatus
+		}
+		configs[service] = config
+	}
+	return configs, errs
+}
+
+// faultPlan 单个请求实际注入的故障
+type faultPlan struct {
+	delay       time.Duration
+	bytesPerSec int64
+	errorStatus int // 0 表示不注入错误
+}
+
+// plan 为本次请求抽样决定要注入的故障，未配置时返回 nil
+func (c *faultConfig) plan() *faultPlan {
+	if c == nil {
+		return nil
+	}
+	plan := &faultPlan{delay: c.latency, bytesPerSec: c.bytesPerSec}
+	if c.jitter > 0 {
+		plan.delay += time.Duration(rand.Int64N(int64(c.jitter) + 1))
+	}
+	if c.errorRate > 0 && rand.Float64() < c.errorRate {
+		plan.errorStatus = c.errorStatus
+	}
+	if plan.delay == 0 && plan.bytesPerSec == 0 && plan.errorStatus == 0 {
+		return nil
+	}
+	return plan
+}
+
+// describe 日志中展示的故障标记
+func (p *faultPlan) describe() string {
+	if p == nil {
+		return ""
+	}
+	var parts []string
+	if p.delay > 0 {
+		parts = append(parts, "latency "+p.delay.Round(time.Millisecond).String())
+	}
+	if p.bytesPerSec > 0 {
+		parts = append(parts, fmt.Sprintf("bandwidth %dkbps", p.bytesPerSec*8/1000))
+	}
+	if p.errorStatus > 0 {
+		parts = append(parts, fmt.Sprintf("error %d", p.errorStatus))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// faultTransport 在转发前注入延迟，命中错误率时直接返回错误响应而不访问上游
+type faultTransport struct {
+	next http.RoundTripper
+	plan *faultPlan
+}
+
+func (t *faultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := sleepContext(req.Context(), t.plan.delay); err != nil {
+		return nil, err
+	}
+	if t.plan.errorStatus == 0 {
+		return t.next.RoundTrip(req)
+	}
+
+	body := fmt.Sprintf("Injected fault: %d %s\n", t.plan.errorStatus, http.StatusText(t.plan.errorStatus))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", t.plan.errorStatus, http.StatusText(t.plan.errorStatus)),
+		StatusCode:    t.plan.errorStatus,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"text/plain; charset=utf-8"}, "X-Messer-Fault": {"injected"}},
+		Body:          io.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// throttledResponseWriter 按带宽上限分块写出响应
+type throttledResponseWriter struct {
+	http.ResponseWriter
+	ctx         context.Context
+	bytesPerSec int64
+}
+
+// throttleChunkDivisor 每块为 1/10 秒的数据量，保证输出平滑
+const throttleChunkDivisor = 10
+
+func (w *throttledResponseWriter) Write(b []byte) (int, error) {
+	chunkSize := max(int(w.bytesPerSec/throttleChunkDivisor), 1)
+	written := 0
+	for written < len(b) {
+		end := min(written+chunkSize, len(b))
+		n, err := w.ResponseWriter.Write(b[written:end])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		if err := sleepContext(w.ctx, time.Duration(int64(n)*int64(time.Second)/w.bytesPerSec)); err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+func (w *throttledResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// SetFaultsPaused 暂停或恢复所有服务的故障注入
+func (sp *ServiceProxy) SetFaultsPaused(paused bool) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	sp.faultsPaused = paused
+}
+
+// HasFaults 是否有服务配置了故障注入
+func (sp *ServiceProxy) HasFaults() bool {
+	sp.mu.RLock()
+	defer sp.mu.RUnlock()
+	return len(sp.faults) > 0
+}
+
+// faultPlanFor 为请求抽样故障，暂停时返回 nil
+func (sp *ServiceProxy) faultPlanFor(service *SSHService) *faultPlan {
+	sp.mu.RLock()
+	config := sp.faults[service]
+	paused := sp.faultsPaused
+	sp.mu.RUnlock()
+	if paused {
+		return nil
+	}
+	return config.plan()
+}
+
+// ============================================================
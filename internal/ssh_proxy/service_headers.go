@@ -0,0 +1,83 @@
+package ssh_proxy
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Service 请求 header 注入与认证
+// ------------------------------------------------------------
+
+// headerRules 编译后的服务 header 规则，值中的引用在连接前已由配置层解析
+type headerRules struct {
+	set       map[string]string
+	remove    []string
+	basicAuth *resolvedBasicAuth
+}
+
+type resolvedBasicAuth struct {
+	username string
+	password string
+}
+
+// buildHeaderRules 为每个配置了 header 规则的服务构建规则，无效的 basic_auth 会被跳过
+func buildHeaderRules(services []SSHService) (map[*SSHService]*headerRules, []error) {
+	rulesByService := make(map[*SSHService]*headerRules)
+	var errs []error
+
+	for i := range services {
+		service := &services[i]
+		if len(service.RequestHeaders) == 0 && len(service.RemoveHeaders) == 0 && service.BasicAuth == nil {
+			continue
+		}
+		name := ServiceDisplayName(service)
+
+		rules := &headerRules{set: make(map[string]string)}
+		for _, header := range service.RemoveHeaders {
+			rules.remove = append(rules.remove, http.CanonicalHeaderKey(header))
+		}
+		// 值中的引用已在连接前由 TomlConfig.Resolve 解析，这里不再解析，避免密钥内容被当作引用再次执行
+		for header, value := range service.RequestHeaders {
+			rules.set[http.CanonicalHeaderKey(header)] = value
+		}
+		if auth := service.BasicAuth; auth != nil {
+			basicAuth, err := buildBasicAuth(auth)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("service %s: basic_auth: %w", name, err))
+			} else {
+				rules.basicAuth = basicAuth
+			}
+		}
+		rulesByService[service] = rules
+	}
+	return rulesByService, errs
+}
+
+func buildBasicAuth(auth *ServiceBasicAuth) (*resolvedBasicAuth, error) {
+	if auth.Username == nil || *auth.Username == "" {
+		return nil, fmt.Errorf("username is required")
+	}
+	var password string
+	if auth.Password != nil {
+		password = *auth.Password
+	}
+	return &resolvedBasicAuth{username: *auth.Username, password: password}, nil
+}
+
+// apply 在转发前修改请求 header：先移除，再设置，最后写入 basic auth
+func (h *headerRules) apply(req *http.Request) {
+	if h == nil {
+		return
+	}
+	for _, name := range h.remove {
+		req.Header.Del(name)
+	}
+	for name, value := range h.set {
+		req.Header.Set(name, value)
+	}
+	if h.basicAuth != nil {
+		req.SetBasicAuth(h.basicAuth.username, h.basicAuth.password)
+	}
+}
+
+// ============================================================
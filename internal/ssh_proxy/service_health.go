@@ -0,0 +1,234 @@
+package ssh_proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"ssh-messer/internal/pubsub"
+	"ssh-messer/pkg"
+)
+
+// Service 主动健康检查
+// ------------------------------------------------------------
+const (
+	defaultHealthCheckInterval = 30 * time.Second
+	defaultHealthCheckTimeout  = 5 * time.Second
+)
+
+var serviceHealthBroker = pubsub.NewBroker[ServiceHealthEvent]()
+
+// GetServiceHealthBroker 获取服务健康状态 broker
+func GetServiceHealthBroker() *pubsub.Broker[ServiceHealthEvent] {
+	return serviceHealthBroker
+}
+
+// ServiceHealthEvent 服务健康检查结果
+type ServiceHealthEvent struct {
+	ConfigName  string
+	ServiceName string // 与 ServiceDisplayName 一致
+	Health      string // ServiceHealthHealthy / ServiceHealthUnhealthy
+	Message     string // 失败原因或状态码
+	Latency     time.Duration
+	CheckedAt   time.Time
+}
+
+// startHealthChecks 为配置了 health_check 的服务启动探测，调用方需持有写锁
+func (sp *ServiceProxy) startHealthChecks() {
+	sp.healthStop = make(chan struct{})
+	for i := range sp.services {
+		service := &sp.services[i]
+		if service.HealthCheck == nil {
+			continue
+		}
+		go sp.healthCheckLoop(service, sp.healthStop)
+	}
+}
+
+// stopHealthChecks 停止所有探测，调用方需持有写锁
+func (sp *ServiceProxy) stopHealthChecks() {
+	if sp.healthStop != nil {
+		close(sp.healthStop)
+		sp.healthStop = nil
+	}
+}
+
+// pruneProbeHealth 删除已移除或不再配置 health_check 的服务的探测结果，调用方需持有写锁
+// 返回被删除的结果，由调用方释放锁后通过 publishProbeRemoved 通知订阅者
+func (sp *ServiceProxy) pruneProbeHealth() []ServiceHealthEvent {
+	probed := make(map[string]bool)
+	if !sp.stopped {
+		for i := range sp.services {
+			if sp.services[i].HealthCheck != nil {
+				probed[ServiceDisplayName(&sp.services[i])] = true
+			}
+		}
+	}
+
+	var removed []ServiceHealthEvent
+	for name, event := range sp.probeHealth {
+		if !probed[name] {
+			delete(sp.probeHealth, name)
+			removed = append(removed, event)
+		}
+	}
+	return removed
+}
+
+// publishProbeRemoved 以 DeletedEvent 发布被删除的探测结果
+func publishProbeRemoved(removed []ServiceHealthEvent) {
+	for _, event := range removed {
+		serviceHealthBroker.Publish(pubsub.DeletedEvent, event)
+	}
+}
+
+func (sp *ServiceProxy) healthCheckLoop(service *SSHService, stop <-chan struct{}) {
+	interval := defaultHealthCheckInterval
+	if check := service.HealthCheck; check.IntervalSec != nil && *check.IntervalSec > 0 {
+		interval = time.Duration(*check.IntervalSec) * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	sp.probeService(service, stop)
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			sp.probeService(service, stop)
+		}
+	}
+}
+
+// probeService 执行一次探测并发布结果，探测期间已停止（服务被移除或代理停止）时丢弃结果
+func (sp *ServiceProxy) probeService(service *SSHService, stop <-chan struct{}) {
+	check := service.HealthCheck
+	timeout := defaultHealthCheckTimeout
+	if check.TimeoutSec != nil && *check.TimeoutSec > 0 {
+		timeout = time.Duration(*check.TimeoutSec) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	startedAt := time.Now()
+	var message string
+	var err error
+	if check.TCP != nil && *check.TCP {
+		message, err = sp.probeTCP(ctx, service)
+	} else {
+		message, err = sp.probeHTTP(ctx, service)
+	}
+
+	event := ServiceHealthEvent{
+		ConfigName:  sp.configName,
+		ServiceName: ServiceDisplayName(service),
+		Health:      ServiceHealthHealthy,
+		Message:     message,
+		Latency:     time.Since(startedAt),
+		CheckedAt:   startedAt,
+	}
+	if err != nil {
+		event.Health = ServiceHealthUnhealthy
+		event.Message = truncateErrorMessage(err.Error())
+	}
+
+	sp.mu.Lock()
+	select {
+	case <-stop:
+		sp.mu.Unlock()
+		return
+	default:
+	}
+	previous, known := sp.probeHealth[event.ServiceName]
+	sp.probeHealth[event.ServiceName] = event
+	sp.mu.Unlock()
+
+	if !known || previous.Health != event.Health {
+		pkg.Logger.Info().Str("config_name", sp.configName).Str("service", event.ServiceName).Str("health", event.Health).Str("message", event.Message).Msg("[ServiceProxy] 服务健康状态变化")
+	}
+	serviceHealthBroker.Publish(pubsub.UpdatedEvent, event)
+}
+
+// probeTCP 通过 hop client 建立 TCP 连接，多目标服务任一目标可连接即视为健康
+func (sp *ServiceProxy) probeTCP(ctx context.Context, service *SSHService) (string, error) {
+	sp.mu.RLock()
+	pool := sp.backendPools[service]
+	sp.mu.RUnlock()
+
+	type tcpTarget struct {
+		addr     string
+		hopOrder *int
+	}
+	targets := []tcpTarget{{addr: buildRemoteAddress(service), hopOrder: service.HopOrder}}
+	if pool != nil {
+		targets = targets[:0]
+		for _, b := range pool.backends {
+			targets = append(targets, tcpTarget{addr: b.addr, hopOrder: b.hopOrder})
+		}
+	}
+
+	var lastErr error
+	for _, target := range targets {
+		client := sp.clientForHop(target.hopOrder)
+		if client == nil {
+			lastErr = fmt.Errorf("SSH client is not available")
+			continue
+		}
+		conn, err := client.DialContext(ctx, "tcp", target.addr)
+		if err != nil {
+			lastErr = fmt.Errorf("tcp %s: %w", target.addr, err)
+			continue
+		}
+		conn.Close()
+		return "tcp " + target.addr + " ok", nil
+	}
+	return "", lastErr
+}
+
+// probeHTTP 通过与代理请求相同的 transport 请求健康检查路径
+func (sp *ServiceProxy) probeHTTP(ctx context.Context, service *SSHService) (string, error) {
+	client := sp.clientForHop(service.HopOrder)
+	if client == nil {
+		return "", fmt.Errorf("SSH client is not available")
+	}
+
+	path := "/"
+	if check := service.HealthCheck; check.Path != nil && *check.Path != "" {
+		path = *check.Path
+	}
+	serviceConfig := buildServiceConfig(service)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, serviceConfig.scheme+"://"+serviceConfig.remoteAddr+path, nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid health check path %q: %w", path, err)
+	}
+	req.Host = serviceConfig.remoteHost
+
+	sp.mu.RLock()
+	rules := sp.headerRules[service]
+	sp.mu.RUnlock()
+	rules.apply(req)
+
+	resp, err := sp.newTransport(service, serviceConfig.useTLS, client).RoundTrip(req)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	if !healthStatusOK(service.HealthCheck, resp.StatusCode) {
+		return "", fmt.Errorf("GET %s returned %d", path, resp.StatusCode)
+	}
+	return fmt.Sprintf("GET %s %d", path, resp.StatusCode), nil
+}
+
+// healthStatusOK 未配置 expected_status 时 2xx/3xx 视为健康
+func healthStatusOK(check *ServiceHealthCheck, statusCode int) bool {
+	if check.ExpectedStatus != nil && *check.ExpectedStatus > 0 {
+		return statusCode == *check.ExpectedStatus
+	}
+	return statusCode >= 200 && statusCode < 400
+}
+
+// ============================================================
@@ -0,0 +1,161 @@
+package ssh_proxy
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Service Host 路由
+// ------------------------------------------------------------
+
+// defaultBaseDomains 默认支持的基础域名，<subdomain>.<base> 会路由到对应服务
+// lvh.me 及其子域名在公网 DNS 中解析到 127.0.0.1，适合不支持 *.localhost 的客户端
+var defaultBaseDomains = []string{"localhost", "lvh.me"}
+
+// hostPattern 服务 hosts 中的一条匹配规则
+//   - "api.example.test"      精确匹配
+//   - "*.dev.localhost"       通配匹配任意层级子域名（不含 dev.localhost 本身）
+//   - "~^api-[0-9]+\.local$"  以 "~" 开头表示正则匹配
+type hostPattern struct {
+	service *SSHService
+	raw     string
+	suffix  string         // 通配规则的后缀，如 ".dev.localhost"
+	regex   *regexp.Regexp // 正则规则
+}
+
+// hostRouter 按 Host 选择服务，优先级：精确 > 通配（后缀越长越优先）> 正则 > subdomain
+type hostRouter struct {
+	exact       map[string]*SSHService
+	wildcards   []hostPattern
+	regexps     []hostPattern
+	subdomains  map[string]*SSHService
+	baseDomains []string
+}
+
+// buildHostRouter 根据服务配置构建 Host 路由，非法的规则会被跳过并返回错误
+func buildHostRouter(services []SSHService, baseDomains []string) (*hostRouter, []error) {
+	router := &hostRouter{
+		exact:       make(map[string]*SSHService),
+		subdomains:  make(map[string]*SSHService),
+		baseDomains: mergeBaseDomains(baseDomains),
+	}
+
+	var errs []error
+	for i := range services {
+		service := &services[i]
+
+		if service.Subdomain != nil && *service.Subdomain != "" {
+			router.subdomains[strings.ToLower(*service.Subdomain)] = service
+		}
+
+		for _, raw := range service.Hosts {
+			raw = strings.TrimSpace(raw)
+			switch {
+			case raw == "":
+				continue
+			case strings.HasPrefix(raw, "~"):
+				re, err := regexp.Compile(raw[1:])
+				if err != nil {
+					errs = append(errs, fmt.Errorf("invalid host regexp %q: %w", raw, err))
+					continue
+				}
+				router.regexps = append(router.regexps, hostPattern{service: service, raw: raw, regex: re})
+			case strings.HasPrefix(raw, "*."):
+				router.wildcards = append(router.wildcards, hostPattern{service: service, raw: raw, suffix: strings.ToLower(raw[1:])})
+			default:
+				router.exact[normalizeHost(raw)] = service
+			}
+		}
+	}
+
+	sort.SliceStable(router.wildcards, func(i, j int) bool {
+		return len(router.wildcards[i].suffix) > len(router.wildcards[j].suffix)
+	})
+
+	return router, errs
+}
+
+// mergeBaseDomains 合并默认与自定义的基础域名并去重
+func mergeBaseDomains(custom []string) []string {
+	seen := make(map[string]bool)
+	var domains []string
+	for _, domain := range append(append([]string{}, defaultBaseDomains...), custom...) {
+		domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), ".")
+		if domain == "" || seen[domain] {
+			continue
+		}
+		seen[domain] = true
+		domains = append(domains, domain)
+	}
+	return domains
+}
+
+// match 根据 Host 查找服务，未命中返回 nil
+func (h *hostRouter) match(rawHost string) *SSHService {
+	host := normalizeHost(rawHost)
+	if host == "" {
+		return nil
+	}
+
+	if service := h.exact[host]; service != nil {
+		return service
+	}
+	for _, pattern := range h.wildcards {
+		if strings.HasSuffix(host, pattern.suffix) {
+			return pattern.service
+		}
+	}
+	for _, pattern := range h.regexps {
+		if pattern.regex.MatchString(host) {
+			return pattern.service
+		}
+	}
+	if subdomain := h.subdomainOf(host); subdomain != "" {
+		return h.subdomains[subdomain]
+	}
+	return nil
+}
+
+// subdomainOf 返回 host 在基础域名下的 subdomain，IP 和裸基础域名返回空
+func (h *hostRouter) subdomainOf(host string) string {
+	if net.ParseIP(host) != nil {
+		return ""
+	}
+	for _, base := range h.baseDomains {
+		if subdomain, ok := strings.CutSuffix(host, "."+base); ok && subdomain != "" {
+			return subdomain
+		}
+	}
+	return ""
+}
+
+// hostsFor 列出可访问该服务的 Host（用于提示页面）
+func (h *hostRouter) hostsFor(service *SSHService) []string {
+	var hosts []string
+	if service.Subdomain != nil && *service.Subdomain != "" {
+		for _, base := range h.baseDomains {
+			hosts = append(hosts, strings.ToLower(*service.Subdomain)+"."+base)
+		}
+	}
+	for _, raw := range service.Hosts {
+		if raw = strings.TrimSpace(raw); raw != "" {
+			hosts = append(hosts, raw)
+		}
+	}
+	return hosts
+}
+
+// normalizeHost 去掉端口与结尾的 "."，并转为小写
+func normalizeHost(host string) string {
+	host = strings.TrimSpace(host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimPrefix(strings.TrimSuffix(host, "]"), "[")
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// ============================================================
@@ -0,0 +1,60 @@
+package ssh_proxy
+
+import "testing"
+
+func TestHostRouterMatch(t *testing.T) {
+	services := []SSHService{
+		{Alias: strPtr("exact"), Hosts: []string{"api.dev.localhost"}},
+		{Alias: strPtr("wildcard"), Hosts: []string{"*.dev.localhost"}},
+		{Alias: strPtr("deep-wildcard"), Hosts: []string{"*.eu.dev.localhost"}},
+		{Alias: strPtr("regexp"), Hosts: []string{`~^app-[0-9]+\.test$`}},
+		{Alias: strPtr("sub"), Subdomain: strPtr("Docs")},
+	}
+	router, errs := buildHostRouter(services, []string{"corp.internal"})
+	if len(errs) > 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+
+	tests := []struct {
+		host string
+		want string // 空表示未命中
+	}{
+		{host: "api.dev.localhost", want: "exact"},
+		{host: "API.dev.localhost.:8080", want: "exact"},
+		{host: "web.dev.localhost", want: "wildcard"},
+		{host: "a.b.dev.localhost", want: "wildcard"},
+		{host: "x.eu.dev.localhost", want: "deep-wildcard"},
+		{host: "dev.localhost"},
+		{host: "app-12.test", want: "regexp"},
+		{host: "app-x.test"},
+		{host: "docs.localhost", want: "sub"},
+		{host: "docs.lvh.me:8080", want: "sub"},
+		{host: "docs.corp.internal", want: "sub"},
+		{host: "localhost"},
+		{host: "127.0.0.1:8080"},
+		{host: "[::1]:8080"},
+		{host: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			got := ""
+			if service := router.match(tt.host); service != nil {
+				got = ServiceDisplayName(service)
+			}
+			if got != tt.want {
+				t.Errorf("match(%q) = %q, want %q", tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildHostRouterInvalidRegexp(t *testing.T) {
+	services := []SSHService{{Alias: strPtr("bad"), Hosts: []string{"~("}}}
+	router, errs := buildHostRouter(services, nil)
+	if len(errs) != 1 {
+		t.Fatalf("errors = %v, want one invalid regexp error", errs)
+	}
+	if service := router.match("("); service != nil {
+		t.Errorf("invalid rule should be skipped, matched %q", ServiceDisplayName(service))
+	}
+}
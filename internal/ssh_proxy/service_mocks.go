@@ -0,0 +1,159 @@
+package ssh_proxy
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"ssh-messer/pkg"
+)
+
+// Service 本地 mock 响应
+// ------------------------------------------------------------
+
+// mockRule 编译后的 mock 规则
+type mockRule struct {
+	method  string // 空表示任意方法
+	pattern string // path.Match 通配，以 "/**" 结尾时匹配所有子路径
+	status  int
+	headers http.Header
+	body    []byte
+	file    string // 每次请求时读取，修改文件无需重启
+}
+
+// buildMockRules 为配置了 mocks 的服务编译规则，按配置顺序匹配
+func buildMockRules(services []SSHService) (map[*SSHService][]mockRule, []error) {
+	rules := make(map[*SSHService][]mockRule)
+	var errs []error
+
+	for i := range services {
+		service := &services[i]
+		for j, mock := range service.Mocks {
+			rule, err := buildMockRule(mock)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("service %s: mock #%d: %w", ServiceDisplayName(service), j+1, err))
+				continue
+			}
+			rules[service] = append(rules[service], rule)
+		}
+	}
+	return rules, errs
+}
+
+func buildMockRule(mock ServiceMock) (mockRule, error) {
+	if mock.Path == nil || *mock.Path == "" {
+		return mockRule{}, fmt.Errorf("path is required")
+	}
+	rule := mockRule{
+		pattern: *mock.Path,
+		status:  http.StatusOK,
+		headers: http.Header{},
+	}
+	if !strings.HasPrefix(rule.pattern, "/") {
+		rule.pattern = "/" + rule.pattern
+	}
+	if _, err := path.Match(strings.TrimSuffix(rule.pattern, "/**"), "/"); err != nil {
+		return mockRule{}, fmt.Errorf("invalid path pattern %q: %w", *mock.Path, err)
+	}
+	if mock.Method != nil && *mock.Method != "" && *mock.Method != "*" {
+		rule.method = strings.ToUpper(*mock.Method)
+	}
+	if mock.Status != nil {
+		// 1xx 不是最终响应，mock 只能返回 200-599
+		if *mock.Status < 200 || *mock.Status > 599 {
+			return mockRule{}, fmt.Errorf("invalid status %d (expected 200-599)", *mock.Status)
+		}
+		rule.status = *mock.Status
+	}
+	for name, value := range mock.Headers {
+		rule.headers.Set(name, value)
+	}
+
+	hasBody := mock.Body != nil
+	hasFile := mock.File != nil && *mock.File != ""
+	if hasBody && hasFile {
+		return mockRule{}, fmt.Errorf("body and file are mutually exclusive")
+	}
+	if hasBody {
+		rule.body = []byte(*mock.Body)
+	}
+	if hasFile {
+		file, err := pkg.ExpandHome(*mock.File)
+		if err != nil {
+			return mockRule{}, err
+		}
+		rule.file = file
+	}
+	return rule, nil
+}
+
+// matches 判断请求是否命中规则，urlPath 为去除路由前缀后的路径
+func (m *mockRule) matches(method, urlPath string) bool {
+	if m.method != "" && m.method != method {
+		return false
+	}
+	if prefix, ok := strings.CutSuffix(m.pattern, "/**"); ok {
+		return urlPath == prefix || strings.HasPrefix(urlPath, prefix+"/")
+	}
+	matched, _ := path.Match(m.pattern, urlPath)
+	return matched
+}
+
+// findMock 查找请求命中的 mock 规则
+func (sp *ServiceProxy) findMock(service *SSHService, r *http.Request) *mockRule {
+	sp.mu.RLock()
+	rules := sp.mocks[service]
+	sp.mu.RUnlock()
+
+	for i := range rules {
+		if rules[i].matches(r.Method, r.URL.Path) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// serveMock 在本地返回 mock 响应，不经过 SSH 隧道
+func (sp *ServiceProxy) serveMock(w http.ResponseWriter, r *http.Request, mock *mockRule, local localRequest) {
+	local.serve(w, r, func(rw http.ResponseWriter) string {
+		// 204、304 与 HEAD 响应不能带 body，也不发送 Content-Length
+		hasBody := mockHasBody(r.Method, mock.status)
+		body := mock.body
+		if hasBody && mock.file != "" {
+			data, err := os.ReadFile(mock.file)
+			if err != nil {
+				pkg.Logger.Warn().Err(err).Str("config_name", sp.configName).Str("file", mock.file).Msg("[ServiceProxy] 读取 mock 文件失败")
+				errorMessage := truncateErrorMessage(fmt.Sprintf("failed to read mock file: %v", err))
+				http.Error(rw, errorMessage, http.StatusInternalServerError)
+				return errorMessage
+			}
+			body = data
+		}
+
+		for name, values := range mock.headers {
+			rw.Header()[name] = append([]string(nil), values...)
+		}
+		rw.Header().Set("X-Messer-Mock", "true")
+		if !hasBody {
+			rw.Header().Del("Content-Length")
+			rw.WriteHeader(mock.status)
+			return ""
+		}
+		rw.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		rw.WriteHeader(mock.status)
+		rw.Write(body)
+		return ""
+	}, func(event *ServiceProxyLogEvent) {
+		event.Mock = true
+	})
+}
+
+// mockHasBody 响应是否可以带 body
+func mockHasBody(method string, status int) bool {
+	return method != http.MethodHead && status != http.StatusNoContent && status != http.StatusNotModified
+}
+
+// ============================================================
@@ -0,0 +1,294 @@
+package ssh_proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"ssh-messer/pkg"
+)
+
+// Service Proxy 内置页面
+// ------------------------------------------------------------
+const internalPathPrefix = "/_messer/" // 内置页面的路径前缀，仅在 Host 未命中服务时生效
+
+var pageStyle = template.CSS(`
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; margin: 2rem; color: #1f2937; }
+h1 { font-size: 1.3rem; } code { background: #f3f4f6; padding: 0 .3rem; border-radius: 3px; }
+li { margin: .2rem 0; } .service { margin-bottom: 1rem; } .meta { color: #6b7280; }
+table { border-collapse: collapse; width: 100%; } th, td { text-align: left; padding: .4rem .6rem; border-bottom: 1px solid #e5e7eb; vertical-align: top; }
+ul.links { list-style: none; padding: 0; margin: 0; }
+.healthy { color: #10b981; } .unhealthy { color: #ef4444; } .unknown { color: #6b7280; }
+`)
+
+var notFoundPageTemplate = template.Must(template.New("not_found").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>No service found · ssh-messer</title><style>{{.Style}}</style></head>
+<body>
+<h1>No service found for <code>{{.Host}}{{.Path}}</code></h1>
+<p>Config <code>{{.ConfigName}}</code> serves the following hosts and paths (<a href="/">index</a>):</p>
+{{range .Services}}<div class="service"><strong>{{.Name}}</strong>
+<ul>
+{{range .URLs}}<li><a href="{{.}}">{{.}}</a></li>
+{{end}}{{range .HostPatterns}}<li><code>{{.}}</code></li>
+{{end}}{{range .Paths}}<li><a href="{{.}}">{{.}}</a></li>
+{{end}}</ul></div>
+{{else}}<p>No services configured.</p>
+{{end}}
+</body>
+</html>
+`))
+
+var unavailablePageTemplate = template.Must(template.New("unavailable").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta http-equiv="refresh" content="5"><title>Service unavailable · ssh-messer</title><style>{{.Style}}</style></head>
+<body>
+<h1><code>{{.Service}}</code> is temporarily unavailable</h1>
+<p>{{.Reason}}</p>
+<p class="meta">Config <code>{{.ConfigName}}</code> · this page reloads every 5 seconds · <a href="/">index</a></p>
+</body>
+</html>
+`))
+
+var indexPageTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.ConfigName}} · ssh-messer</title><style>{{.Style}}</style></head>
+<body>
+<h1>{{.ConfigName}}</h1>
+<p class="meta">{{len .Services}} services · generated {{.GeneratedAt.Format "15:04:05"}} · <a href="/_messer/services.json">services.json</a></p>
+<table>
+<tr><th></th><th>Service</th><th>Links</th><th>Hop</th><th>Remote</th><th>Requests (5m / total)</th><th>Last</th></tr>
+{{range .Services}}<tr>
+<td class="{{.Health}}" title="{{.Health}}">{{if eq .Health "unknown"}}○{{else}}●{{end}}</td>
+<td><strong>{{.Name}}</strong></td>
+<td><ul class="links">
+{{range .URLs}}<li><a href="{{.}}">{{.}}</a></li>{{end}}
+{{range .HostPatterns}}<li><code>{{.}}</code></li>{{end}}
+{{range .Paths}}<li><a href="{{.}}">{{.}}</a></li>{{end}}
+{{range .Pages}}<li>🔗 <a href="{{.URL}}">{{.Name}}</a></li>{{end}}
+</ul></td>
+<td>{{.Hop}}</td>
+<td><code>{{.Remote}}</code></td>
+<td>{{.RecentRequests}} / {{.TotalRequests}}{{if .ErrorRequests}} <span class="unhealthy">({{.ErrorRequests}} errors)</span>{{end}}</td>
+<td>{{if .LastRequestAt}}{{.LastStatus}} · {{.LastRequestAt.Format "15:04:05"}}{{if .LastError}}<br><span class="unhealthy">{{.LastError}}</span>{{end}}{{else}}<span class="meta">-</span>{{end}}</td>
+</tr>
+{{else}}<tr><td colspan="7">No services configured.</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// SetHopNames 设置 hop 的展示名称（按 hop 顺序），用于在页面上展示服务所走的 hop
+func (sp *ServiceProxy) SetHopNames(hopNames []string) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	sp.hopNames = hopNames
+}
+
+// ServiceSummaries 汇总所有服务的访问地址、hop 与请求统计
+// port 为链接中使用的端口，为空时使用代理监听的端口
+func (sp *ServiceProxy) ServiceSummaries(port string) []ServiceSummary {
+	sp.mu.RLock()
+	services := sp.services
+	hosts := sp.hostRouter
+	routes := sp.routes
+	hopNames := sp.hopNames
+	backendPools := sp.backendPools
+	probeHealth := make(map[string]ServiceHealthEvent, len(sp.probeHealth))
+	for name, event := range sp.probeHealth {
+		probeHealth[name] = event
+	}
+	sp.mu.RUnlock()
+
+	if port == "" {
+		port = sp.localPort
+	}
+	scheme := "http"
+	if sp.localTLSEnabled() {
+		scheme = "https"
+	}
+
+	now := time.Now()
+	summaries := make([]ServiceSummary, 0, len(services))
+	for i := range services {
+		service := &services[i]
+		name := ServiceDisplayName(service)
+		summary := ServiceSummary{
+			Name:   name,
+			URLs:   []string{},
+			Hop:    describeServiceHop(service, hopNames),
+			Remote: buildRemoteAddress(service),
+		}
+		if pool := backendPools[service]; pool != nil {
+			summary.Remote = pool.addrs()
+		}
+		if service.Subdomain != nil {
+			summary.Subdomain = *service.Subdomain
+		}
+		if service.Alias != nil {
+			summary.Alias = *service.Alias
+		}
+
+		for _, host := range hosts.hostsFor(service) {
+			if strings.HasPrefix(host, "*") || strings.HasPrefix(host, "~") {
+				summary.HostPatterns = append(summary.HostPatterns, host)
+				continue
+			}
+			summary.URLs = append(summary.URLs, scheme+"://"+net.JoinHostPort(host, port)+"/")
+		}
+		for _, route := range routes {
+			if route.service == service {
+				summary.Paths = append(summary.Paths, route.prefix+"/")
+			}
+		}
+		for _, page := range service.Pages {
+			if page.Name != nil && page.URL != nil {
+				summary.Pages = append(summary.Pages, ServicePageLink{Name: *page.Name, URL: *page.URL})
+			}
+		}
+
+		snap := sp.statsFor(name).snapshot(now)
+		summary.Health = snap.passiveHealth()
+		if probe, ok := probeHealth[name]; ok {
+			// 有主动探测结果时以探测为准
+			summary.Health = probe.Health
+		}
+		summary.TotalRequests = snap.Total
+		summary.RecentRequests = snap.Recent
+		summary.ErrorRequests = snap.Errors
+		summary.LastStatus = snap.LastStatus
+		summary.LastError = snap.LastError
+		if !snap.LastRequestAt.IsZero() {
+			lastRequestAt := snap.LastRequestAt
+			summary.LastRequestAt = &lastRequestAt
+		}
+
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// describeServiceHop 服务所走的 hop 描述，未指定 hopOrder 时走最后一个 hop
+func describeServiceHop(service *SSHService, hopNames []string) string {
+	hopOrder := len(hopNames)
+	if service.HopOrder != nil && *service.HopOrder > 0 && *service.HopOrder <= len(hopNames) {
+		hopOrder = *service.HopOrder
+	}
+	if hopOrder == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d. %s", hopOrder, hopNames[hopOrder-1])
+}
+
+// isInternalRequest 判断请求是否访问内置页面
+func isInternalRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, internalPathPrefix)
+}
+
+// serveInternalPage 处理 /_messer/ 下的内置页面
+func (sp *ServiceProxy) serveInternalPage(w http.ResponseWriter, r *http.Request) {
+	switch strings.TrimPrefix(r.URL.Path, internalPathPrefix) {
+	case "", "index.html":
+		sp.writeIndexPage(w, r)
+	case "services.json":
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(sp.ServiceSummaries(requestPort(r))); err != nil {
+			pkg.Logger.Error().Err(err).Str("config_name", sp.configName).Msg("[ServiceProxy] 输出 services.json 失败")
+		}
+	default:
+		sp.writeNotFoundPage(w, r)
+	}
+}
+
+// writeIndexPage 返回服务索引页
+func (sp *ServiceProxy) writeIndexPage(w http.ResponseWriter, r *http.Request) {
+	data := struct {
+		Style       template.CSS
+		ConfigName  string
+		GeneratedAt time.Time
+		Services    []ServiceSummary
+	}{
+		Style:       pageStyle,
+		ConfigName:  sp.configName,
+		GeneratedAt: time.Now(),
+		Services:    sp.ServiceSummaries(requestPort(r)),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	if err := indexPageTemplate.Execute(w, data); err != nil {
+		pkg.Logger.Error().Err(err).Str("config_name", sp.configName).Msg("[ServiceProxy] 渲染索引页失败")
+	}
+}
+
+// writeNotFoundPage 返回列出可用 Host 的 404 页面
+func (sp *ServiceProxy) writeNotFoundPage(w http.ResponseWriter, r *http.Request) {
+	data := struct {
+		Style      template.CSS
+		ConfigName string
+		Host       string
+		Path       string
+		Services   []ServiceSummary
+	}{
+		Style:      pageStyle,
+		ConfigName: sp.configName,
+		Host:       r.Host,
+		Path:       r.URL.Path,
+		Services:   sp.ServiceSummaries(requestPort(r)),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusNotFound)
+	if err := notFoundPageTemplate.Execute(w, data); err != nil {
+		pkg.Logger.Error().Err(err).Str("config_name", sp.configName).Msg("[ServiceProxy] 渲染 404 页面失败")
+	}
+}
+
+// writeUnavailablePage 隧道重连或熔断期间返回 503 提示页，避免请求挂起
+func (sp *ServiceProxy) writeUnavailablePage(w http.ResponseWriter, serviceName string, reason string) {
+	data := struct {
+		Style      template.CSS
+		ConfigName string
+		Service    string
+		Reason     string
+	}{
+		Style:      pageStyle,
+		ConfigName: sp.configName,
+		Service:    serviceName,
+		Reason:     reason,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Retry-After", unavailableRetryAfterSeconds)
+	w.WriteHeader(http.StatusServiceUnavailable)
+	if err := unavailablePageTemplate.Execute(w, data); err != nil {
+		pkg.Logger.Error().Err(err).Str("config_name", sp.configName).Msg("[ServiceProxy] 渲染 503 页面失败")
+	}
+}
+
+// requestPort 从请求的 Host 中获取端口，没有端口时返回空
+func requestPort(r *http.Request) string {
+	if _, port, err := net.SplitHostPort(r.Host); err == nil {
+		return port
+	}
+	return ""
+}
+
+// ServiceDisplayName 服务展示名称：alias > subdomain > host:port
+func ServiceDisplayName(service *SSHService) string {
+	if service.Alias != nil && *service.Alias != "" {
+		return *service.Alias
+	}
+	if service.Subdomain != nil && *service.Subdomain != "" {
+		return *service.Subdomain
+	}
+	return buildRemoteAddress(service)
+}
+
+// ============================================================
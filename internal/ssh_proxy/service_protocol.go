@@ -0,0 +1,113 @@
+package ssh_proxy
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Service HTTP/2 与 gRPC 支持
+// ------------------------------------------------------------
+const (
+	ProtocolHTTP1 = "http1" // 默认，HTTP/1.1
+	ProtocolH2    = "h2"    // 基于 TLS 的 HTTP/2（ALPN 协商，需要 use_tls）
+	ProtocolH2C   = "h2c"   // 明文 HTTP/2（prior knowledge），常用于 gRPC
+)
+
+// grpcStatusNames gRPC 状态码名称
+var grpcStatusNames = []string{
+	"OK", "CANCELLED", "UNKNOWN", "INVALID_ARGUMENT", "DEADLINE_EXCEEDED", "NOT_FOUND",
+	"ALREADY_EXISTS", "PERMISSION_DENIED", "RESOURCE_EXHAUSTED", "FAILED_PRECONDITION",
+	"ABORTED", "OUT_OF_RANGE", "UNIMPLEMENTED", "INTERNAL", "UNAVAILABLE", "DATA_LOSS", "UNAUTHENTICATED",
+}
+
+// serviceProtocol 服务的上游协议，未配置或无效时为 http1
+func serviceProtocol(service *SSHService) string {
+	if service.Protocol == nil {
+		return ProtocolHTTP1
+	}
+	switch protocol := strings.ToLower(*service.Protocol); protocol {
+	case ProtocolH2, ProtocolH2C:
+		return protocol
+	default:
+		return ProtocolHTTP1
+	}
+}
+
+// validateServiceProtocols 检查 protocol 配置
+func validateServiceProtocols(services []SSHService) []error {
+	var errs []error
+	for i := range services {
+		service := &services[i]
+		if service.Protocol == nil || *service.Protocol == "" {
+			continue
+		}
+		switch strings.ToLower(*service.Protocol) {
+		case ProtocolHTTP1, ProtocolH2C:
+		case ProtocolH2:
+			if service.UseTLS == nil || !*service.UseTLS {
+				errs = append(errs, fmt.Errorf("service %s: protocol h2 requires use_tls = true, use h2c for plaintext HTTP/2", ServiceDisplayName(service)))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("service %s: unknown protocol %q (expected http1, h2 or h2c)", ServiceDisplayName(service), *service.Protocol))
+		}
+	}
+	return errs
+}
+
+// upstreamProtocols 上游 http.Transport 使用的协议
+func upstreamProtocols(protocol string) *http.Protocols {
+	protocols := new(http.Protocols)
+	switch protocol {
+	case ProtocolH2:
+		protocols.SetHTTP1(true)
+		protocols.SetHTTP2(true)
+	case ProtocolH2C:
+		protocols.SetUnencryptedHTTP2(true)
+	default:
+		protocols.SetHTTP1(true)
+	}
+	return protocols
+}
+
+// localProtocols 本地监听支持的协议：HTTP/1.1 与 h2c，启用 TLS 时额外支持 h2
+func localProtocols() *http.Protocols {
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(true)
+	return protocols
+}
+
+// isGRPCRequest 判断是否为 gRPC 请求（需要流式转发与 trailer）
+func isGRPCRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc")
+}
+
+// grpcStatusFromHeader 从响应 header/trailer 中读取 gRPC 状态
+func grpcStatusFromHeader(header http.Header) (string, string) {
+	status := header.Get("Grpc-Status")
+	if status == "" {
+		status = header.Get(http.TrailerPrefix + "Grpc-Status")
+	}
+	if status == "" {
+		return "", ""
+	}
+	message := header.Get("Grpc-Message")
+	if message == "" {
+		message = header.Get(http.TrailerPrefix + "Grpc-Message")
+	}
+	return status, message
+}
+
+// GRPCStatusName 返回 gRPC 状态码的名称，如 "14" -> "UNAVAILABLE"
+func GRPCStatusName(status string) string {
+	for code, name := range grpcStatusNames {
+		if fmt.Sprint(code) == status {
+			return name
+		}
+	}
+	return status
+}
+
+// ============================================================
@@ -8,7 +8,6 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"net/url"
-	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -64,7 +63,27 @@ type ServiceProxy struct {
 	sshClient       *ssh.Client
 	getClientForHop func(int) *ssh.Client // 根据 hopOrder 获取对应的 SSH client
 	server          *http.Server
-	serviceMap      map[string]*SSHService // subdomain -> service mapping
+	settings        *ServiceProxySettings
+	hostRouter      *hostRouter    // Host -> service 路由
+	routes          []serviceRoute // 按优先级排序的路径路由
+	defaultService  *SSHService    // 未匹配请求的兜底服务
+	capture         *captureConfig // 请求捕获配置，未启用时为 nil
+	headerRules     map[*SSHService]*headerRules
+	upstreamTLS     map[*SSHService]*upstreamTLS
+	backendPools    map[*SSHService]*backendPool // 配置了 targets 的服务的目标池
+	breakers        map[*SSHService]*circuitBreaker
+	reconnecting    bool // SSH 隧道重连中，请求直接返回 503 提示页
+	faults          map[*SSHService]*faultConfig
+	faultsPaused    bool // TUI 中暂停故障注入
+	mocks           map[*SSHService][]mockRule
+	caches          map[*SSHService]*responseCache
+	access          *accessControl // 本地访问控制，未启用时为 nil
+	accessErr       error          // 访问控制配置无效时拒绝所有请求
+	hopNames        []string       // 按顺序的 hop 展示名称
+	stats           map[string]*serviceStats
+	probeHealth     map[string]ServiceHealthEvent // 主动健康检查的最新结果
+	healthStop      chan struct{}
+	statsMu         sync.Mutex
 	mu              sync.RWMutex
 	stopped         bool
 }
@@ -77,23 +96,94 @@ func NewServiceProxy(configName string, localPort string, services []SSHService,
 
 // NewServiceProxyWithHopSelector 创建新的 Service Proxy，支持根据 hopOrder 选择 client
 func NewServiceProxyWithHopSelector(configName string, localPort string, services []SSHService, defaultClient *ssh.Client, getClientForHop func(int) *ssh.Client) *ServiceProxy {
-	serviceMap := make(map[string]*SSHService)
-	for i := range services {
-		service := &services[i]
-		if service.Subdomain != nil && *service.Subdomain != "" {
-			serviceMap[*service.Subdomain] = service
-		}
-	}
-
-	return &ServiceProxy{
+	sp := &ServiceProxy{
 		configName:      configName,
 		localPort:       localPort,
 		services:        services,
 		sshClient:       defaultClient,
 		getClientForHop: getClientForHop,
-		serviceMap:      serviceMap,
+		stats:           make(map[string]*serviceStats),
+		probeHealth:     make(map[string]ServiceHealthEvent),
 		stopped:         false,
 	}
+	sp.rebuildRoutingLocked()
+	return sp
+}
+
+// SetSettings 更新代理全局设置并重建路由
+func (sp *ServiceProxy) SetSettings(settings *ServiceProxySettings) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+
+	sp.settings = settings
+	sp.rebuildRoutingLocked()
+}
+
+// rebuildRoutingLocked 根据 services 与 settings 重建路由表，调用方需持有写锁
+// 路由表构建后只读，请求处理时整体替换，确保并发安全
+func (sp *ServiceProxy) rebuildRoutingLocked() {
+	var baseDomains []string
+	var captureSettings *CaptureSettings
+	var accessSettings *AccessSettings
+	if sp.settings != nil {
+		baseDomains = sp.settings.BaseDomains
+		captureSettings = sp.settings.Capture
+		accessSettings = sp.settings.Access
+	}
+
+	hostRouter, errs := buildHostRouter(sp.services, baseDomains)
+	for _, err := range errs {
+		pkg.Logger.Warn().Err(err).Str("config_name", sp.configName).Msg("[ServiceProxy] 忽略无效的 host 规则")
+	}
+
+	sp.hostRouter = hostRouter
+	sp.routes = buildServiceRoutes(sp.services)
+	sp.defaultService = findDefaultService(sp.services)
+	sp.capture = buildCaptureConfig(captureSettings)
+
+	headerRules, errs := buildHeaderRules(sp.services)
+	for _, err := range errs {
+		pkg.Logger.Warn().Err(err).Str("config_name", sp.configName).Msg("[ServiceProxy] 忽略无法解析的 header 规则")
+	}
+	sp.headerRules = headerRules
+
+	backendPools, errs := buildBackendPools(sp.services)
+	for _, err := range errs {
+		pkg.Logger.Warn().Err(err).Str("config_name", sp.configName).Msg("[ServiceProxy] 忽略无效的 targets 配置")
+	}
+	sp.backendPools = backendPools
+
+	sp.breakers = buildCircuitBreakers(sp.services)
+
+	faults, errs := buildFaultConfigs(sp.services)
+	for _, err := range errs {
+		pkg.Logger.Warn().Err(err).Str("config_name", sp.configName).Msg("[ServiceProxy] 忽略无效的 faults 配置")
+	}
+	sp.faults = faults
+
+	for _, err := range validateServiceProtocols(sp.services) {
+		pkg.Logger.Warn().Err(err).Str("config_name", sp.configName).Msg("[ServiceProxy] 无效的 protocol 配置，使用 HTTP/1.1")
+	}
+
+	mocks, errs := buildMockRules(sp.services)
+	for _, err := range errs {
+		pkg.Logger.Warn().Err(err).Str("config_name", sp.configName).Msg("[ServiceProxy] 忽略无效的 mock 配置")
+	}
+	sp.mocks = mocks
+	sp.caches = buildResponseCaches(sp.services)
+
+	sp.upstreamTLS = buildUpstreamTLS(sp.services)
+	for service, upstream := range sp.upstreamTLS {
+		if upstream.err != nil {
+			pkg.Logger.Error().Err(upstream.err).Str("config_name", sp.configName).Str("service", ServiceDisplayName(service)).Msg("[ServiceProxy] 上游 TLS 配置无效")
+		}
+	}
+
+	// 访问控制配置无效时不能静默放行
+	sp.access, sp.accessErr = buildAccessControl(accessSettings)
+	if sp.accessErr != nil {
+		pkg.Logger.Error().Err(sp.accessErr).Str("config_name", sp.configName).Msg("[ServiceProxy] 访问控制配置无效，将拒绝所有请求")
+	}
 }
 
 // ============================================================
@@ -117,26 +207,68 @@ func (sp *ServiceProxy) StartReverseProxy() error {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", sp.handleReverseProxyRequest)
 
+	// 本地监听同时支持 HTTP/1.1 与 HTTP/2（明文 h2c，配置证书时为 h2）
 	sp.server = &http.Server{
-		Addr:    ":" + sp.localPort,
-		Handler: mux,
+		Addr:      ":" + sp.localPort,
+		Handler:   mux,
+		Protocols: localProtocols(),
+	}
+	server := sp.server
+	certFile, keyFile, err := sp.localTLSFiles()
+	if err != nil {
+		sp.server = nil
+		return err
 	}
 
 	go func() {
-		if err := sp.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if certFile != "" {
+			err = server.ListenAndServeTLS(certFile, keyFile)
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			pkg.Logger.Error().Err(err).Str("config_name", sp.configName).Str("port", sp.localPort).Msg("[ServiceProxy] 服务代理服务器错误")
 		}
 	}()
 
+	sp.startHealthChecks()
+
 	pkg.Logger.Info().Str("config_name", sp.configName).Str("port", sp.localPort).Msg("[ServiceProxy] 服务代理启动成功")
 	return nil
 }
 
+// localTLSEnabled 本地监听是否启用 TLS
+func (sp *ServiceProxy) localTLSEnabled() bool {
+	sp.mu.RLock()
+	settings := sp.settings
+	sp.mu.RUnlock()
+	return settings != nil && settings.TLSCert != nil && *settings.TLSCert != ""
+}
+
+// localTLSFiles 本地监听的证书与私钥路径，未启用 TLS 时返回空
+func (sp *ServiceProxy) localTLSFiles() (string, string, error) {
+	if sp.settings == nil || sp.settings.TLSCert == nil || *sp.settings.TLSCert == "" {
+		return "", "", nil
+	}
+	if sp.settings.TLSKey == nil || *sp.settings.TLSKey == "" {
+		return "", "", fmt.Errorf("proxy tls_key is required when tls_cert is set")
+	}
+	certFile, err := pkg.ExpandHome(*sp.settings.TLSCert)
+	if err != nil {
+		return "", "", err
+	}
+	keyFile, err := pkg.ExpandHome(*sp.settings.TLSKey)
+	if err != nil {
+		return "", "", err
+	}
+	return certFile, keyFile, nil
+}
+
 // handleRequest 处理 HTTP 请求
 func (sp *ServiceProxy) handleReverseProxyRequest(w http.ResponseWriter, r *http.Request) {
 	sp.mu.RLock()
 	stopped := sp.stopped
-	serviceMap := sp.serviceMap
 	sp.mu.RUnlock()
 
 	if stopped {
@@ -144,74 +276,119 @@ func (sp *ServiceProxy) handleReverseProxyRequest(w http.ResponseWriter, r *http
 		return
 	}
 
-	// 从 Host header 提取 subdomain
-	host := r.Host
-	if host == "" {
-		host = r.Header.Get("Host")
+	// 在路由之前校验本地访问权限，重放请求由 TUI 发起无需校验
+	if replayOfFromContext(r.Context()) == "" && !sp.authorize(w, r) {
+		return
 	}
 
-	// 尝试通过 subdomain 路由
-	var targetService *SSHService
-	subdomain := extractSubdomain(host)
-	if subdomain != "" {
-		targetService = serviceMap[subdomain]
+	match, ok := sp.resolveRoute(r)
+	if !ok {
+		// 未匹配任何服务时，根路径返回服务索引页，其余返回 404 提示页
+		if r.URL.Path == "/" {
+			sp.writeIndexPage(w, r)
+		} else {
+			sp.writeNotFoundPage(w, r)
+		}
+		return
 	}
+	if match.internal {
+		sp.serveInternalPage(w, r)
+		return
+	}
+	targetService := match.service
 
-	// 如果 subdomain 路由失败，尝试通过路径路由
-	if targetService == nil {
-		path := r.URL.Path
-		for _, service := range sp.services {
-			if service.Alias != nil && strings.HasPrefix(path, "/"+*service.Alias+"/") {
-				targetService = &service
-				// 重写路径，移除 alias 前缀
-				r.URL.Path = strings.TrimPrefix(path, "/"+*service.Alias)
-				break
-			}
+	// 记录请求开始时间
+	startTime := time.Now()
+
+	// 在改写路径之前开始捕获，记录客户端访问的原始 URL
+	sp.mu.RLock()
+	captureConfig := sp.capture
+	sp.mu.RUnlock()
+	recorder := captureConfig.startCapture(r, startTime)
+
+	// 获取服务别名
+	serviceAlias := ServiceDisplayName(targetService)
+
+	local := localRequest{sp: sp, serviceAlias: serviceAlias, startTime: startTime, recorder: recorder, capture: captureConfig}
+
+	// 前缀路由访问 "/<prefix>" 时重定向到 "/<prefix>/"，保证页面内相对链接可用
+	if match.strippedPrefix != "" && r.URL.Path == match.strippedPrefix && (r.Method == http.MethodGet || r.Method == http.MethodHead) {
+		target := match.strippedPrefix + "/"
+		if r.URL.RawQuery != "" {
+			target += "?" + r.URL.RawQuery
+		}
+		local.serve(w, r, func(rw http.ResponseWriter) string {
+			http.Redirect(rw, r, target, http.StatusTemporaryRedirect)
+			return ""
+		}, nil)
+		return
+	}
+	if match.strippedPrefix != "" {
+		r.URL.Path = stripRoutePrefix(r.URL.Path, match.strippedPrefix)
+		if r.URL.RawPath != "" {
+			r.URL.RawPath = stripRoutePrefix(r.URL.RawPath, match.strippedPrefix)
 		}
 	}
 
-	if targetService == nil {
-		http.Error(w, "No service found for this request", http.StatusNotFound)
+	// 命中 mock 时直接在本地响应，不依赖 SSH 隧道
+	if mock := sp.findMock(targetService, r); mock != nil {
+		sp.serveMock(w, r, mock, local)
 		return
 	}
 
-	// 根据 service 的 HopOrder 选择对应的 SSH client
+	// 命中未过期的缓存时直接返回，过期的缓存在转发时重新验证
 	sp.mu.RLock()
-	getClientForHop := sp.getClientForHop
-	defaultClient := sp.sshClient
+	cache := sp.caches[targetService]
 	sp.mu.RUnlock()
-
-	var sshClient *ssh.Client
-	if targetService.HopOrder != nil && *targetService.HopOrder > 0 && getClientForHop != nil {
-		// 使用指定 hopOrder 的 client
-		sshClient = getClientForHop(*targetService.HopOrder)
-		if sshClient == nil {
-			// 如果指定的 hopOrder client 不存在，使用默认 client
-			sshClient = defaultClient
+	var cacheStatus string
+	var staleEntry *cacheEntry
+	cacheKey, cacheableRequest := cacheKey(r)
+	cacheableRequest = cacheableRequest && cache != nil
+	if cacheableRequest {
+		cacheStatus = CacheStatusMiss
+		if entry := cache.get(cacheKey); entry != nil && entry.matches(r) && !requestBypassesCache(r) {
+			if entry.fresh(time.Now()) {
+				local.serve(w, r, func(rw http.ResponseWriter) string {
+					writeCachedResponse(rw, r, entry, CacheStatusHit)
+					return ""
+				}, func(event *ServiceProxyLogEvent) {
+					event.CacheStatus = CacheStatusHit
+				})
+				return
+			}
+			staleEntry = entry
 		}
-	} else {
-		// 使用默认 client
-		sshClient = defaultClient
 	}
 
-	if sshClient == nil {
-		http.Error(w, "SSH client is not available", http.StatusServiceUnavailable)
+	// 隧道重连中或熔断打开时快速失败
+	sp.mu.RLock()
+	reconnecting := sp.reconnecting
+	breaker := sp.breakers[targetService]
+	sp.mu.RUnlock()
+	if reconnecting {
+		sp.rejectUnavailable(w, r, serviceAlias, startTime, "The SSH tunnel is reconnecting, please retry in a few seconds.")
 		return
 	}
 
-	// 记录请求开始时间
-	startTime := time.Now()
+	// 根据 service 的 HopOrder 选择对应的 SSH client
+	sshClient := sp.clientForHop(targetService.HopOrder)
+	if sshClient == nil {
+		sp.rejectUnavailable(w, r, serviceAlias, startTime, "The SSH tunnel is not connected.")
+		return
+	}
+	if allowed, remaining := breaker.allow(); !allowed {
+		reason := "The upstream service keeps failing, requests are paused (circuit breaker open)."
+		if remaining > 0 {
+			reason = fmt.Sprintf("The upstream service keeps failing, requests are paused for %s (circuit breaker open).", remaining.Round(time.Second))
+		}
+		sp.rejectUnavailable(w, r, serviceAlias, startTime, reason)
+		return
+	}
 
 	// 生成请求 ID
 	requestID := generateRequestID()
-
-	// 获取服务别名
-	serviceAlias := "unknown"
-	if targetService.Alias != nil {
-		serviceAlias = *targetService.Alias
-	} else if targetService.Subdomain != nil {
-		serviceAlias = *targetService.Subdomain
-	}
+	replayOf := replayOfFromContext(r.Context())
+	faults := sp.faultPlanFor(targetService)
 
 	// 在请求开始时发送日志（StatusCode 为 0 表示请求中）
 	requestEvent := ServiceProxyLogEvent{
@@ -224,15 +401,25 @@ func (sp *ServiceProxy) handleReverseProxyRequest(w http.ResponseWriter, r *http
 		ResponseSize: 0,
 		Timestamp:    startTime,
 		IsUpdate:     false,
+		ReplayOf:     replayOf,
+		Faults:       faults.describe(),
 	}
 	serviceProxyLogBroker.Publish(pubsub.UpdatedEvent, requestEvent)
 
+	// 限速时在最外层按带宽写出响应
+	if faults != nil && faults.bytesPerSec > 0 {
+		w = &throttledResponseWriter{ResponseWriter: w, ctx: r.Context(), bytesPerSec: faults.bytesPerSec}
+	}
+
 	// 创建响应写入器来捕获状态码和响应大小
 	responseWriter := &responseWriter{
 		ResponseWriter: w,
 		statusCode:     http.StatusOK,
 		responseSize:   0,
 	}
+	if recorder != nil {
+		responseWriter.capture = &captureBuffer{limit: captureConfig.maxBodyBytes}
+	}
 
 	// 构建服务配置
 	serviceConfig := buildServiceConfig(targetService)
@@ -242,6 +429,8 @@ func (sp *ServiceProxy) handleReverseProxyRequest(w http.ResponseWriter, r *http
 		duration := time.Since(startTime)
 		http.Error(w, fmt.Sprintf("Invalid remote address: %v", err), http.StatusInternalServerError)
 		// 发送错误响应日志
+		breaker.record(true)
+		sp.statsFor(serviceAlias).record(http.StatusInternalServerError, err.Error(), startTime)
 		errorEvent := ServiceProxyLogEvent{
 			RequestID:    requestID,
 			ConfigName:   sp.configName,
@@ -253,6 +442,9 @@ func (sp *ServiceProxy) handleReverseProxyRequest(w http.ResponseWriter, r *http
 			Timestamp:    startTime,
 			IsUpdate:     true,
 			Duration:     duration,
+			Capture:      recorder.finish(nil, duration),
+			ReplayOf:     replayOf,
+			Faults:       faults.describe(),
 		}
 		serviceProxyLogBroker.Publish(pubsub.UpdatedEvent, errorEvent)
 		return
@@ -260,9 +452,17 @@ func (sp *ServiceProxy) handleReverseProxyRequest(w http.ResponseWriter, r *http
 
 	// 创建反向代理
 	proxy := httputil.NewSingleHostReverseProxy(remoteURL)
+	rewriteHTML := targetService.RewriteHTML != nil && *targetService.RewriteHTML
+	sp.mu.RLock()
+	rules := sp.headerRules[targetService]
+	sp.mu.RUnlock()
 
 	// 自定义 Transport 以通过 SSH 隧道
 	originalDirector := proxy.Director
+	compression := targetService.Compression != nil && *targetService.Compression && !(rewriteHTML && match.strippedPrefix != "")
+	clientAcceptEncoding := r.Header.Get("Accept-Encoding")
+	var negotiation *compressionNegotiation
+	revalidating := false
 	proxy.Director = func(req *http.Request) {
 		originalDirector(req)
 		// 使用配置中的 scheme 和 host
@@ -270,11 +470,84 @@ func (sp *ServiceProxy) handleReverseProxyRequest(w http.ResponseWriter, r *http
 		req.URL.Host = serviceConfig.remoteAddr
 		// 重写 Host header（关键：用于 SNI 和虚拟主机识别）
 		req.Host = serviceConfig.remoteHost
+
+		if match.strippedPrefix != "" {
+			if targetService.ForwardedPrefix != nil && *targetService.ForwardedPrefix {
+				req.Header.Set("X-Forwarded-Prefix", match.strippedPrefix)
+			}
+			if rewriteHTML {
+				// 需要改写 HTML 时要求上游返回未压缩内容
+				req.Header.Del("Accept-Encoding")
+			}
+		}
+		if compression {
+			negotiation = negotiateUpstreamEncoding(req, clientAcceptEncoding)
+		}
+		// 过期缓存带上条件请求头，上游返回 304 时复用缓存
+		if staleEntry != nil && req.Header.Get("If-None-Match") == "" && req.Header.Get("If-Modified-Since") == "" {
+			if etag := staleEntry.header.Get("ETag"); etag != "" {
+				req.Header.Set("If-None-Match", etag)
+				revalidating = true
+			}
+			if lastModified := staleEntry.header.Get("Last-Modified"); lastModified != "" {
+				req.Header.Set("If-Modified-Since", lastModified)
+				revalidating = true
+			}
+		}
+		rules.apply(req)
+	}
+	if isGRPCRequest(r) {
+		// gRPC 需要立即转发每个消息帧
+		proxy.FlushInterval = -1
+	}
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		responseWriter.proto = resp.Proto
+		if revalidating && resp.StatusCode == http.StatusNotModified {
+			refreshed := cache.refresh(staleEntry, resp, time.Now())
+			resp.Body.Close()
+			*resp = *cachedResponse(refreshed, resp.Request)
+			cacheStatus = CacheStatusRevalidated
+			return nil
+		}
+		if err := negotiation.decodeResponse(resp); err != nil {
+			return err
+		}
+		if match.strippedPrefix != "" {
+			rewriteLocationHeader(resp, match.strippedPrefix)
+			if rewriteHTML {
+				if err := rewriteHTMLLinks(resp, match.strippedPrefix); err != nil {
+					return err
+				}
+			}
+		}
+		if cacheableRequest && responseCacheable(resp, r) {
+			// 读取完整 body 后写入缓存（存储的是改写后的内容）
+			header := resp.Header.Clone()
+			statusCode := resp.StatusCode
+			vary, _ := responseVary(resp, r)
+			_, public := parseCacheControl(header.Get("Cache-Control"))["public"]
+			resp.Body = &cachingReadCloser{ReadCloser: resp.Body, limit: cache.maxBodyBytes, onDone: func(body []byte) {
+				now := time.Now()
+				cache.put(&cacheEntry{
+					key:        cacheKey,
+					statusCode: statusCode,
+					header:     header,
+					body:       append([]byte(nil), body...),
+					storedAt:   now,
+					expiresAt:  now.Add(freshnessLifetime(header, now)),
+					vary:       vary,
+					public:     public,
+				})
+			}}
+		}
+		return nil
 	}
-	proxy.Transport = &sshTransport{
-		sshClient:     sshClient,
-		useTLS:        serviceConfig.useTLS,
-		tlsServerName: serviceConfig.tlsServerName,
+	transport := sp.newTransport(targetService, serviceConfig.useTLS, sshClient)
+	transport.retry = buildRetryPolicy(targetService.Retry)
+	transport.streaming = isGRPCRequest(r)
+	proxy.Transport = transport
+	if faults != nil {
+		proxy.Transport = &faultTransport{next: transport, plan: faults}
 	}
 
 	// 设置错误处理器以捕获错误消息
@@ -290,6 +563,8 @@ func (sp *ServiceProxy) handleReverseProxyRequest(w http.ResponseWriter, r *http
 	// 计算请求用时
 	duration := time.Since(startTime)
 
+	grpcStatus, grpcMessage := grpcStatusFromHeader(responseWriter.Header())
+
 	// 在响应返回后发送更新日志
 	responseEvent := ServiceProxyLogEvent{
 		RequestID:    requestID,
@@ -303,11 +578,124 @@ func (sp *ServiceProxy) handleReverseProxyRequest(w http.ResponseWriter, r *http
 		IsUpdate:     true,
 		ErrorMessage: errorMessage,
 		Duration:     duration,
+		Capture:      recorder.finish(responseWriter, duration),
+		ReplayOf:     replayOf,
+		Backend:      transport.backend,
+		Faults:       faults.describe(),
+		Protocol:     responseWriter.proto,
+		CacheStatus:  cacheStatus,
+		GRPCStatus:   grpcStatus,
+		GRPCMessage:  grpcMessage,
 	}
 
+	breaker.record(errorMessage != "")
+	sp.statsFor(serviceAlias).record(responseWriter.statusCode, errorMessage, startTime)
 	serviceProxyLogBroker.Publish(pubsub.UpdatedEvent, responseEvent)
 }
 
+// localRequest 在本地生成响应（mock、缓存命中）所需的请求上下文
+type localRequest struct {
+	sp           *ServiceProxy
+	serviceAlias string
+	startTime    time.Time
+	recorder     *exchangeRecorder
+	capture      *captureConfig
+}
+
+// serve 由 write 写出响应（返回错误消息），记录统计并发布日志，annotate 用于标记日志事件（可为 nil）
+func (l localRequest) serve(w http.ResponseWriter, r *http.Request, write func(rw http.ResponseWriter) string, annotate func(event *ServiceProxyLogEvent)) {
+	responseWriter := &responseWriter{
+		ResponseWriter: w,
+		statusCode:     http.StatusOK,
+	}
+	if l.recorder != nil {
+		responseWriter.capture = &captureBuffer{limit: l.capture.maxBodyBytes}
+	}
+
+	errorMessage := write(responseWriter)
+
+	duration := time.Since(l.startTime)
+	l.sp.statsFor(l.serviceAlias).record(responseWriter.statusCode, errorMessage, l.startTime)
+	event := ServiceProxyLogEvent{
+		RequestID:    generateRequestID(),
+		ConfigName:   l.sp.configName,
+		ServiceAlias: l.serviceAlias,
+		Method:       r.Method,
+		URL:          r.URL.String(),
+		StatusCode:   responseWriter.statusCode,
+		ResponseSize: responseWriter.responseSize,
+		Timestamp:    l.startTime,
+		IsUpdate:     true,
+		ErrorMessage: errorMessage,
+		Duration:     duration,
+		Capture:      l.recorder.finish(responseWriter, duration),
+		ReplayOf:     replayOfFromContext(r.Context()),
+	}
+	if annotate != nil {
+		annotate(&event)
+	}
+	serviceProxyLogBroker.Publish(pubsub.UpdatedEvent, event)
+}
+
+// rejectUnavailable 返回 503 提示页并发布日志
+func (sp *ServiceProxy) rejectUnavailable(w http.ResponseWriter, r *http.Request, serviceAlias string, startTime time.Time, reason string) {
+	sp.writeUnavailablePage(w, serviceAlias, reason)
+	serviceProxyLogBroker.Publish(pubsub.UpdatedEvent, ServiceProxyLogEvent{
+		RequestID:    generateRequestID(),
+		ConfigName:   sp.configName,
+		ServiceAlias: serviceAlias,
+		Method:       r.Method,
+		URL:          r.URL.String(),
+		StatusCode:   http.StatusServiceUnavailable,
+		Timestamp:    startTime,
+		IsUpdate:     true,
+		ErrorMessage: reason,
+		Duration:     time.Since(startTime),
+		ReplayOf:     replayOfFromContext(r.Context()),
+	})
+}
+
+// SetReconnecting 标记 SSH 隧道是否在重连，重连期间保持监听并返回 503 提示页
+func (sp *ServiceProxy) SetReconnecting(reconnecting bool) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	sp.reconnecting = reconnecting
+}
+
+// IsReconnecting 是否处于重连状态
+func (sp *ServiceProxy) IsReconnecting() bool {
+	sp.mu.RLock()
+	defer sp.mu.RUnlock()
+	return sp.reconnecting
+}
+
+// ResumeWithClient 重连成功后替换默认 client 并恢复转发
+func (sp *ServiceProxy) ResumeWithClient(client *ssh.Client) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	sp.sshClient = client
+	sp.reconnecting = false
+}
+
+// UpdateServices 热更新服务列表（配置文件变更时），保持本地监听不中断
+func (sp *ServiceProxy) UpdateServices(services []SSHService) {
+	sp.mu.Lock()
+	running := sp.server != nil && !sp.stopped
+	if running {
+		sp.stopHealthChecks()
+	}
+	sp.services = services
+	sp.rebuildRoutingLocked()
+	removed := sp.pruneProbeHealth()
+	if running {
+		sp.startHealthChecks()
+	}
+	sp.mu.Unlock()
+	publishProbeRemoved(removed)
+
+	pkg.Logger.Info().Str("config_name", sp.configName).Int("services_count", len(services)).Msg("[ServiceProxy] 服务列表已更新")
+}
+
 // Stop 停止 Service Proxy 服务器
 func (sp *ServiceProxy) StopReverseProxy() error {
 	sp.mu.Lock()
@@ -320,6 +708,9 @@ func (sp *ServiceProxy) StopReverseProxy() error {
 	pkg.Logger.Debug().Str("config_name", sp.configName).Str("port", sp.localPort).Msg("[ServiceProxy] 开始停止服务代理")
 
 	sp.stopped = true
+	sp.stopHealthChecks()
+	// 停止后不再有探测结果，通知侧边栏移除健康标记
+	defer publishProbeRemoved(sp.pruneProbeHealth())
 
 	if sp.server != nil {
 		err := sp.server.Close()
@@ -334,31 +725,86 @@ func (sp *ServiceProxy) StopReverseProxy() error {
 	return nil
 }
 
-// ============================================================
+// clientForHop 根据 hopOrder 选择 SSH client，未指定或不存在时使用默认 client
+func (sp *ServiceProxy) clientForHop(hopOrder *int) *ssh.Client {
+	sp.mu.RLock()
+	getClientForHop := sp.getClientForHop
+	defaultClient := sp.sshClient
+	sp.mu.RUnlock()
 
-// extractSubdomain 从 host 中提取 subdomain
-// ------------------------------------------------------------
-func extractSubdomain(host string) string {
-	// 移除端口
-	if idx := strings.Index(host, ":"); idx != -1 {
-		host = host[:idx]
+	if hopOrder != nil && *hopOrder > 0 && getClientForHop != nil {
+		if client := getClientForHop(*hopOrder); client != nil {
+			return client
+		}
 	}
+	return defaultClient
+}
 
-	// 移除 localhost 后缀
-	if strings.HasSuffix(host, ".localhost") {
-		subdomain := strings.TrimSuffix(host, ".localhost")
-		return subdomain
+// authorize 校验本地访问权限，拒绝时写入响应并发布日志
+func (sp *ServiceProxy) authorize(w http.ResponseWriter, r *http.Request) bool {
+	sp.mu.RLock()
+	access := sp.access
+	accessErr := sp.accessErr
+	sp.mu.RUnlock()
+
+	if accessErr != nil {
+		sp.publishAccessDenied(r, http.StatusForbidden, accessErr.Error())
+		http.Error(w, "Access control is misconfigured", http.StatusForbidden)
+		return false
+	}
+
+	statusCode, reason := access.check(w, r)
+	if statusCode == 0 {
+		return true
+	}
+	sp.publishAccessDenied(r, statusCode, reason)
+	http.Error(w, http.StatusText(statusCode), statusCode)
+	return false
+}
+
+// resolveRoute 为请求选择目标服务：Host -> 路径路由 -> 默认服务
+func (sp *ServiceProxy) resolveRoute(r *http.Request) (routeMatch, bool) {
+	sp.mu.RLock()
+	hostRouter := sp.hostRouter
+	routes := sp.routes
+	defaultService := sp.defaultService
+	sp.mu.RUnlock()
+
+	host := r.Host
+	if host == "" {
+		host = r.Header.Get("Host")
+	}
+
+	// 尝试通过 Host 路由（精确 / 通配 / 正则 / subdomain）
+	if service := hostRouter.match(host); service != nil {
+		return routeMatch{service: service}, true
 	}
 
-	// 如果 host 包含点，尝试提取第一个部分作为 subdomain
-	parts := strings.Split(host, ".")
-	if len(parts) > 1 {
-		return parts[0]
+	// Host 未命中服务时，/_messer/ 下为内置页面
+	if isInternalRequest(r) {
+		return routeMatch{internal: true}, true
 	}
 
-	return ""
+	// 如果 Host 路由失败，尝试通过路径路由
+	if route, ok := matchPathRoute(routes, r.URL.Path); ok {
+		match := routeMatch{service: route.service}
+		if route.stripPrefix {
+			match.strippedPrefix = route.prefix
+		}
+		return match, true
+	}
+
+	if defaultService != nil {
+		return routeMatch{service: defaultService}, true
+	}
+
+	return routeMatch{}, false
 }
 
+// ============================================================
+
+// 远程服务地址与传输
+// ------------------------------------------------------------
 // buildRemoteAddress 构建远程服务地址
 func buildRemoteAddress(service *SSHService) string {
 	host := "localhost"
@@ -418,16 +864,109 @@ func buildServiceConfig(service *SSHService) ServiceConfig {
 }
 
 // sshTransport 通过 SSH 隧道传输 HTTP 请求
+// 每个请求创建一个实例，backend 记录实际使用的上游目标
 type sshTransport struct {
-	sshClient     *ssh.Client
-	useTLS        bool
-	tlsServerName string
+	sshClient    *ssh.Client
+	useTLS       bool
+	tlsConfig    *tls.Config // 由 buildUpstreamTLS 预先构建
+	tlsErr       error       // TLS 配置无效时直接返回该错误
+	pool         *backendPool
+	clientForHop func(*int) *ssh.Client
+	retry        *retryPolicy
+	protocol     string // ProtocolHTTP1 / ProtocolH2 / ProtocolH2C
+	streaming    bool   // gRPC 等长连接流式请求不设置整体超时
+	backend      string
+}
+
+// newTransport 构建转发到服务的 sshTransport，代理请求与健康检查使用相同的协议、上游 TLS 与多目标设置
+func (sp *ServiceProxy) newTransport(service *SSHService, useTLS bool, client *ssh.Client) *sshTransport {
+	sp.mu.RLock()
+	upstream := sp.upstreamTLS[service]
+	pool := sp.backendPools[service]
+	sp.mu.RUnlock()
+
+	transport := &sshTransport{
+		sshClient:    client,
+		useTLS:       useTLS,
+		pool:         pool,
+		clientForHop: sp.clientForHop,
+		protocol:     serviceProtocol(service),
+	}
+	if upstream != nil {
+		transport.tlsConfig = upstream.config
+		transport.tlsErr = upstream.err
+	}
+	return transport
 }
 
 func (t *sshTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.tlsErr != nil {
+		return nil, t.tlsErr
+	}
+
+	resp, err := t.roundTripBackends(req)
+	if err == nil || !t.retry.canRetry(req) {
+		return resp, err
+	}
+	for attempt := 1; attempt <= t.retry.attempts && isRetryableError(err); attempt++ {
+		if !t.retry.wait(req.Context(), attempt) {
+			break
+		}
+		pkg.Logger.Debug().Err(err).Str("url", req.URL.String()).Int("attempt", attempt).Msg("[ServiceProxy] 重试上游请求")
+		resp, err = t.roundTripBackends(req)
+		if err == nil {
+			return resp, nil
+		}
+	}
+	return nil, err
+}
+
+// roundTripBackends 发送一次请求，配置了多个目标时在目标间故障转移
+func (t *sshTransport) roundTripBackends(req *http.Request) (*http.Response, error) {
+	if t.pool == nil {
+		return t.roundTrip(req, t.sshClient, nil)
+	}
+
+	// 连接失败时请求尚未发出，无 body 的请求可以换下一个目标重试
+	canRetry := req.Body == nil || req.Body == http.NoBody || req.ContentLength == 0
+	var lastErr error
+	for _, i := range t.pool.candidates() {
+		b := &t.pool.backends[i]
+		client := t.clientForHop(b.hopOrder)
+		if client == nil {
+			continue
+		}
+
+		t.backend = b.addr
+		resp, err := t.roundTrip(req, client, b)
+		if err == nil {
+			t.pool.markUp(i)
+			return resp, nil
+		}
+		if !isBackendDialError(err) {
+			return nil, err
+		}
+
+		t.pool.markDown(i)
+		pkg.Logger.Warn().Err(err).Str("backend", b.addr).Msg("[ServiceProxy] 上游目标连接失败，标记为不可用")
+		lastErr = err
+		if !canRetry {
+			break
+		}
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no available backend")
+	}
+	return nil, lastErr
+}
+
+// roundTrip 通过指定的 SSH client 发送请求，b 不为空时转发到该上游目标
+func (t *sshTransport) roundTrip(req *http.Request, sshClient *ssh.Client, b *backend) (*http.Response, error) {
 	// 从请求 URL 提取目标地址
 	targetAddr := req.URL.Host
-	if req.URL.Port() == "" {
+	if b != nil {
+		targetAddr = b.addr
+	} else if req.URL.Port() == "" {
 		if req.URL.Scheme == "https" || t.useTLS {
 			targetAddr += ":443"
 		} else {
@@ -446,32 +985,41 @@ func (t *sshTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	newReq.RequestURI = "" // 清除 RequestURI，这是客户端请求的要求
 	newReq.URL.Scheme = scheme
 	newReq.URL.Host = targetAddr
+	if b != nil && b.hostHeader != "" {
+		newReq.Host = b.hostHeader
+	}
 
 	// 创建 HTTP Transport，通过 SSH 客户端建立连接
 	// 参考 maancoffee 的实现：让 Transport 自动处理 TLS
 	var tlsConfig *tls.Config
 	if t.useTLS {
-		tlsConfig = &tls.Config{
-			ServerName:         t.tlsServerName,
-			InsecureSkipVerify: false, // 验证证书
+		tlsConfig = t.tlsConfig
+		if b != nil && b.serverName != "" && tlsConfig != nil {
+			tlsConfig = tlsConfig.Clone()
+			tlsConfig.ServerName = b.serverName
 		}
 	}
 
 	transport := &http.Transport{
 		DialContext: func(_ context.Context, network, addr string) (net.Conn, error) {
 			// 通过 SSH 客户端建立到远程服务的 TCP 连接
-			conn, err := t.sshClient.Dial("tcp", targetAddr)
+			conn, err := sshClient.Dial("tcp", targetAddr)
 			if err != nil {
-				return nil, fmt.Errorf("failed to dial remote service through SSH (target: %s): %v", targetAddr, err)
+				return nil, &backendDialError{addr: targetAddr, err: err}
 			}
 			return conn, nil
 		},
 		TLSClientConfig: tlsConfig, // 如果使用 TLS，让 Transport 自动处理
+		Protocols:       upstreamProtocols(t.protocol),
 	}
 
+	timeout := 30 * time.Second
+	if t.streaming {
+		timeout = 0
+	}
 	client := &http.Client{
 		Transport: transport,
-		Timeout:   30 * time.Second,
+		Timeout:   timeout,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return http.ErrUseLastResponse
 		},
@@ -480,7 +1028,7 @@ func (t *sshTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	// 执行请求
 	resp, err := client.Do(newReq)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %v", err)
+		return nil, fmt.Errorf("failed to execute request: %w", describeTLSError(err))
 	}
 
 	return resp, nil
@@ -491,6 +1039,8 @@ type responseWriter struct {
 	http.ResponseWriter
 	statusCode   int
 	responseSize int64
+	capture      *captureBuffer // 启用捕获时保存响应 body
+	proto        string         // 上游响应的协议
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
@@ -501,7 +1051,15 @@ func (rw *responseWriter) WriteHeader(code int) {
 func (rw *responseWriter) Write(b []byte) (int, error) {
 	n, err := rw.ResponseWriter.Write(b)
 	rw.responseSize += int64(n)
+	if rw.capture != nil {
+		rw.capture.Write(b[:n])
+	}
 	return n, err
 }
 
+// Unwrap 允许 http.ResponseController 访问底层的 ResponseWriter（Flush 等）
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
 // ============================================================
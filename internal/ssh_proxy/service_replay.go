@@ -0,0 +1,106 @@
+package ssh_proxy
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Service 请求重放
+// ------------------------------------------------------------
+
+// ReplayRequest 重放请求，URL 为客户端访问代理时的完整地址（与捕获的 URL 一致）
+type ReplayRequest struct {
+	ReplayOf      string // 被重放请求的 RequestID
+	Method        string
+	URL           string
+	Header        http.Header
+	Body          []byte
+	BodyTruncated bool // Body 来自被截断的捕获，只是原始 body 的开头，用户确认后清除才能重放
+}
+
+// NewReplayRequest 根据捕获的请求创建重放请求，脱敏的 header 不会被重放
+// 捕获的 body 被截断时设置 BodyTruncated，Replay 会拒绝发送
+func NewReplayRequest(requestID string, captured CapturedRequest) ReplayRequest {
+	header := http.Header{}
+	for name, values := range captured.Header {
+		for _, value := range values {
+			if value != redactedHeaderValue {
+				header.Add(name, value)
+			}
+		}
+	}
+	return ReplayRequest{
+		ReplayOf:      requestID,
+		Method:        captured.Method,
+		URL:           captured.URL,
+		Header:        header,
+		Body:          append([]byte(nil), captured.Body...),
+		BodyTruncated: captured.BodyTruncated,
+	}
+}
+
+type replayContextKey struct{}
+
+// replayOfFromContext 获取请求所重放的原始 RequestID
+func replayOfFromContext(ctx context.Context) string {
+	replayOf, _ := ctx.Value(replayContextKey{}).(string)
+	return replayOf
+}
+
+// Replay 通过同一个 ServiceProxy（相同的路由与 hop client）重新发送请求
+// 重放结果与普通请求一样通过日志 broker 发布，ReplayOf 字段指向原始请求
+func (sp *ServiceProxy) Replay(replay ReplayRequest) error {
+	sp.mu.RLock()
+	stopped := sp.stopped
+	sp.mu.RUnlock()
+	if stopped {
+		return fmt.Errorf("service proxy is stopped")
+	}
+	if replay.BodyTruncated {
+		return fmt.Errorf("captured body was truncated, replaying it would send an incomplete body")
+	}
+
+	target, err := url.Parse(replay.URL)
+	if err != nil {
+		return fmt.Errorf("invalid replay URL %q: %w", replay.URL, err)
+	}
+	if target.Host == "" {
+		return fmt.Errorf("replay URL must be absolute: %q", replay.URL)
+	}
+
+	ctx := context.WithValue(context.Background(), replayContextKey{}, replay.ReplayOf)
+	req, err := http.NewRequestWithContext(ctx, replay.Method, target.String(), bytes.NewReader(replay.Body))
+	if err != nil {
+		return fmt.Errorf("failed to build replay request: %w", err)
+	}
+	// 模拟服务端收到的请求：保留 Host 与 RequestURI，路由逻辑与真实请求一致
+	req.Header = replay.Header.Clone()
+	if req.Header == nil {
+		req.Header = http.Header{}
+	}
+	req.Host = target.Host
+	req.RequestURI = target.RequestURI()
+	req.RemoteAddr = "127.0.0.1:0"
+	req.URL = &url.URL{Path: target.Path, RawPath: target.RawPath, RawQuery: target.RawQuery}
+
+	sp.handleReverseProxyRequest(newDiscardResponseWriter(), req)
+	return nil
+}
+
+// discardResponseWriter 丢弃响应内容的 ResponseWriter，重放结果只通过日志展示
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func newDiscardResponseWriter() *discardResponseWriter {
+	return &discardResponseWriter{header: http.Header{}}
+}
+
+func (w *discardResponseWriter) Header() http.Header         { return w.header }
+func (w *discardResponseWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardResponseWriter) WriteHeader(int)             {}
+
+// ============================================================
@@ -0,0 +1,160 @@
+package ssh_proxy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Service 重试与熔断
+// ------------------------------------------------------------
+const (
+	defaultRetryBackoff            = 200 * time.Millisecond
+	defaultBreakerFailureThreshold = 5
+	defaultBreakerOpenDuration     = 15 * time.Second
+	unavailableRetryAfterSeconds   = "5"
+)
+
+// retryPolicy 编译后的重试策略，未配置时为 nil
+type retryPolicy struct {
+	attempts int           // 失败后的额外重试次数
+	backoff  time.Duration // 首次重试等待时间，之后指数增长
+}
+
+func buildRetryPolicy(settings *ServiceRetry) *retryPolicy {
+	if settings == nil || settings.Attempts == nil || *settings.Attempts <= 0 {
+		return nil
+	}
+	policy := &retryPolicy{attempts: *settings.Attempts, backoff: defaultRetryBackoff}
+	if settings.BackoffMs != nil && *settings.BackoffMs >= 0 {
+		policy.backoff = time.Duration(*settings.BackoffMs) * time.Millisecond
+	}
+	return policy
+}
+
+// canRetry 只有幂等且没有 body 的请求可以安全重试（body 在首次发送时已被读取）
+func (p *retryPolicy) canRetry(req *http.Request) bool {
+	if p == nil {
+		return false
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.ContentLength == 0
+}
+
+// wait 等待第 attempt 次重试的退避时间，请求被取消时返回 false
+func (p *retryPolicy) wait(ctx context.Context, attempt int) bool {
+	delay := p.backoff << (attempt - 1)
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// isRetryableError 只重试建立连接失败（上游拒绝连接、SSH 通道打开失败等），此时请求尚未发送
+// 超时不重试：上游无响应时重试只会让用户多等数倍的超时时间
+func isRetryableError(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return false
+	}
+	return isBackendDialError(err)
+}
+
+// circuitBreaker 连续失败达到阈值后打开，打开期间直接返回 503；到期后放行一个请求试探
+type circuitBreaker struct {
+	threshold    int
+	openDuration time.Duration
+	mu           sync.Mutex
+	failures     int
+	openUntil    time.Time
+	probing      bool
+}
+
+func buildCircuitBreaker(settings *ServiceCircuitBreaker) *circuitBreaker {
+	if settings == nil || (settings.Enabled != nil && !*settings.Enabled) {
+		return nil
+	}
+	breaker := &circuitBreaker{
+		threshold:    defaultBreakerFailureThreshold,
+		openDuration: defaultBreakerOpenDuration,
+	}
+	if settings.FailureThreshold != nil && *settings.FailureThreshold > 0 {
+		breaker.threshold = *settings.FailureThreshold
+	}
+	if settings.OpenSec != nil && *settings.OpenSec > 0 {
+		breaker.openDuration = time.Duration(*settings.OpenSec) * time.Second
+	}
+	return breaker
+}
+
+// buildCircuitBreakers 为配置了 circuit_breaker 的服务创建熔断器
+func buildCircuitBreakers(services []SSHService) map[*SSHService]*circuitBreaker {
+	breakers := make(map[*SSHService]*circuitBreaker)
+	for i := range services {
+		if breaker := buildCircuitBreaker(services[i].CircuitBreaker); breaker != nil {
+			breakers[&services[i]] = breaker
+		}
+	}
+	return breakers
+}
+
+// allow 判断请求是否可以通过，不允许时返回剩余的打开时间
+func (b *circuitBreaker) allow() (bool, time.Duration) {
+	if b == nil {
+		return true, 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openUntil.IsZero() {
+		return true, 0
+	}
+	if remaining := time.Until(b.openUntil); remaining > 0 {
+		return false, remaining
+	}
+	// 半开状态：只放行一个试探请求
+	if b.probing {
+		return false, 0
+	}
+	b.probing = true
+	return true, 0
+}
+
+// record 记录请求结果，failed 表示上游不可达（网关错误）
+func (b *circuitBreaker) record(failed bool) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		b.openUntil = time.Time{}
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = time.Now().Add(b.openDuration)
+	}
+}
+
+// ============================================================
@@ -0,0 +1,37 @@
+package ssh_proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "connection refused", err: &backendDialError{addr: "api:80", err: syscall.ECONNREFUSED}, want: true},
+		{name: "ssh channel open failure", err: &url.Error{Op: "Get", URL: "http://api", Err: &backendDialError{addr: "api:80", err: &ssh.OpenChannelError{Reason: ssh.ConnectionFailed, Message: "connect failed"}}}, want: true},
+		{name: "wrapped dial error", err: fmt.Errorf("failed to execute request: %w", &backendDialError{addr: "api:80", err: errors.New("ssh: disconnected")}), want: true},
+		{name: "client timeout", err: &url.Error{Op: "Get", URL: "http://api", Err: os.ErrDeadlineExceeded}},
+		{name: "dial timeout", err: &backendDialError{addr: "api:80", err: os.ErrDeadlineExceeded}},
+		{name: "deadline exceeded", err: fmt.Errorf("failed to execute request: %w", context.DeadlineExceeded)},
+		{name: "canceled", err: &url.Error{Op: "Get", URL: "http://api", Err: context.Canceled}},
+		{name: "error after the request was sent", err: errors.New("unexpected EOF")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableError(tt.err); got != tt.want {
+				t.Errorf("isRetryableError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package ssh_proxy
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Service 路径路由
+// ------------------------------------------------------------
+
+// serviceRoute 编译后的路径路由
+type serviceRoute struct {
+	service     *SSHService
+	prefix      string // 规范化后的前缀，不以 "/" 结尾，根路径为 ""
+	stripPrefix bool
+	priority    int
+}
+
+// routeMatch 路由匹配结果
+type routeMatch struct {
+	service        *SSHService
+	strippedPrefix string // 转发前被移除的前缀，未移除时为空
+	internal       bool   // 请求内置页面（/_messer/）
+}
+
+// buildServiceRoutes 根据 services 构建路径路由表
+// 显式的 routes 按配置生成，未配置 routes 的服务使用 "/<alias>" 作为默认路由
+func buildServiceRoutes(services []SSHService) []serviceRoute {
+	var routes []serviceRoute
+	for i := range services {
+		service := &services[i]
+
+		if len(service.Routes) == 0 {
+			if service.Alias != nil && *service.Alias != "" {
+				routes = append(routes, serviceRoute{
+					service:     service,
+					prefix:      normalizeRoutePrefix(*service.Alias),
+					stripPrefix: true,
+				})
+			}
+			continue
+		}
+
+		for _, route := range service.Routes {
+			if route.PathPrefix == nil {
+				continue
+			}
+			compiled := serviceRoute{
+				service:     service,
+				prefix:      normalizeRoutePrefix(*route.PathPrefix),
+				stripPrefix: true,
+			}
+			if route.StripPrefix != nil {
+				compiled.stripPrefix = *route.StripPrefix
+			}
+			if route.Priority != nil {
+				compiled.priority = *route.Priority
+			}
+			routes = append(routes, compiled)
+		}
+	}
+
+	// 优先级高的在前；优先级相同时前缀越长越优先
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].priority != routes[j].priority {
+			return routes[i].priority > routes[j].priority
+		}
+		return len(routes[i].prefix) > len(routes[j].prefix)
+	})
+
+	return routes
+}
+
+// findDefaultService 查找标记为 default 的服务
+func findDefaultService(services []SSHService) *SSHService {
+	for i := range services {
+		if services[i].Default != nil && *services[i].Default {
+			return &services[i]
+		}
+	}
+	return nil
+}
+
+// normalizeRoutePrefix 规范化路由前缀："api/" -> "/api"，"/" -> ""
+func normalizeRoutePrefix(prefix string) string {
+	prefix = strings.TrimSpace(prefix)
+	prefix = strings.TrimRight(prefix, "/")
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	return prefix
+}
+
+// matches 判断路径是否落在路由前缀下（按路径段匹配，/api 不匹配 /apix）
+func (r serviceRoute) matches(path string) bool {
+	if r.prefix == "" {
+		return true
+	}
+	return path == r.prefix || strings.HasPrefix(path, r.prefix+"/")
+}
+
+// matchPathRoute 按顺序查找第一个匹配的路由
+func matchPathRoute(routes []serviceRoute, path string) (serviceRoute, bool) {
+	for _, route := range routes {
+		if route.matches(path) {
+			return route, true
+		}
+	}
+	return serviceRoute{}, false
+}
+
+// stripRoutePrefix 从路径中移除路由前缀，结果总是以 "/" 开头
+func stripRoutePrefix(path, prefix string) string {
+	stripped := strings.TrimPrefix(path, prefix)
+	if !strings.HasPrefix(stripped, "/") {
+		stripped = "/" + stripped
+	}
+	return stripped
+}
+
+// ============================================================
+
+// 前缀下的响应改写
+// ------------------------------------------------------------
+
+// htmlAbsoluteLinkPattern 匹配 HTML 属性中的站内绝对链接（不含协议相对链接 //host）
+var htmlAbsoluteLinkPattern = regexp.MustCompile(`(\s(?:href|src|action|poster)\s*=\s*["'])(/[^"']*)`)
+
+// addRoutePrefix 为站内绝对路径加上前缀，已带前缀或协议相对的路径保持不变
+func addRoutePrefix(location, prefix string) string {
+	if prefix == "" || !strings.HasPrefix(location, "/") || strings.HasPrefix(location, "//") {
+		return location
+	}
+	if location == prefix || strings.HasPrefix(location, prefix+"/") {
+		return location
+	}
+	return prefix + location
+}
+
+// rewriteLocationHeader 将重定向中的站内绝对路径改写到前缀下
+func rewriteLocationHeader(resp *http.Response, prefix string) {
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return
+	}
+	resp.Header.Set("Location", addRoutePrefix(location, prefix))
+}
+
+// maxRewriteHTMLBytes 改写 HTML 链接时最多读入内存的字节数，更大的响应原样转发
+const maxRewriteHTMLBytes = 4 * 1024 * 1024
+
+// rewriteHTMLLinks 将未压缩 HTML 响应中的绝对链接改写到前缀下
+// 没有 body 的响应（HEAD、204、304）与超过 maxRewriteHTMLBytes 的响应不改写
+func rewriteHTMLLinks(resp *http.Response, prefix string) error {
+	if prefix == "" || !responseHasBody(resp) {
+		return nil
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") {
+		return nil
+	}
+	if encoding := resp.Header.Get("Content-Encoding"); encoding != "" && encoding != "identity" {
+		return nil
+	}
+	if resp.ContentLength > maxRewriteHTMLBytes {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxRewriteHTMLBytes+1))
+	if err != nil {
+		resp.Body.Close()
+		return err
+	}
+	if len(body) > maxRewriteHTMLBytes {
+		// 长度未知且超过上限：已读部分与剩余 body 拼接后原样转发
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		return nil
+	}
+	resp.Body.Close()
+
+	rewritten := htmlAbsoluteLinkPattern.ReplaceAllFunc(body, func(match []byte) []byte {
+		parts := htmlAbsoluteLinkPattern.FindSubmatch(match)
+		return append(append([]byte{}, parts[1]...), addRoutePrefix(string(parts[2]), prefix)...)
+	})
+
+	resp.Body = io.NopCloser(bytes.NewReader(rewritten))
+	resp.ContentLength = int64(len(rewritten))
+	resp.Header.Set("Content-Length", strconv.Itoa(len(rewritten)))
+	return nil
+}
+
+// responseHasBody 判断响应是否带有 body
+func responseHasBody(resp *http.Response) bool {
+	if resp.Body == nil || resp.Body == http.NoBody || resp.ContentLength == 0 {
+		return false
+	}
+	if resp.Request != nil && resp.Request.Method == http.MethodHead {
+		return false
+	}
+	return resp.StatusCode >= http.StatusOK && resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusNotModified
+}
+
+// ============================================================
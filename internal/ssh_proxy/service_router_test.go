@@ -0,0 +1,123 @@
+package ssh_proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResolveRoute(t *testing.T) {
+	services := []SSHService{
+		{Alias: strPtr("web"), Subdomain: strPtr("web")},
+		{Alias: strPtr("api"), Hosts: []string{"api.example.test"}, Routes: []ServiceRoute{
+			{PathPrefix: strPtr("/api")},
+			{PathPrefix: strPtr("/api/raw"), StripPrefix: boolPtr(false)},
+		}},
+		{Alias: strPtr("admin")},
+		{Alias: strPtr("fallback"), Default: boolPtr(true), Routes: []ServiceRoute{
+			{PathPrefix: strPtr("/"), Priority: intPtr(-1)},
+		}},
+	}
+	sp := NewServiceProxy("test", "8080", services, nil)
+
+	tests := []struct {
+		name     string
+		host     string
+		path     string
+		service  string // 期望的服务别名，空表示内置页面
+		stripped string
+		internal bool
+	}{
+		{name: "subdomain", host: "web.localhost:8080", path: "/api/users", service: "web"},
+		{name: "subdomain on lvh.me", host: "WEB.lvh.me", path: "/", service: "web"},
+		{name: "exact host", host: "api.example.test", path: "/", service: "api"},
+		{name: "path prefix is stripped", host: "localhost:8080", path: "/api/users", service: "api", stripped: "/api"},
+		{name: "prefix itself", host: "localhost:8080", path: "/api", service: "api", stripped: "/api"},
+		{name: "longer prefix wins, not stripped", host: "localhost:8080", path: "/api/raw/x", service: "api"},
+		{name: "prefix matches whole segments", host: "localhost:8080", path: "/apix", service: "fallback"},
+		{name: "alias as default route", host: "localhost:8080", path: "/admin/", service: "admin", stripped: "/admin"},
+		{name: "internal page", host: "localhost:8080", path: "/_messer/", internal: true},
+		{name: "host wins over internal page", host: "web.localhost", path: "/_messer/", service: "web"},
+		{name: "unknown host falls back to routes", host: "other.localhost", path: "/api/x", service: "api", stripped: "/api"},
+		{name: "default service", host: "127.0.0.1:8080", path: "/anything", service: "fallback"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "http://"+tt.host+tt.path, nil)
+			match, ok := sp.resolveRoute(r)
+			if !ok {
+				t.Fatalf("no route for %s%s", tt.host, tt.path)
+			}
+			if match.internal != tt.internal {
+				t.Fatalf("internal = %v, want %v", match.internal, tt.internal)
+			}
+			if tt.internal {
+				return
+			}
+			if got := ServiceDisplayName(match.service); got != tt.service {
+				t.Errorf("service = %q, want %q", got, tt.service)
+			}
+			if match.strippedPrefix != tt.stripped {
+				t.Errorf("strippedPrefix = %q, want %q", match.strippedPrefix, tt.stripped)
+			}
+		})
+	}
+}
+
+func TestResolveRouteWithoutDefault(t *testing.T) {
+	sp := NewServiceProxy("test", "8080", []SSHService{{Alias: strPtr("web"), Subdomain: strPtr("web")}}, nil)
+
+	r := httptest.NewRequest("GET", "http://localhost:8080/missing", nil)
+	if match, ok := sp.resolveRoute(r); ok {
+		t.Fatalf("expected no route, got %q", ServiceDisplayName(match.service))
+	}
+}
+
+func strPtr(value string) *string { return &value }
+func boolPtr(value bool) *bool    { return &value }
+func intPtr(value int) *int       { return &value }
+
+func TestRewriteHTMLLinks(t *testing.T) {
+	large := `<a href="/x">` + strings.Repeat("a", maxRewriteHTMLBytes)
+	tests := []struct {
+		name              string
+		method            string
+		status            int
+		body              string
+		contentLength     int64
+		want              string
+		wantContentLength string
+	}{
+		{name: "rewrites links", method: http.MethodGet, status: http.StatusOK, body: `<a href="/x">`, contentLength: -1, want: `<a href="/app/x">`, wantContentLength: "17"},
+		{name: "head has no body", method: http.MethodHead, status: http.StatusOK, contentLength: -1},
+		{name: "not modified has no body", method: http.MethodGet, status: http.StatusNotModified, contentLength: -1},
+		{name: "large body of unknown length is forwarded unchanged", method: http.MethodGet, status: http.StatusOK, body: large, contentLength: -1, want: large},
+		{name: "large declared body is forwarded unchanged", method: http.MethodGet, status: http.StatusOK, body: large, contentLength: int64(len(large)), want: large},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode:    tt.status,
+				Header:        http.Header{"Content-Type": {"text/html; charset=utf-8"}},
+				Body:          io.NopCloser(strings.NewReader(tt.body)),
+				ContentLength: tt.contentLength,
+				Request:       httptest.NewRequest(tt.method, "/app/", nil),
+			}
+			if err := rewriteHTMLLinks(resp, "/app"); err != nil {
+				t.Fatalf("rewriteHTMLLinks: %v", err)
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != tt.want {
+				t.Errorf("body = %.40q (%d bytes), want %.40q (%d bytes)", body, len(body), tt.want, len(tt.want))
+			}
+			if got := resp.Header.Get("Content-Length"); got != tt.wantContentLength {
+				t.Errorf("Content-Length = %q, want %q", got, tt.wantContentLength)
+			}
+		})
+	}
+}
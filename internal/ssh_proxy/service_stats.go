@@ -0,0 +1,112 @@
+package ssh_proxy
+
+import (
+	"sync"
+	"time"
+)
+
+// Service 请求统计
+// ------------------------------------------------------------
+const (
+	recentRequestsWindow = 5 * time.Minute // "最近请求数" 的统计窗口
+	maxRecentRequests    = 10000           // 窗口内最多保留的请求时间戳
+)
+
+// 服务健康状态
+const (
+	ServiceHealthUnknown   = "unknown"
+	ServiceHealthHealthy   = "healthy"
+	ServiceHealthUnhealthy = "unhealthy"
+)
+
+// serviceStats 单个服务的请求统计（被动，根据代理的请求结果更新）
+type serviceStats struct {
+	mu            sync.Mutex
+	total         int64
+	errors        int64
+	lastStatus    int
+	lastError     string
+	lastRequestAt time.Time
+	recent        []time.Time
+}
+
+// serviceStatsSnapshot 统计快照
+type serviceStatsSnapshot struct {
+	Total         int64
+	Errors        int64
+	Recent        int
+	LastStatus    int
+	LastError     string
+	LastRequestAt time.Time
+}
+
+// record 记录一次已完成的请求
+func (s *serviceStats) record(statusCode int, errorMessage string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.total++
+	if errorMessage != "" || statusCode >= 500 {
+		s.errors++
+	}
+	s.lastStatus = statusCode
+	s.lastError = errorMessage
+	s.lastRequestAt = at
+
+	s.recent = append(s.pruneLocked(at), at)
+	if len(s.recent) > maxRecentRequests {
+		s.recent = s.recent[len(s.recent)-maxRecentRequests:]
+	}
+}
+
+// pruneLocked 移除统计窗口外的时间戳
+func (s *serviceStats) pruneLocked(now time.Time) []time.Time {
+	cutoff := now.Add(-recentRequestsWindow)
+	i := 0
+	for i < len(s.recent) && s.recent[i].Before(cutoff) {
+		i++
+	}
+	return s.recent[i:]
+}
+
+func (s *serviceStats) snapshot(now time.Time) serviceStatsSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.recent = s.pruneLocked(now)
+	return serviceStatsSnapshot{
+		Total:         s.total,
+		Errors:        s.errors,
+		Recent:        len(s.recent),
+		LastStatus:    s.lastStatus,
+		LastError:     s.lastError,
+		LastRequestAt: s.lastRequestAt,
+	}
+}
+
+// passiveHealth 根据最近一次请求结果推断服务健康状态
+func (snap serviceStatsSnapshot) passiveHealth() string {
+	switch {
+	case snap.Total == 0:
+		return ServiceHealthUnknown
+	case snap.LastError != "" || snap.LastStatus >= 500:
+		return ServiceHealthUnhealthy
+	default:
+		return ServiceHealthHealthy
+	}
+}
+
+// statsFor 获取（必要时创建）服务的统计
+func (sp *ServiceProxy) statsFor(serviceName string) *serviceStats {
+	sp.statsMu.Lock()
+	defer sp.statsMu.Unlock()
+
+	stats, exists := sp.stats[serviceName]
+	if !exists {
+		stats = &serviceStats{}
+		sp.stats[serviceName] = stats
+	}
+	return stats
+}
+
+// ============================================================
@@ -0,0 +1,130 @@
+package ssh_proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"ssh-messer/pkg"
+)
+
+// Service 上游 TLS 配置
+// ------------------------------------------------------------
+
+// tlsVersions min_tls_version 支持的取值
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// upstreamTLS 编译后的上游 TLS 配置，err 不为空时该服务的请求直接失败
+type upstreamTLS struct {
+	config *tls.Config
+	err    error
+}
+
+// buildUpstreamTLS 为启用 TLS 的服务构建 tls.Config（加载 CA 与客户端证书）
+func buildUpstreamTLS(services []SSHService) map[*SSHService]*upstreamTLS {
+	configs := make(map[*SSHService]*upstreamTLS)
+	for i := range services {
+		service := &services[i]
+		if service.UseTLS == nil || !*service.UseTLS {
+			continue
+		}
+		config, err := buildServiceTLSConfig(service)
+		if err != nil {
+			err = fmt.Errorf("invalid TLS settings for service %s: %w", ServiceDisplayName(service), err)
+		}
+		configs[service] = &upstreamTLS{config: config, err: err}
+	}
+	return configs
+}
+
+func buildServiceTLSConfig(service *SSHService) (*tls.Config, error) {
+	serviceConfig := buildServiceConfig(service)
+	config := &tls.Config{
+		ServerName: serviceConfig.tlsServerName,
+	}
+
+	if service.InsecureSkipVerify != nil && *service.InsecureSkipVerify {
+		config.InsecureSkipVerify = true
+	}
+
+	if service.MinTLSVersion != nil && *service.MinTLSVersion != "" {
+		version, ok := tlsVersions[strings.TrimSpace(*service.MinTLSVersion)]
+		if !ok {
+			return nil, fmt.Errorf("unsupported min_tls_version %q (expected 1.0, 1.1, 1.2 or 1.3)", *service.MinTLSVersion)
+		}
+		config.MinVersion = version
+	}
+
+	if service.CAFile != nil && *service.CAFile != "" {
+		path, err := pkg.ExpandHome(*service.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca_file: %w", err)
+		}
+		// 在系统根证书基础上追加私有 CA
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in ca_file %s", path)
+		}
+		config.RootCAs = pool
+	}
+
+	hasCert := service.ClientCert != nil && *service.ClientCert != ""
+	hasKey := service.ClientKey != nil && *service.ClientKey != ""
+	if hasCert != hasKey {
+		return nil, fmt.Errorf("client_cert and client_key must be set together")
+	}
+	if hasCert {
+		certPath, err := pkg.ExpandHome(*service.ClientCert)
+		if err != nil {
+			return nil, err
+		}
+		keyPath, err := pkg.ExpandHome(*service.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		certificate, err := tls.LoadX509KeyPair(certPath, keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	return config, nil
+}
+
+// describeTLSError 为证书校验失败补充可读的原因与处理建议，其他错误原样返回
+func describeTLSError(err error) error {
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	var verificationErr *tls.CertificateVerificationError
+
+	switch {
+	case errors.As(err, &unknownAuthority):
+		return fmt.Errorf("TLS verification failed: certificate signed by unknown authority, set ca_file (or insecure_skip_verify for testing): %w", err)
+	case errors.As(err, &hostnameErr):
+		return fmt.Errorf("TLS verification failed: certificate is not valid for %q, check tls_server_name: %w", hostnameErr.Host, err)
+	case errors.As(err, &invalidErr):
+		return fmt.Errorf("TLS verification failed: invalid certificate: %w", err)
+	case errors.As(err, &verificationErr):
+		return fmt.Errorf("TLS verification failed: %w", err)
+	}
+	return err
+}
+
+// ============================================================
@@ -1,6 +1,7 @@
 package ssh_proxy
 
 import (
+	"sync"
 	"time"
 
 	"golang.org/x/crypto/ssh"
@@ -10,12 +11,12 @@ type SSHHopConfig struct {
 	Order          *int    `toml:"order"`
 	Host           *string `toml:"host"`
 	Port           *int    `toml:"port"`
-	AuthType       *string `toml:"authType"`
-	PrivateKeyPath *string `toml:"privateKeyPath"`
-	Passphrase     *string `toml:"passphrase"`
+	AuthType       *string `toml:"auth_type"`
+	PrivateKeyPath *string `toml:"private_key_path"`
+	Passphrase     *string `toml:"passphrase"` // 建议使用 "keyring:[service/]account" 引用，缺少时在 TUI 中提示输入
 	User           *string `toml:"user"`
 	Alias          *string `toml:"alias,omitempty"`
-	TimeoutSec     *int    `toml:"timeoutSec,omitempty"`
+	TimeoutSec     *int    `toml:"timeout_sec,omitempty"`
 }
 
 type ServicePage struct {
@@ -23,16 +24,143 @@ type ServicePage struct {
 	URL  *string `toml:"url"`
 }
 
+// ServiceRoute 服务的路径路由定义
+type ServiceRoute struct {
+	PathPrefix  *string `toml:"path_prefix"`
+	StripPrefix *bool   `toml:"strip_prefix,omitempty"` // 转发前是否移除前缀，默认 true
+	Priority    *int    `toml:"priority,omitempty"`     // 数值越大越优先匹配，默认 0
+}
+
 type SSHService struct {
-	Host          *string       `toml:"host"`
-	Port          *string       `toml:"port"`
-	Subdomain     *string       `toml:"subdomain"`
-	Alias         *string       `toml:"alias,omitempty"`
-	UseTLS        *bool         `toml:"use_tls,omitempty"`
-	TLSServerName *string       `toml:"tls_server_name,omitempty"`
-	RemoteHost    *string       `toml:"remote_host,omitempty"`
-	Pages         []ServicePage `toml:"pages,omitempty"`
-	HopOrder      *int          `toml:"hopOrder,omitempty"`
+	Host            *string        `toml:"host"`
+	Port            *string        `toml:"port"`
+	Subdomain       *string        `toml:"subdomain"`
+	Alias           *string        `toml:"alias,omitempty"`
+	UseTLS          *bool          `toml:"use_tls,omitempty"`
+	TLSServerName   *string        `toml:"tls_server_name,omitempty"`
+	RemoteHost      *string        `toml:"remote_host,omitempty"`
+	Pages           []ServicePage  `toml:"pages,omitempty"`
+	HopOrder        *int           `toml:"hop_order,omitempty"`
+	Hosts           []string       `toml:"hosts,omitempty"` // 额外匹配的 Host：精确、"*.dev.localhost" 通配或 "~" 开头的正则
+	Routes          []ServiceRoute `toml:"routes,omitempty"`
+	Default         *bool          `toml:"default,omitempty"`          // 未匹配任何路由的请求转发到该服务
+	RewriteHTML     *bool          `toml:"rewrite_html,omitempty"`     // 将 HTML 中的绝对链接改写到路由前缀下（超过 4MiB 的响应不改写）
+	ForwardedPrefix *bool          `toml:"forwarded_prefix,omitempty"` // 向上游发送 X-Forwarded-Prefix
+	// 转发到上游前设置的 header，值支持 "${ENV}"、"env:NAME"、"file:PATH"、"cmd:COMMAND" 与 "keyring:[service/]account" 引用
+	RequestHeaders map[string]string `toml:"request_headers,omitempty"`
+	RemoveHeaders  []string          `toml:"remove_headers,omitempty"` // 转发前移除的 header
+	BasicAuth      *ServiceBasicAuth `toml:"basic_auth,omitempty"`
+	// 上游 TLS 设置（仅 use_tls = true 时生效）
+	CAFile             *string `toml:"ca_file,omitempty"`              // 私有 CA 证书（PEM），追加到系统根证书
+	ClientCert         *string `toml:"client_cert,omitempty"`          // mTLS 客户端证书（PEM）
+	ClientKey          *string `toml:"client_key,omitempty"`           // mTLS 客户端私钥（PEM）
+	MinTLSVersion      *string `toml:"min_tls_version,omitempty"`      // "1.0" / "1.1" / "1.2" / "1.3"
+	InsecureSkipVerify *bool   `toml:"insecure_skip_verify,omitempty"` // 跳过证书校验，仅用于测试
+	// 多个上游目标，配置后替代 host/port
+	Targets []ServiceTarget `toml:"targets,omitempty"`
+	Balance *string         `toml:"balance,omitempty"` // "round_robin"（默认）或 "failover"
+	// 主动健康检查，未配置时只根据请求结果被动推断
+	HealthCheck *ServiceHealthCheck `toml:"health_check,omitempty"`
+	// 请求失败时的重试与熔断
+	Retry          *ServiceRetry          `toml:"retry,omitempty"`
+	CircuitBreaker *ServiceCircuitBreaker `toml:"circuit_breaker,omitempty"`
+	// 流量整形与故障注入，可在 TUI 中按 f 暂停/恢复
+	Faults *ServiceFaults `toml:"faults,omitempty"`
+	// 本地 mock 响应，命中时不经过 SSH 隧道
+	Mocks []ServiceMock `toml:"mocks,omitempty"`
+	// 上游协议："http1"（默认）、"h2"（TLS + ALPN）或 "h2c"（明文 HTTP/2，如 gRPC）
+	Protocol *string `toml:"protocol,omitempty"`
+	// 请求上游返回压缩内容（gzip，客户端支持时包括 br），客户端不支持 gzip 时在本地解压
+	Compression *bool `toml:"compression,omitempty"`
+	// 本地 HTTP 缓存，遵循 Cache-Control / ETag，可在 TUI 中按 p 清空
+	Cache *ServiceCache `toml:"cache,omitempty"`
+}
+
+// ServiceCache 服务的本地 HTTP 缓存（TOML 中的 [services.cache]）
+type ServiceCache struct {
+	Enabled      *bool `toml:"enabled,omitempty"`
+	MaxEntries   *int  `toml:"max_entries,omitempty"`    // 最多缓存的响应数，默认 200
+	MaxBodyBytes *int  `toml:"max_body_bytes,omitempty"` // 单个响应最大字节数，默认 1MiB
+}
+
+// ServiceMock 本地 mock 响应（TOML 中的 [[services.mocks]]），按配置顺序匹配
+type ServiceMock struct {
+	Method  *string           `toml:"method,omitempty"` // 为空或 "*" 时匹配任意方法
+	Path    *string           `toml:"path"`             // 去除路由前缀后的路径，支持 "*" 通配与 "/**" 子路径
+	Status  *int              `toml:"status,omitempty"` // 默认 200，范围 200-599
+	Headers map[string]string `toml:"headers,omitempty"`
+	Body    *string           `toml:"body,omitempty"`
+	File    *string           `toml:"file,omitempty"` // 从文件读取 body，每次请求时读取
+}
+
+// ServiceFaults 服务故障注入（TOML 中的 [services.faults]）
+type ServiceFaults struct {
+	Enabled       *bool    `toml:"enabled,omitempty"`        // 默认 true
+	LatencyMs     *int     `toml:"latency_ms,omitempty"`     // 转发前固定延迟
+	JitterMs      *int     `toml:"jitter_ms,omitempty"`      // 额外的随机延迟上限
+	BandwidthKbps *int     `toml:"bandwidth_kbps,omitempty"` // 响应带宽上限（kbit/s）
+	ErrorRate     *float64 `toml:"error_rate,omitempty"`     // 直接返回错误的请求百分比（0-100）
+	ErrorStatus   *int     `toml:"error_status,omitempty"`   // 注入错误的状态码，默认 503
+}
+
+// ServiceRetry 连接上游失败时的重试（超时不重试），仅对没有 body 的幂等请求生效
+type ServiceRetry struct {
+	Attempts  *int `toml:"attempts,omitempty"`   // 失败后的额外重试次数
+	BackoffMs *int `toml:"backoff_ms,omitempty"` // 首次重试等待时间，之后指数增长，默认 200
+}
+
+// ServiceCircuitBreaker 服务熔断器，连续失败达到阈值后一段时间内直接返回 503
+type ServiceCircuitBreaker struct {
+	Enabled          *bool `toml:"enabled,omitempty"`           // 默认 true
+	FailureThreshold *int  `toml:"failure_threshold,omitempty"` // 连续失败次数阈值，默认 5
+	OpenSec          *int  `toml:"open_sec,omitempty"`          // 熔断持续时间，默认 15 秒
+}
+
+// ServiceHealthCheck 服务健康检查（TOML 中的 [services.health_check]）
+type ServiceHealthCheck struct {
+	Path           *string `toml:"path,omitempty"`            // HTTP 探测路径，默认 "/"
+	ExpectedStatus *int    `toml:"expected_status,omitempty"` // 期望的状态码，默认 2xx/3xx 均视为健康
+	TCP            *bool   `toml:"tcp,omitempty"`             // 只检查 TCP 连接
+	IntervalSec    *int    `toml:"interval_sec,omitempty"`    // 探测间隔，默认 30 秒
+	TimeoutSec     *int    `toml:"timeout_sec,omitempty"`     // 单次探测超时，默认 5 秒
+}
+
+// ServiceTarget 服务的上游目标
+type ServiceTarget struct {
+	Host     *string `toml:"host"`
+	Port     *string `toml:"port"`
+	HopOrder *int    `toml:"hop_order,omitempty"` // 未指定时使用服务的 hop_order
+}
+
+// ServiceBasicAuth 转发到上游时附加的 Basic 认证，字段支持 "${ENV}"、"env:NAME"、"file:PATH"、"cmd:COMMAND" 与 "keyring:[service/]account" 引用
+type ServiceBasicAuth struct {
+	Username *string `toml:"username"`
+	Password *string `toml:"password"`
+}
+
+// ServiceProxySettings 本地服务代理的全局设置（TOML 中的 [proxy]）
+type ServiceProxySettings struct {
+	BaseDomains []string         `toml:"base_domains,omitempty"` // 额外的基础域名，默认已包含 localhost 与 lvh.me
+	Capture     *CaptureSettings `toml:"capture,omitempty"`
+	Access      *AccessSettings  `toml:"access,omitempty"`
+	// 本地监听启用 TLS（同时支持 h2），未配置时监听明文 HTTP/1.1 与 h2c
+	TLSCert *string `toml:"tls_cert,omitempty"`
+	TLSKey  *string `toml:"tls_key,omitempty"`
+}
+
+// AccessSettings 本地代理访问控制（TOML 中的 [proxy.access]）
+// allow_cidrs 为必要条件；token 与 basic_auth 同时配置时任一通过即可
+type AccessSettings struct {
+	Token      *string           `toml:"token,omitempty"`       // 通过 X-Messer-Token header、messer_token cookie 或 ?messer_token= 提供，支持与 basic_auth 相同的引用
+	BasicAuth  *ServiceBasicAuth `toml:"basic_auth,omitempty"`  // 本地 Basic 认证，字段支持的引用见 ServiceBasicAuth
+	AllowCIDRs []string          `toml:"allow_cidrs,omitempty"` // 允许访问的客户端地址段，如 "127.0.0.1/32"
+}
+
+// CaptureSettings 请求/响应捕获设置（TOML 中的 [proxy.capture]）
+type CaptureSettings struct {
+	Enabled       *bool    `toml:"enabled,omitempty"`
+	MaxBodyBytes  *int     `toml:"max_body_bytes,omitempty"` // 每个 body 最多捕获的字节数，默认 65536
+	RedactHeaders []string `toml:"redact_headers,omitempty"` // 额外脱敏的 header，默认已包含 Authorization、Cookie 等
 }
 
 type SSHHopsProxy struct {
@@ -40,12 +168,26 @@ type SSHHopsProxy struct {
 	hopsConfigs         []SSHHopConfig
 	client              *ssh.Client
 	hopClients          map[int]*ssh.Client // 存储不同 hopOrder 对应的 SSH client
-	Status              SSHProxyStatus
+	hopClientsMu        sync.RWMutex        // 保护 client 与 hopClients：请求处理中读取，替换时同时持有 mu
+	Status              SSHProxyStatus      // 由 statusMu 保护，其他 goroutine 通过 GetStatus 读取
 	serviceProxy        *ServiceProxy
+	proxySettings       *ServiceProxySettings
+	faultsPaused        bool // 故障注入是否被暂停，重建 services 代理时保留
 	healthStop          chan struct{}
+	reconnectMu         sync.Mutex
+	reconnectStop       chan struct{} // 非空表示正在重连（含退避等待），Disconnect 时关闭以停止重试
 	services            []SSHService
 	localPort           string
 	healthCheckInterval time.Duration
+
+	// mu 保护 services、localPort、proxySettings 与 client 的替换，
+	// 使连接建立后的收尾、重连前的清理与热更新（均在 tea.Cmd 的 goroutine 中执行）互斥；
+	// 持有期间可能建立 SSH 连接，TUI 读取的 hopsConfigs 使用单独的 hopsMu
+	mu          sync.Mutex
+	hopsMu      sync.RWMutex
+	hopsChanged bool // 连接开始后 hop 配置又发生了变化，连接完成后需要使用新配置重连
+	connectGen  int  // 每次 Disconnect 递增，连接完成时与开始时不一致则丢弃新连接，由 mu 保护
+	statusMu    sync.Mutex
 }
 
 type SSHProxyStatus struct {
@@ -62,6 +204,8 @@ type SSHProxyStatus struct {
 
 // UpdateSSHProxyStatus 更新 SSH 代理状态
 func (s *SSHHopsProxy) UpdateSSHProxyStatus(status SSHProxyStatus) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
 	s.Status = status
 }
 
@@ -75,7 +219,42 @@ type ServiceProxyLogEvent struct {
 	StatusCode   int // 0 表示请求中，>0 表示响应状态码
 	ResponseSize int64
 	Timestamp    time.Time
-	IsUpdate     bool          // true 表示这是更新事件，false 表示新请求
-	ErrorMessage string        // 错误消息（如果有）
-	Duration     time.Duration // 请求用时
+	IsUpdate     bool              // true 表示这是更新事件，false 表示新请求
+	ErrorMessage string            // 错误消息（如果有）
+	Duration     time.Duration     // 请求用时
+	Capture      *CapturedExchange // 捕获的请求/响应（仅在启用捕获时的更新事件中存在）
+	ReplayOf     string            // 重放请求对应的原始 RequestID，普通请求为空
+	Backend      string            // 实际转发的上游目标（host:port）
+	Faults       string            // 本次请求注入的故障描述，未注入时为空
+	Mock         bool              // 由本地 mock 响应，未经过 SSH 隧道
+	Protocol     string            // 上游响应的协议，如 "HTTP/2.0"
+	CacheStatus  string            // 本地缓存状态：HIT / REVALIDATED / MISS，未启用缓存时为空
+	GRPCStatus   string            // gRPC 状态码（grpc-status），非 gRPC 请求为空
+	GRPCMessage  string            // gRPC 错误消息（grpc-message）
+}
+
+// ServiceSummary 服务概览（用于本地索引页与 /_messer/services.json）
+type ServiceSummary struct {
+	Name           string            `json:"name"`
+	Subdomain      string            `json:"subdomain,omitempty"`
+	Alias          string            `json:"alias,omitempty"`
+	URLs           []string          `json:"urls"`
+	HostPatterns   []string          `json:"host_patterns,omitempty"` // 通配与正则 Host 规则
+	Paths          []string          `json:"paths,omitempty"`
+	Hop            string            `json:"hop"`
+	Remote         string            `json:"remote"`
+	Health         string            `json:"health"` // unknown / healthy / unhealthy
+	TotalRequests  int64             `json:"total_requests"`
+	RecentRequests int               `json:"recent_requests"` // 最近 5 分钟的请求数
+	ErrorRequests  int64             `json:"error_requests"`
+	LastStatus     int               `json:"last_status,omitempty"`
+	LastError      string            `json:"last_error,omitempty"`
+	LastRequestAt  *time.Time        `json:"last_request_at,omitempty"`
+	Pages          []ServicePageLink `json:"pages,omitempty"`
+}
+
+// ServicePageLink 服务页面链接
+type ServicePageLink struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
 }
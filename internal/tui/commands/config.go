@@ -1,8 +1,14 @@
 package commands
 
 import (
+	"fmt"
+	"os"
+
 	"ssh-messer/internal/config_loader"
+	"ssh-messer/internal/ssh_proxy"
 	"ssh-messer/internal/tui/messages"
+	"ssh-messer/internal/tui/types"
+	"ssh-messer/pkg"
 
 	tea "github.com/charmbracelet/bubbletea/v2"
 )
@@ -10,7 +16,7 @@ import (
 // LoadAllConfigs 加载所有配置（系统配置 + TOML 配置）
 func LoadAllConfigs() tea.Cmd {
 	return func() tea.Msg {
-		configs, err := config_loader.LoadTomlConfigsFromHomeDir()
+		configs, err := config_loader.LoadTomlConfigs()
 		if err != nil {
 			return messages.AppErrMsg{
 				Error:   err,
@@ -24,3 +30,109 @@ func LoadAllConfigs() tea.Cmd {
 		}
 	}
 }
+
+// ApplyServiceChanges 将重新加载的 services 配置热更新到正在运行的代理
+func ApplyServiceChanges(appState *types.AppState, configName string) tea.Cmd {
+	return func() tea.Msg {
+		config := appState.GetConfig(configName)
+		proxy := appState.GetSSHProxy(configName)
+		if config == nil || proxy == nil {
+			return messages.ConfigAppliedMsg{ConfigName: configName, Err: fmt.Errorf("SSH proxy is not initialized")}
+		}
+		// 只解析 services，不重新执行 hop 的 cmd: 引用或读取钥匙串
+		config, err := config.ResolveServices()
+		if request, ok := secretRequired(configName, err); ok {
+			return request
+		}
+		if err != nil {
+			return messages.ConfigAppliedMsg{ConfigName: configName, Err: err}
+		}
+
+		localPort := ""
+		if config.LocalHttpPort != nil {
+			localPort = *config.LocalHttpPort
+		}
+		if err := proxy.ApplyServiceConfig(config.SSHServices, localPort, config.Proxy); err != nil {
+			pkg.Logger.Error().Err(err).Str("configName", configName).Msg("[ApplyServiceChanges] 热更新 services 失败")
+			return messages.ConfigAppliedMsg{ConfigName: configName, Err: err}
+		}
+		return messages.ConfigAppliedMsg{ConfigName: configName}
+	}
+}
+
+// ReconnectWithHops 使用重新加载的 hop 配置重新连接
+func ReconnectWithHops(appState *types.AppState, configName string) tea.Cmd {
+	return func() tea.Msg {
+		config := appState.GetConfig(configName)
+		proxy := appState.GetSSHProxy(configName)
+		if config == nil || proxy == nil {
+			return messages.AppErrMsg{Error: fmt.Errorf("SSH proxy is not initialized"), IsFatal: false}
+		}
+		config, err := config.ResolveHops()
+		if err != nil {
+			return messages.ConfigAppliedMsg{ConfigName: configName, Err: err}
+		}
+
+		go proxy.ReconnectWithHops(config.SSHHops)
+		return nil
+	}
+}
+
+// SaveConfig 保存配置编辑页面中的配置
+func SaveConfig(edit *config_loader.ConfigEdit) tea.Cmd {
+	return func() tea.Msg {
+		if err := edit.Save(); err != nil {
+			pkg.Logger.Error().Err(err).Str("file", edit.Path).Msg("[SaveConfig] 保存配置失败")
+			return messages.ConfigSavedMsg{Path: edit.Path, Err: err}
+		}
+		return messages.ConfigSavedMsg{Path: edit.Path}
+	}
+}
+
+// TestConnection 使用配置编辑页面中的 hop 测试连接，不启动 services
+func TestConnection(config *config_loader.TomlConfig) tea.Cmd {
+	return func() tea.Msg {
+		resolved, err := config.ResolveHops()
+		if err != nil {
+			return messages.ConnectionTestedMsg{Err: err}
+		}
+		return messages.ConnectionTestedMsg{
+			Hops: len(resolved.SSHHops),
+			Err:  ssh_proxy.TestHops(resolved.SSHHops),
+		}
+	}
+}
+
+// DecryptBundle 读取并解密加密包，在临时目录中校验后返回安装预览
+func DecryptBundle(path string, passphrase string) tea.Cmd {
+	return func() tea.Msg {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return messages.BundleDecryptedMsg{Err: err}
+		}
+		bundle, err := config_loader.DecryptBundle(data, []byte(passphrase))
+		if err != nil {
+			return messages.BundleDecryptedMsg{Err: err}
+		}
+		dir, err := config_loader.PrimaryConfigDir()
+		if err != nil {
+			return messages.BundleDecryptedMsg{Err: err}
+		}
+		plan, err := bundle.Plan(dir)
+		if err != nil {
+			pkg.Logger.Error().Err(err).Str("file", path).Msg("[DecryptBundle] 校验加密包失败")
+			return messages.BundleDecryptedMsg{Err: err}
+		}
+		return messages.BundleDecryptedMsg{Plan: plan}
+	}
+}
+
+// InstallBundle 将确认后的加密包写入配置目录
+func InstallBundle(plan *config_loader.ImportPlan, overwrite, allowReferences bool) tea.Cmd {
+	return func() tea.Msg {
+		if err := plan.Install(overwrite, allowReferences); err != nil {
+			return messages.BundleInstalledMsg{Dir: plan.Dir, Err: err}
+		}
+		return messages.BundleInstalledMsg{Count: len(plan.Items), Dir: plan.Dir}
+	}
+}
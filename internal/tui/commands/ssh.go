@@ -1,8 +1,12 @@
 package commands
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
+	"ssh-messer/internal/config_loader"
+	"ssh-messer/internal/secrets"
 	"ssh-messer/internal/ssh_proxy"
 	"ssh-messer/internal/tui/messages"
 	"ssh-messer/internal/tui/types"
@@ -27,6 +31,18 @@ func InitSSHProxy(appState *types.AppState, configName string) tea.Cmd {
 			return nil
 		}
 
+		// 连接时才解析 "${NAME}"、"file:"、"cmd:" 等引用，AppState 中保留未解析的配置
+		config, err := config.Resolve()
+		if request, ok := secretRequired(configName, err); ok {
+			return request
+		}
+		if err != nil {
+			return messages.AppErrMsg{
+				Error:   err,
+				IsFatal: false,
+			}
+		}
+
 		// 从配置读取健康检查间隔，如果未配置则使用默认值 30 秒
 		healthCheckInterval := 30 * time.Second
 		if config.HealthCheckIntervalSecs != nil {
@@ -53,9 +69,92 @@ func InitSSHProxy(appState *types.AppState, configName string) tea.Cmd {
 		}
 
 		sshProxy := ssh_proxy.NewSSHHopsProxy(configName, config.SSHHops, healthCheckInterval, services, localPort)
+		sshProxy.SetProxySettings(config.Proxy)
 		appState.SetSSHProxy(configName, sshProxy)
 
 		go sshProxy.Connect()
 		return nil
 	}
 }
+
+// ReplayRequest 通过当前配置的 SSH 代理重放请求
+func ReplayRequest(appState *types.AppState, replay ssh_proxy.ReplayRequest) tea.Cmd {
+	return func() tea.Msg {
+		proxy := appState.GetSSHProxy(appState.CurrentConfigName)
+		if proxy == nil {
+			return messages.ReplayFailedMsg{Err: fmt.Errorf("SSH proxy is not initialized")}
+		}
+
+		if err := proxy.Replay(replay); err != nil {
+			pkg.Logger.Error().Err(err).Str("configName", appState.CurrentConfigName).Msg("[ReplayRequest] 重放请求失败")
+			return messages.ReplayFailedMsg{Err: err}
+		}
+		return nil
+	}
+}
+
+// ToggleFaults 暂停或恢复当前配置的故障注入
+func ToggleFaults(appState *types.AppState) tea.Cmd {
+	return func() tea.Msg {
+		proxy := appState.GetSSHProxy(appState.CurrentConfigName)
+		if proxy == nil {
+			return messages.FaultsToggledMsg{Err: fmt.Errorf("SSH proxy is not initialized")}
+		}
+
+		active, err := proxy.ToggleFaults()
+		return messages.FaultsToggledMsg{Active: active, Err: err}
+	}
+}
+
+// PurgeCache 清空当前配置的本地响应缓存
+func PurgeCache(appState *types.AppState) tea.Cmd {
+	return func() tea.Msg {
+		proxy := appState.GetSSHProxy(appState.CurrentConfigName)
+		if proxy == nil {
+			return messages.CachePurgedMsg{Err: fmt.Errorf("SSH proxy is not initialized")}
+		}
+
+		count, err := proxy.PurgeCache()
+		return messages.CachePurgedMsg{Count: count, Err: err}
+	}
+}
+
+// secretRequired 解析引用时钥匙串中缺少密钥，返回提示用户输入的消息
+func secretRequired(configName string, err error) (messages.SecretRequiredMsg, bool) {
+	var missing *secrets.MissingSecretError
+	if !errors.As(err, &missing) {
+		return messages.SecretRequiredMsg{}, false
+	}
+	var fieldErr *config_loader.FieldError
+	field := "config"
+	if errors.As(err, &fieldErr) {
+		field = fieldErr.Field
+	}
+	return messages.SecretRequiredMsg{
+		ConfigName: configName,
+		Field:      field,
+		Service:    missing.Service,
+		Account:    missing.Account,
+		Backend:    missing.Backend,
+	}, true
+}
+
+// ProvideSecret 在本次运行中记住用户输入的密钥，save 为 true 时同时保存到钥匙串
+func ProvideSecret(msg messages.SecretProvidedMsg) tea.Cmd {
+	return func() tea.Msg {
+		secrets.RememberSessionSecret(msg.Service, msg.Account, msg.Secret)
+
+		stored := messages.SecretStoredMsg{ConfigName: msg.ConfigName, Service: msg.Service, Account: msg.Account}
+		if msg.Save {
+			keyring := secrets.GetKeyring()
+			if err := keyring.Set(msg.Service, msg.Account, msg.Secret); err != nil {
+				pkg.Logger.Error().Err(err).Str("service", msg.Service).Str("account", msg.Account).Msg("[ProvideSecret] 保存密钥到钥匙串失败")
+				stored.Err = err
+			} else {
+				pkg.Logger.Info().Str("service", msg.Service).Str("account", msg.Account).Str("backend", keyring.Name()).Msg("[ProvideSecret] 密钥已保存到钥匙串")
+				stored.Backend = keyring.Name()
+			}
+		}
+		return stored
+	}
+}
@@ -0,0 +1,275 @@
+package bundle_import
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"ssh-messer/internal/config_loader"
+	"ssh-messer/internal/tui/commands"
+	"ssh-messer/internal/tui/components/core/layout"
+	"ssh-messer/internal/tui/messages"
+	"ssh-messer/internal/tui/styles"
+	"ssh-messer/internal/tui/util"
+	"ssh-messer/pkg"
+
+	"github.com/charmbracelet/bubbles/v2/textinput"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// 导入步骤
+const (
+	stepPath = iota
+	stepPassphrase
+	stepDecrypting
+	stepPreview
+	stepInstalling
+)
+
+var (
+	titleStyle = lipgloss.NewStyle().Foreground(styles.NeonCyan).Bold(true)
+	labelStyle = lipgloss.NewStyle().Foreground(styles.Meta)
+	valueStyle = lipgloss.NewStyle().Foreground(styles.Text)
+	warnStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#F59E0B"))
+	errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#EF4444"))
+)
+
+// BundleImportCmp 加密包导入组件接口
+type BundleImportCmp interface {
+	util.Model
+	layout.Sizeable
+	Open() tea.Cmd
+}
+
+// bundleImportCmp 加密包导入组件实现：输入文件路径与口令，预览校验结果后安装
+type bundleImportCmp struct {
+	width, height int
+	step          int
+	path          textinput.Model
+	passphrase    textinput.Model
+	plan          *config_loader.ImportPlan
+	trusted       bool // 用户已确认包中的 cmd: / file: 引用
+	err           error
+}
+
+// New 创建加密包导入组件
+func New() BundleImportCmp {
+	path := textinput.New()
+	path.Prompt = ""
+	path.Placeholder = "~/Downloads/team" + config_loader.BundleExtension
+
+	passphrase := textinput.New()
+	passphrase.Prompt = ""
+	passphrase.EchoMode = textinput.EchoPassword
+	passphrase.EchoCharacter = '•'
+
+	return &bundleImportCmp{path: path, passphrase: passphrase}
+}
+
+func (b *bundleImportCmp) Init() tea.Cmd {
+	return nil
+}
+
+// Open 从输入文件路径开始
+func (b *bundleImportCmp) Open() tea.Cmd {
+	b.step = stepPath
+	b.plan = nil
+	b.trusted = false
+	b.err = nil
+	b.path.SetValue("")
+	b.passphrase.SetValue("")
+	b.passphrase.Blur()
+	return b.path.Focus()
+}
+
+func (b *bundleImportCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case messages.BundleDecryptedMsg:
+		if msg.Err != nil {
+			b.err = msg.Err
+			b.step = stepPassphrase
+			b.passphrase.SetValue("")
+			return b, b.passphrase.Focus()
+		}
+		b.err = nil
+		b.plan = msg.Plan
+		b.trusted = false
+		b.step = stepPreview
+		return b, nil
+
+	case messages.BundleInstalledMsg:
+		if msg.Err != nil {
+			b.err = msg.Err
+			b.step = stepPreview
+		}
+		return b, nil
+
+	case tea.KeyPressMsg:
+		return b, b.handleKey(msg)
+	}
+
+	var cmd tea.Cmd
+	switch b.step {
+	case stepPath:
+		b.path, cmd = b.path.Update(msg)
+	case stepPassphrase:
+		b.passphrase, cmd = b.passphrase.Update(msg)
+	}
+	return b, cmd
+}
+
+func (b *bundleImportCmp) handleKey(msg tea.KeyPressMsg) tea.Cmd {
+	if msg.String() == "esc" {
+		b.passphrase.SetValue("")
+		return util.CmdHandler(messages.CloseBundleImportMsg{})
+	}
+
+	var cmd tea.Cmd
+	switch b.step {
+	case stepPath:
+		if msg.String() != "enter" {
+			b.path, cmd = b.path.Update(msg)
+			return cmd
+		}
+		path, err := pkg.ExpandHome(strings.TrimSpace(b.path.Value()))
+		if err != nil {
+			b.err = err
+			return nil
+		}
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			b.err = fmt.Errorf("%s is not a readable file", b.path.Value())
+			return nil
+		}
+		b.err = nil
+		b.step = stepPassphrase
+		b.path.Blur()
+		return b.passphrase.Focus()
+
+	case stepPassphrase:
+		if msg.String() != "enter" {
+			b.passphrase, cmd = b.passphrase.Update(msg)
+			return cmd
+		}
+		if b.passphrase.Value() == "" {
+			b.err = fmt.Errorf("passphrase must not be empty")
+			return nil
+		}
+		path, err := pkg.ExpandHome(strings.TrimSpace(b.path.Value()))
+		if err != nil {
+			b.err = err
+			return nil
+		}
+		passphrase := b.passphrase.Value()
+		// 提交后立即清空，避免口令留在组件中
+		b.passphrase.SetValue("")
+		b.err = nil
+		b.step = stepDecrypting
+		return commands.DecryptBundle(path, passphrase)
+
+	case stepPreview:
+		switch msg.String() {
+		case "y":
+			if b.plan.References() > 0 {
+				b.trusted = !b.trusted
+				b.err = nil
+			}
+		case "enter", "ctrl+o":
+			overwrite := msg.String() == "ctrl+o"
+			if conflicts := b.plan.Conflicts(); len(conflicts) > 0 && !overwrite {
+				b.err = fmt.Errorf("%d file(s) already exist, press ctrl+o to overwrite them", len(conflicts))
+				return nil
+			}
+			if b.plan.References() > 0 && !b.trusted {
+				b.err = fmt.Errorf("review the cmd:/file: references and press y to trust them first")
+				return nil
+			}
+			b.step = stepInstalling
+			return commands.InstallBundle(b.plan, overwrite, b.trusted)
+		}
+	}
+	return nil
+}
+
+func (b *bundleImportCmp) View() string {
+	parts := []string{titleStyle.Render("📦 Import config bundle"), ""}
+
+	switch b.step {
+	case stepPath, stepPassphrase:
+		parts = append(parts,
+			labelStyle.Render("Bundle file"),
+			"  "+b.path.View(),
+		)
+		if b.step == stepPassphrase {
+			parts = append(parts,
+				labelStyle.Render("Passphrase"),
+				"  "+b.passphrase.View(),
+			)
+		}
+		parts = append(parts, "")
+		if b.err != nil {
+			parts = append(parts, errorStyle.Render("Error: "+b.err.Error()))
+		}
+		parts = append(parts, labelStyle.Render("enter continue · esc cancel"))
+
+	case stepDecrypting:
+		parts = append(parts, labelStyle.Render("Decrypting and validating…"))
+
+	case stepPreview, stepInstalling:
+		parts = append(parts, labelStyle.Render("Install into "+b.plan.Dir+":"))
+		invalid := 0
+		for _, item := range b.plan.Items {
+			status := "new"
+			if item.Exists {
+				status = "replaces existing file"
+			}
+			if item.Dependency {
+				status += ", shared by the configs"
+			}
+			parts = append(parts, "  "+valueStyle.Render(item.Name)+labelStyle.Render(" ("+status+")"))
+			if len(item.Errors) > 0 {
+				invalid++
+				problem := fmt.Sprintf("    ⚠️  %d problem(s): %v", len(item.Errors), item.Errors[0])
+				parts = append(parts, warnStyle.Render(util.TruncateString(problem, max(b.width, 10))))
+			}
+			for _, reference := range item.References {
+				parts = append(parts, errorStyle.Render(util.TruncateString("    ❗ "+reference, max(b.width, 10))))
+			}
+		}
+		parts = append(parts, "")
+		if invalid > 0 {
+			parts = append(parts, warnStyle.Render("Configs with problems can be installed and fixed with e in the config list."))
+		}
+		if count := b.plan.References(); count > 0 {
+			if b.trusted {
+				parts = append(parts, warnStyle.Render(fmt.Sprintf("✓ %d cmd:/file: reference(s) trusted (y to undo)", count)))
+			} else {
+				parts = append(parts, errorStyle.Render(fmt.Sprintf("%d cmd:/file: reference(s) run commands or read files when you connect. Press y to trust them.", count)))
+			}
+		}
+		switch {
+		case b.step == stepInstalling:
+			parts = append(parts, labelStyle.Render("Installing…"))
+		case b.err != nil:
+			parts = append(parts, errorStyle.Render("Error: "+b.err.Error()))
+		}
+		parts = append(parts, labelStyle.Render("enter install · ctrl+o install and overwrite · esc cancel"))
+	}
+
+	return lipgloss.NewStyle().
+		Width(b.width).
+		Height(b.height).
+		Render(strings.Join(parts, "\n"))
+}
+
+func (b *bundleImportCmp) SetSize(width, height int) tea.Cmd {
+	b.width = width
+	b.height = height
+	b.path.SetWidth(max(width-4, 10))
+	b.passphrase.SetWidth(max(width-4, 10))
+	return nil
+}
+
+func (b *bundleImportCmp) GetSize() (int, int) {
+	return b.width, b.height
+}
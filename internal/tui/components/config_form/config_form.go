@@ -0,0 +1,558 @@
+package config_form
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"ssh-messer/internal/config_loader"
+	"ssh-messer/internal/secrets"
+	"ssh-messer/internal/tui/commands"
+	"ssh-messer/internal/tui/components/core/layout"
+	"ssh-messer/internal/tui/messages"
+	"ssh-messer/internal/tui/styles"
+	"ssh-messer/internal/tui/util"
+
+	"github.com/charmbracelet/bubbles/v2/textinput"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// 行类型
+type rowKind int
+
+const (
+	rowHeading rowKind = iota // 分组标题，不可聚焦
+	rowInput                  // 文本输入
+	rowChoice                 // 在固定选项间切换
+	rowToggle                 // 布尔开关
+	rowAction                 // 添加 hop / service
+)
+
+// 表单底部展示的最大错误行数
+const maxErrorLines = 4
+
+// 标签列宽度
+const labelWidth = 16
+
+var authTypes = []string{"privateKey", "privateKeyWithPassphrase", "password"}
+
+var (
+	titleStyle   = lipgloss.NewStyle().Foreground(styles.NeonCyan).Bold(true)
+	headingStyle = lipgloss.NewStyle().Foreground(styles.NeonPurple).Bold(true)
+	labelStyle   = lipgloss.NewStyle().Foreground(styles.Meta)
+	focusStyle   = lipgloss.NewStyle().Foreground(styles.Primary).Bold(true)
+	valueStyle   = lipgloss.NewStyle().Foreground(styles.Text)
+	okStyle      = lipgloss.NewStyle().Foreground(styles.NeonGreen)
+	errorStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#EF4444"))
+)
+
+// formRow 表单中的一行，值通过 get / set 读写 ConfigEdit
+type formRow struct {
+	kind        rowKind
+	label       string
+	field       string // 对应的校验字段，如 "ssh_hops[0].host"
+	placeholder string
+	secret      bool
+	options     []string
+	get         func() string
+	set         func(string)
+	action      func()
+	remove      func() // ctrl+d 删除所在的 hop / service
+}
+
+// ConfigFormCmp 配置编辑表单组件接口
+type ConfigFormCmp interface {
+	util.Model
+	layout.Sizeable
+	Open(edit *config_loader.ConfigEdit) tea.Cmd
+}
+
+// configFormCmp 配置编辑表单组件实现
+type configFormCmp struct {
+	width, height int
+	edit          *config_loader.ConfigEdit
+	fileName      string
+	rows          []formRow
+	focus         int
+	offset        int
+	input         textinput.Model
+
+	built   *config_loader.TomlConfig
+	errs    config_loader.ValidationErrors
+	dirty   bool
+	confirm bool // 有未保存的修改时，第一次 esc 只提示
+	saving  bool
+	testing bool
+	status  string
+	err     error
+}
+
+// New 创建配置编辑表单
+func New() ConfigFormCmp {
+	input := textinput.New()
+	input.Prompt = ""
+	input.EchoCharacter = '•'
+	return &configFormCmp{input: input}
+}
+
+func (f *configFormCmp) Init() tea.Cmd {
+	return nil
+}
+
+// Open 使用配置填充表单
+func (f *configFormCmp) Open(edit *config_loader.ConfigEdit) tea.Cmd {
+	f.edit = edit
+	f.fileName = ""
+	f.focus = 0
+	f.offset = 0
+	f.dirty = false
+	f.confirm = false
+	f.saving = false
+	f.testing = false
+	f.status = ""
+	f.err = nil
+	f.rebuild()
+	return f.setFocus(f.nextFocusable(-1, 1))
+}
+
+func (f *configFormCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case messages.ConfigSavedMsg:
+		f.saving = false
+		if msg.Err != nil {
+			f.err = msg.Err
+		}
+		return f, nil
+
+	case messages.ConnectionTestedMsg:
+		f.testing = false
+		if msg.Err != nil {
+			f.status = ""
+			f.err = fmt.Errorf("connection failed: %w", msg.Err)
+		} else {
+			f.err = nil
+			f.status = fmt.Sprintf("✓ Connected through %d hop(s)", msg.Hops)
+		}
+		return f, nil
+
+	case tea.KeyMsg:
+		if f.edit == nil || f.saving {
+			return f, nil
+		}
+		return f, f.handleKey(msg)
+	}
+
+	var cmd tea.Cmd
+	f.input, cmd = f.input.Update(msg)
+	return f, cmd
+}
+
+// handleKey 处理按键，表单级快捷键优先，其余交给获得焦点的行
+func (f *configFormCmp) handleKey(msg tea.KeyMsg) tea.Cmd {
+	key := msg.String()
+	if key != "esc" {
+		f.confirm = false
+	}
+
+	switch key {
+	case "esc":
+		if f.dirty && !f.confirm {
+			f.confirm = true
+			return nil
+		}
+		return util.CmdHandler(messages.CloseConfigEditorMsg{})
+	case "tab", "down":
+		return f.setFocus(f.nextFocusable(f.focus, 1))
+	case "shift+tab", "up":
+		return f.setFocus(f.nextFocusable(f.focus, -1))
+	case "ctrl+s":
+		if len(f.errs) > 0 {
+			f.err = fmt.Errorf("fix %d problem(s) before saving", len(f.errs))
+			return nil
+		}
+		f.saving = true
+		f.err = nil
+		return commands.SaveConfig(f.edit)
+	case "ctrl+t":
+		return f.testConnection()
+	case "ctrl+d":
+		if row := f.focusedRow(); row != nil && row.remove != nil {
+			// 删除后聚焦到原位置的下一项
+			start := f.focus
+			for start > 0 && f.rows[start].kind != rowHeading {
+				start--
+			}
+			row.remove()
+			f.changed()
+			return f.setFocus(f.nextFocusable(min(start, len(f.rows))-1, 1))
+		}
+		return nil
+	}
+
+	row := f.focusedRow()
+	if row == nil {
+		return nil
+	}
+	switch row.kind {
+	case rowInput:
+		if key == "enter" {
+			return f.setFocus(f.nextFocusable(f.focus, 1))
+		}
+		var cmd tea.Cmd
+		f.input, cmd = f.input.Update(msg)
+		if f.input.Value() != row.get() {
+			row.set(f.input.Value())
+			f.changed()
+		}
+		return cmd
+	case rowChoice:
+		step := 0
+		switch key {
+		case "enter", "space", " ", "right":
+			step = 1
+		case "left":
+			step = len(row.options) - 1
+		}
+		if step > 0 {
+			index := indexOf(row.options, row.get())
+			row.set(row.options[(index+step)%len(row.options)])
+			f.changed()
+		}
+	case rowToggle:
+		if key == "enter" || key == "space" || key == " " {
+			if row.get() == "true" {
+				row.set("false")
+			} else {
+				row.set("true")
+			}
+			f.changed()
+		}
+	case rowAction:
+		if key == "enter" || key == "space" || key == " " {
+			row.action()
+			f.changed()
+			// 聚焦到新增项的第一个字段
+			return f.setFocus(f.nextFocusable(f.focus-1, 1))
+		}
+	}
+	return nil
+}
+
+// testConnection 使用当前编辑结果测试 hop 连接，hop 存在问题时不测试
+func (f *configFormCmp) testConnection() tea.Cmd {
+	if f.testing {
+		return nil
+	}
+	if f.built == nil {
+		f.err = fmt.Errorf("fix the problems below before testing the connection")
+		return nil
+	}
+	for _, err := range f.errs {
+		if strings.HasPrefix(err.Field, "ssh_hops") || strings.HasPrefix(err.Field, "hops") || strings.HasPrefix(err.Field, "hop_overrides") {
+			f.err = fmt.Errorf("fix the hop problems below before testing the connection")
+			return nil
+		}
+	}
+	f.testing = true
+	f.err = nil
+	f.status = "Testing connection…"
+	return commands.TestConnection(f.built)
+}
+
+// changed 编辑结果发生变化，重新生成行并校验
+func (f *configFormCmp) changed() {
+	f.dirty = true
+	f.status = ""
+	f.err = nil
+	f.rebuild()
+}
+
+// rebuild 根据 ConfigEdit 重新生成表单行并即时校验
+func (f *configFormCmp) rebuild() {
+	f.rows = f.buildRows()
+	f.built, f.errs = f.edit.Build()
+}
+
+func (f *configFormCmp) buildRows() []formRow {
+	edit := f.edit
+	var rows []formRow
+	text := func(label, field, placeholder string, value *string) formRow {
+		return formRow{
+			kind:        rowInput,
+			label:       label,
+			field:       field,
+			placeholder: placeholder,
+			get:         func() string { return *value },
+			set:         func(v string) { *value = v },
+		}
+	}
+
+	rows = append(rows, formRow{kind: rowHeading, label: "Config"})
+	if edit.IsNew() {
+		rows = append(rows, formRow{
+			kind:        rowInput,
+			label:       "File",
+			field:       "file",
+			placeholder: "team/staging (saved as .toml)",
+			get:         func() string { return f.fileName },
+			set: func(v string) {
+				f.fileName = v
+				edit.SetFileName(v)
+			},
+		})
+	}
+	rows = append(rows,
+		text("Name", "name", "shown in the config list", &edit.Name),
+		text("Local port", "local_http_port", "8080", &edit.LocalHttpPort),
+	)
+
+	namedHops := len(edit.NamedHops)
+	for i := range edit.Hops {
+		hop := &edit.Hops[i]
+		field := func(key string) string { return fmt.Sprintf("ssh_hops[%d].%s", i, key) }
+		remove := func() { edit.Hops = append(edit.Hops[:i], edit.Hops[i+1:]...) }
+
+		rows = append(rows, formRow{kind: rowHeading, label: fmt.Sprintf("Hop %d", i+namedHops+1)})
+		hopRows := []formRow{
+			text("Host", field("host"), "bastion.example.com", &hop.Host),
+			text("Port", field("port"), "22", &hop.Port),
+			text("User", field("user"), "deploy", &hop.User),
+			{
+				kind:    rowChoice,
+				label:   "Auth type",
+				field:   field("auth_type"),
+				options: authTypes,
+				get:     func() string { return hop.AuthType },
+				set:     func(v string) { hop.AuthType = v },
+			},
+		}
+		if hop.AuthType != "password" {
+			hopRows = append(hopRows, text("Key path", field("private_key_path"), "~/.ssh/id_ed25519", &hop.PrivateKeyPath))
+		}
+		if hop.AuthType != "privateKey" {
+			passphrase := text("Passphrase", field("passphrase"), "keyring:account, env:NAME or cmd:...", &hop.Passphrase)
+			passphrase.secret = true
+			hopRows = append(hopRows, passphrase)
+		}
+		for _, row := range hopRows {
+			row.remove = remove
+			rows = append(rows, row)
+		}
+	}
+	rows = append(rows, formRow{kind: rowAction, label: "+ Add hop", action: edit.AddHop})
+
+	for i := range edit.Services {
+		service := &edit.Services[i]
+		field := func(key string) string { return fmt.Sprintf("services[%d].%s", i, key) }
+		remove := func() { edit.Services = append(edit.Services[:i], edit.Services[i+1:]...) }
+
+		rows = append(rows, formRow{kind: rowHeading, label: fmt.Sprintf("Service %d", i+1)})
+		serviceRows := []formRow{
+			text("Subdomain", field("subdomain"), "api", &service.Subdomain),
+			text("Alias", field("alias"), "API", &service.Alias),
+			text("Host", field("host"), "localhost", &service.Host),
+			text("Port", field("port"), "8080", &service.Port),
+			{
+				kind:  rowToggle,
+				label: "TLS",
+				field: field("use_tls"),
+				get:   func() string { return fmt.Sprint(service.UseTLS) },
+				set:   func(v string) { service.UseTLS = v == "true" },
+			},
+		}
+		for _, row := range serviceRows {
+			row.remove = remove
+			rows = append(rows, row)
+		}
+	}
+	rows = append(rows, formRow{kind: rowAction, label: "+ Add service", action: edit.AddService})
+	return rows
+}
+
+func (f *configFormCmp) focusedRow() *formRow {
+	if f.focus < 0 || f.focus >= len(f.rows) {
+		return nil
+	}
+	return &f.rows[f.focus]
+}
+
+// nextFocusable 从 from 开始按 step 方向查找下一个可聚焦的行（循环）
+func (f *configFormCmp) nextFocusable(from, step int) int {
+	count := len(f.rows)
+	for i := 1; i <= count; i++ {
+		index := ((from+step*i)%count + count) % count
+		if f.rows[index].kind != rowHeading {
+			return index
+		}
+	}
+	return 0
+}
+
+// setFocus 聚焦到指定行，文本行使用共享的输入框编辑
+func (f *configFormCmp) setFocus(index int) tea.Cmd {
+	f.focus = index
+	f.input.Blur()
+	row := f.focusedRow()
+	if row == nil || row.kind != rowInput {
+		return nil
+	}
+	f.input.SetValue(row.get())
+	f.input.Placeholder = row.placeholder
+	f.input.EchoMode = textinput.EchoNormal
+	if row.secret && !secrets.IsReference(row.get()) {
+		f.input.EchoMode = textinput.EchoPassword
+	}
+	f.input.CursorEnd()
+	return f.input.Focus()
+}
+
+func (f *configFormCmp) View() string {
+	if f.edit == nil {
+		return ""
+	}
+
+	// 标题
+	title := "✎ Edit " + f.edit.Path
+	if f.edit.IsNew() {
+		title = "✎ New config"
+	}
+	header := []string{titleStyle.Render(util.TruncateString(title, max(f.width, 10)))}
+	if len(f.edit.NamedHops) > 0 {
+		header = append(header, labelStyle.Render(fmt.Sprintf("Also uses named hops from %s: %s", config_loader.HopLibraryFileName, strings.Join(f.edit.NamedHops, ", "))))
+	}
+	if f.edit.Extends != "" {
+		header = append(header, labelStyle.Render("Extends "+f.edit.Extends+" (inherited values are not shown)"))
+	}
+	header = append(header, "")
+
+	// 底部：校验结果、状态与快捷键
+	var footer []string
+	footer = append(footer, "")
+	if len(f.errs) == 0 {
+		footer = append(footer, okStyle.Render("✓ Valid"))
+	} else {
+		footer = append(footer, errorStyle.Render(fmt.Sprintf("%d problem(s):", len(f.errs))))
+		for i, err := range f.errs {
+			if i == maxErrorLines {
+				footer = append(footer, errorStyle.Render(fmt.Sprintf("  … and %d more", len(f.errs)-i)))
+				break
+			}
+			footer = append(footer, errorStyle.Render("  • "+util.TruncateString(err.Error(), max(f.width-4, 10))))
+		}
+	}
+	switch {
+	case f.err != nil:
+		footer = append(footer, errorStyle.Render(util.TruncateString("Error: "+f.err.Error(), max(f.width, 10))))
+	case f.saving:
+		footer = append(footer, labelStyle.Render("Saving…"))
+	case f.confirm:
+		footer = append(footer, errorStyle.Render("Unsaved changes, press esc again to discard"))
+	case f.status != "":
+		footer = append(footer, okStyle.Render(f.status))
+	}
+	footer = append(footer, labelStyle.Render("tab/↑↓ move · enter/space change · ctrl+d remove · ctrl+t test connection · ctrl+s save · esc back"))
+
+	// 表单行，超出高度时滚动以保持焦点可见
+	lines := f.renderRows()
+	visible := max(f.height-len(header)-len(footer), 3)
+	if f.focus < f.offset {
+		f.offset = f.focus
+	}
+	if f.focus >= f.offset+visible {
+		f.offset = f.focus - visible + 1
+	}
+	f.offset = max(min(f.offset, len(lines)-visible), 0)
+	end := min(f.offset+visible, len(lines))
+
+	parts := append(header, lines[f.offset:end]...)
+	parts = append(parts, footer...)
+	return lipgloss.NewStyle().
+		Width(f.width).
+		Height(f.height).
+		Render(strings.Join(parts, "\n"))
+}
+
+// renderRows 每行对应 rows 中的一项，下标一致
+func (f *configFormCmp) renderRows() []string {
+	// 只标记本文件中的字段，继承或 hops.toml 中的问题只在底部列出
+	file := filepath.Base(f.edit.Path)
+	fieldErrors := make(map[string]string)
+	for _, err := range f.errs {
+		if _, exists := fieldErrors[err.Field]; !exists && (err.File == "" || err.File == file) {
+			fieldErrors[err.Field] = err.Message
+		}
+	}
+
+	lines := make([]string, len(f.rows))
+	for i, row := range f.rows {
+		focused := i == f.focus
+		if row.kind == rowHeading {
+			lines[i] = headingStyle.Render(row.label)
+			continue
+		}
+		if row.kind == rowAction {
+			if focused {
+				lines[i] = focusStyle.Render("▸ " + row.label)
+			} else {
+				lines[i] = labelStyle.Render("  " + row.label)
+			}
+			continue
+		}
+
+		label := labelStyle.Render(fmt.Sprintf("  %-*s", labelWidth, row.label))
+		if focused {
+			label = focusStyle.Render(fmt.Sprintf("▸ %-*s", labelWidth, row.label))
+		}
+
+		var value string
+		switch row.kind {
+		case rowInput:
+			if focused {
+				value = f.input.View()
+			} else if row.get() == "" {
+				value = labelStyle.Render(row.placeholder)
+			} else if row.secret && !secrets.IsReference(row.get()) {
+				value = valueStyle.Render(strings.Repeat("•", len(row.get())))
+			} else {
+				value = valueStyle.Render(row.get())
+			}
+		case rowChoice:
+			value = valueStyle.Render("‹ " + row.get() + " ›")
+		case rowToggle:
+			if row.get() == "true" {
+				value = valueStyle.Render("[x]")
+			} else {
+				value = valueStyle.Render("[ ]")
+			}
+		}
+
+		line := label + value
+		if message, exists := fieldErrors[row.field]; exists {
+			remaining := f.width - lipgloss.Width(line) - 4
+			line += errorStyle.Render("  ✗ " + util.TruncateString(message, max(remaining, 10)))
+		}
+		lines[i] = line
+	}
+	return lines
+}
+
+func (f *configFormCmp) SetSize(width, height int) tea.Cmd {
+	f.width = width
+	f.height = height
+	f.input.SetWidth(max(width-labelWidth-4, 10))
+	return nil
+}
+
+func (f *configFormCmp) GetSize() (int, int) {
+	return f.width, f.height
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
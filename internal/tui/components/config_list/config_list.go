@@ -2,17 +2,32 @@ package config_list
 
 import (
 	"fmt"
+	"path"
+	"sort"
 	"ssh-messer/internal/config_loader"
 	"ssh-messer/internal/tui/commands"
 	"ssh-messer/internal/tui/components/core/layout"
 	"ssh-messer/internal/tui/messages"
+	"ssh-messer/internal/tui/styles"
 	"ssh-messer/internal/tui/util"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/v2/list"
 	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
 )
 
+// 选中无效配置时在列表下方展示的最大错误行数
+const maxErrorLines = 6
+
+var (
+	errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#EF4444"))
+	helpStyle  = lipgloss.NewStyle().Foreground(styles.Meta)
+)
+
+// 列表下方的快捷键提示
+const helpText = "enter connect · e edit · n new config · i import bundle · q quit"
+
 // // Item 实现 list.Item 接口
 type ConfigItem struct {
 	filename string
@@ -20,11 +35,18 @@ type ConfigItem struct {
 }
 
 func (i ConfigItem) Title() string {
-	if i.config.Name == nil {
-		return strings.ReplaceAll(i.filename, ".toml", "")
+	title := strings.ReplaceAll(path.Base(i.filename), ".toml", "")
+	if i.config.Name != nil {
+		title = *i.config.Name
 	}
-
-	return *i.config.Name
+	// 子目录分组作为前缀
+	if i.config.Group != "" {
+		title = i.config.Group + " / " + title
+	}
+	if !i.config.IsValid() {
+		return "⚠️  " + title
+	}
+	return title
 }
 
 func (i ConfigItem) Description() string {
@@ -32,6 +54,15 @@ func (i ConfigItem) Description() string {
 		return ""
 	}
 
+	if !i.config.IsValid() {
+		return fmt.Sprintf("%d problem(s): %s", len(i.config.Errors), i.config.Errors[0].Error())
+	}
+
+	source := ""
+	if i.config.Source != "" {
+		source = " · " + i.config.Source
+	}
+
 	httpPort := "N/A"
 	if i.config.LocalHttpPort != nil {
 		httpPort = *i.config.LocalHttpPort
@@ -42,11 +73,12 @@ func (i ConfigItem) Description() string {
 		dockerPort = *i.config.LocalDockerPort
 	}
 
-	return fmt.Sprintf("%2d Hops🦘, %3d Services🔗, LocalPort🕸️: %4s, DockerPort🐳: %s",
+	return fmt.Sprintf("%2d Hops🦘, %3d Services🔗, LocalPort🕸️: %4s, DockerPort🐳: %s%s",
 		len(i.config.SSHHops),
 		len(i.config.SSHServices),
 		httpPort,
-		dockerPort)
+		dockerPort,
+		source)
 }
 
 func (c ConfigItem) FilterValue() string {
@@ -94,7 +126,8 @@ func (c *configListCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		}
 
 		var configItems []list.Item
-		for configName, config := range msg.Configs {
+		for _, configName := range sortedConfigNames(msg.Configs) {
+			config := msg.Configs[configName]
 			item := ConfigItem{
 				filename: configName,
 				config:   config,
@@ -105,9 +138,24 @@ func (c *configListCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		c.list.SetItems(configItems)
 		return c, nil
 	case tea.KeyMsg:
-		if msg.String() == "enter" {
+		switch msg.String() {
+		case "n":
+			return c, util.CmdHandler(messages.EditConfigMsg{})
+		case "i":
+			return c, util.CmdHandler(messages.OpenBundleImportMsg{})
+		case "e":
+			// 无效配置也可以编辑，用于修复问题
+			if item, ok := c.list.SelectedItem().(ConfigItem); ok {
+				return c, util.CmdHandler(messages.EditConfigMsg{ConfigName: item.filename})
+			}
+			return c, nil
+		case "enter":
 			selectedItem := c.list.SelectedItem()
 			if item, ok := selectedItem.(ConfigItem); ok {
+				if !item.config.IsValid() {
+					// 无效配置不能连接，错误已展示在列表下方
+					return c, nil
+				}
 				return c, util.CmdHandler(messages.ConfigSelectedMsg{
 					ConfigName: item.filename,
 				})
@@ -121,15 +169,43 @@ func (c *configListCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 }
 
 func (c *configListCmp) View() string {
-	return c.list.View()
+	help := helpStyle.Render(helpText)
+	item, ok := c.list.SelectedItem().(ConfigItem)
+	if !ok || item.config.IsValid() {
+		return lipgloss.JoinVertical(lipgloss.Left, c.list.View(), help)
+	}
+
+	// 展示选中配置的全部问题（超出部分省略）
+	lines := []string{errorStyle.Render(fmt.Sprintf("%s cannot be used:", item.filename))}
+	for i, err := range item.config.Errors {
+		if i == maxErrorLines-1 && len(item.config.Errors) > maxErrorLines {
+			lines = append(lines, errorStyle.Render(fmt.Sprintf("  … and %d more", len(item.config.Errors)-i)))
+			break
+		}
+		lines = append(lines, errorStyle.Render("  • "+util.TruncateString(err.Error(), max(c.width-4, 10))))
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, c.list.View(), help, strings.Join(lines, "\n"))
 }
 
 func (c *configListCmp) SetSize(width, height int) tea.Cmd {
+	c.width = width
+	c.height = height
 	c.list.SetWidth(width)
-	c.list.SetHeight(height)
+	// 为快捷键提示与错误列表预留空间
+	c.list.SetHeight(max(height-maxErrorLines-2, 0))
 	return nil
 }
 
+// sortedConfigNames 按文件名排序，保证列表顺序稳定
+func sortedConfigNames(configs map[string]*config_loader.TomlConfig) []string {
+	names := make([]string, 0, len(configs))
+	for name := range configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 func (c *configListCmp) GetSize() (int, int) {
 	return c.width, c.height
 }
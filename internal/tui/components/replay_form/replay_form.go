@@ -0,0 +1,294 @@
+package replay_form
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"ssh-messer/internal/ssh_proxy"
+	"ssh-messer/internal/tui/components/core/layout"
+	"ssh-messer/internal/tui/messages"
+	"ssh-messer/internal/tui/styles"
+	"ssh-messer/internal/tui/util"
+
+	"github.com/charmbracelet/bubbles/v2/textarea"
+	"github.com/charmbracelet/bubbles/v2/textinput"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// 表单字段
+const (
+	fieldMethod = iota
+	fieldURL
+	fieldHeaders
+	fieldBody
+	fieldCount
+)
+
+var (
+	titleStyle = lipgloss.NewStyle().Foreground(styles.NeonCyan).Bold(true)
+	labelStyle = lipgloss.NewStyle().Foreground(styles.Meta)
+	focusStyle = lipgloss.NewStyle().Foreground(styles.Primary).Bold(true)
+	warnStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("#F59E0B"))
+	errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#EF4444"))
+)
+
+// ReplayFormCmp 请求重放编辑表单组件接口
+type ReplayFormCmp interface {
+	util.Model
+	layout.Sizeable
+	Open(event ssh_proxy.ServiceProxyLogEvent) tea.Cmd
+}
+
+// replayFormCmp 请求重放编辑表单组件实现
+type replayFormCmp struct {
+	width, height int
+	replayOf      string
+	focus         int
+	method        textinput.Model
+	url           textinput.Model
+	headers       *textarea.Model
+	body          *textarea.Model
+	truncated     string // 被截断的捕获 body，body 未修改时发送需要确认
+	confirmed     bool   // 用户已确认发送截断的 body
+	err           error
+}
+
+// New 创建请求重放编辑表单
+func New() ReplayFormCmp {
+	method := textinput.New()
+	method.Prompt = ""
+	method.Placeholder = "GET"
+
+	target := textinput.New()
+	target.Prompt = ""
+	target.Placeholder = "http://api.localhost:8080/path"
+
+	headers := textarea.New()
+	headers.ShowLineNumbers = false
+	headers.Placeholder = "Header-Name: value"
+
+	body := textarea.New()
+	body.ShowLineNumbers = false
+
+	return &replayFormCmp{
+		method:  method,
+		url:     target,
+		headers: headers,
+		body:    body,
+	}
+}
+
+func (f *replayFormCmp) Init() tea.Cmd {
+	return nil
+}
+
+// Open 使用捕获的请求填充表单
+func (f *replayFormCmp) Open(event ssh_proxy.ServiceProxyLogEvent) tea.Cmd {
+	replay := ssh_proxy.NewReplayRequest(event.RequestID, event.Capture.Request)
+
+	f.replayOf = replay.ReplayOf
+	f.confirmed = false
+	f.err = nil
+	f.method.SetValue(replay.Method)
+	f.url.SetValue(replay.URL)
+	f.headers.SetValue(formatHeaderLines(replay.Header))
+	f.body.SetValue(string(replay.Body))
+	// 与 textarea 中的值比较，避免换行等规范化导致误判为已修改
+	f.truncated = ""
+	if replay.BodyTruncated {
+		f.truncated = f.body.Value()
+	}
+	return f.setFocus(fieldMethod)
+}
+
+func (f *replayFormCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			return f, util.CmdHandler(messages.CloseReplayFormMsg{})
+		case "tab":
+			return f, f.setFocus((f.focus + 1) % fieldCount)
+		case "shift+tab":
+			return f, f.setFocus((f.focus + fieldCount - 1) % fieldCount)
+		case "ctrl+s":
+			replay, err := f.buildReplayRequest()
+			if err != nil {
+				f.err = err
+				return f, nil
+			}
+			if replay.BodyTruncated {
+				if !f.confirmed {
+					f.confirmed = true
+					f.err = fmt.Errorf("body is truncated, press ctrl+s again to send it anyway")
+					return f, nil
+				}
+				replay.BodyTruncated = false
+			}
+			return f, tea.Batch(
+				util.CmdHandler(messages.CloseReplayFormMsg{}),
+				util.CmdHandler(messages.ReplayRequestMsg{Request: replay}),
+			)
+		}
+	}
+
+	var cmd tea.Cmd
+	switch f.focus {
+	case fieldMethod:
+		f.method, cmd = f.method.Update(msg)
+	case fieldURL:
+		f.url, cmd = f.url.Update(msg)
+	case fieldHeaders:
+		f.headers, cmd = f.headers.Update(msg)
+	case fieldBody:
+		f.body, cmd = f.body.Update(msg)
+	}
+	return f, cmd
+}
+
+func (f *replayFormCmp) View() string {
+	label := func(field int, text string) string {
+		if f.focus == field {
+			return focusStyle.Render("▸ " + text)
+		}
+		return labelStyle.Render("  " + text)
+	}
+
+	parts := []string{
+		titleStyle.Render("↻ Replay request"),
+		"",
+		label(fieldMethod, "Method"),
+		"  " + f.method.View(),
+		label(fieldURL, "URL"),
+		"  " + f.url.View(),
+		label(fieldHeaders, "Headers"),
+		f.headers.View(),
+		label(fieldBody, "Body"),
+		f.body.View(),
+		"",
+	}
+	if f.bodyTruncated() {
+		parts = append(parts, warnStyle.Render("⚠️  Body truncated: the capture holds only the beginning of the original body."))
+	}
+	if f.err != nil {
+		parts = append(parts, errorStyle.Render("Error: "+f.err.Error()))
+	}
+	parts = append(parts, labelStyle.Render("tab next field · ctrl+s send · esc cancel"))
+
+	return lipgloss.NewStyle().
+		Width(f.width).
+		Height(f.height).
+		Render(strings.Join(parts, "\n"))
+}
+
+func (f *replayFormCmp) SetSize(width, height int) tea.Cmd {
+	f.width = width
+	f.height = height
+
+	inputWidth := max(width-4, 10)
+	f.method.SetWidth(inputWidth)
+	f.url.SetWidth(inputWidth)
+	f.headers.SetWidth(inputWidth)
+	f.body.SetWidth(inputWidth)
+
+	// 标题、标签、提示等固定行占用约 12 行，其余高度分给 headers 与 body
+	textHeight := max((height-12)/2, 3)
+	f.headers.SetHeight(textHeight)
+	f.body.SetHeight(textHeight)
+	return nil
+}
+
+func (f *replayFormCmp) GetSize() (int, int) {
+	return f.width, f.height
+}
+
+// setFocus 切换获得焦点的字段
+func (f *replayFormCmp) setFocus(field int) tea.Cmd {
+	f.focus = field
+	f.method.Blur()
+	f.url.Blur()
+	f.headers.Blur()
+	f.body.Blur()
+
+	switch field {
+	case fieldMethod:
+		return f.method.Focus()
+	case fieldURL:
+		return f.url.Focus()
+	case fieldHeaders:
+		return f.headers.Focus()
+	default:
+		return f.body.Focus()
+	}
+}
+
+// buildReplayRequest 校验并构建重放请求
+func (f *replayFormCmp) buildReplayRequest() (ssh_proxy.ReplayRequest, error) {
+	method := strings.ToUpper(strings.TrimSpace(f.method.Value()))
+	if method == "" {
+		return ssh_proxy.ReplayRequest{}, fmt.Errorf("method is required")
+	}
+
+	target := strings.TrimSpace(f.url.Value())
+	parsed, err := url.Parse(target)
+	if err != nil || parsed.Host == "" {
+		return ssh_proxy.ReplayRequest{}, fmt.Errorf("URL must be absolute, e.g. http://api.localhost:8080/path")
+	}
+
+	header, err := parseHeaderLines(f.headers.Value())
+	if err != nil {
+		return ssh_proxy.ReplayRequest{}, err
+	}
+
+	return ssh_proxy.ReplayRequest{
+		ReplayOf:      f.replayOf,
+		Method:        method,
+		URL:           target,
+		Header:        header,
+		Body:          []byte(f.body.Value()),
+		BodyTruncated: f.bodyTruncated(),
+	}, nil
+}
+
+// bodyTruncated body 仍是被截断的捕获内容（用户修改后视为新的 body）
+func (f *replayFormCmp) bodyTruncated() bool {
+	return f.truncated != "" && f.body.Value() == f.truncated
+}
+
+// formatHeaderLines 将 header 转换为 "Name: value" 多行文本
+func formatHeaderLines(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		for _, value := range header[name] {
+			lines = append(lines, name+": "+value)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseHeaderLines 解析 "Name: value" 多行文本，空行会被忽略
+func parseHeaderLines(text string) (http.Header, error) {
+	header := http.Header{}
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid header on line %d: %q", i+1, line)
+		}
+		header.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	return header, nil
+}
@@ -0,0 +1,122 @@
+package secret_prompt
+
+import (
+	"fmt"
+	"strings"
+
+	"ssh-messer/internal/tui/components/core/layout"
+	"ssh-messer/internal/tui/messages"
+	"ssh-messer/internal/tui/styles"
+	"ssh-messer/internal/tui/util"
+
+	"github.com/charmbracelet/bubbles/v2/textinput"
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+var (
+	titleStyle = lipgloss.NewStyle().Foreground(styles.NeonCyan).Bold(true)
+	labelStyle = lipgloss.NewStyle().Foreground(styles.Meta)
+	errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#EF4444"))
+)
+
+// SecretPromptCmp 缺少钥匙串密钥时的输入提示组件接口
+type SecretPromptCmp interface {
+	util.Model
+	layout.Sizeable
+	Open(request messages.SecretRequiredMsg) tea.Cmd
+}
+
+// secretPromptCmp 缺少钥匙串密钥时的输入提示组件实现
+type secretPromptCmp struct {
+	width, height int
+	request       messages.SecretRequiredMsg
+	input         textinput.Model
+	err           error
+}
+
+// New 创建密钥输入提示
+func New() SecretPromptCmp {
+	input := textinput.New()
+	input.Prompt = ""
+	input.EchoMode = textinput.EchoPassword
+	input.EchoCharacter = '•'
+
+	return &secretPromptCmp{input: input}
+}
+
+func (s *secretPromptCmp) Init() tea.Cmd {
+	return nil
+}
+
+// Open 显示缺少的密钥并聚焦输入框
+func (s *secretPromptCmp) Open(request messages.SecretRequiredMsg) tea.Cmd {
+	s.request = request
+	s.err = nil
+	s.input.SetValue("")
+	return s.input.Focus()
+}
+
+func (s *secretPromptCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "esc":
+			s.input.SetValue("")
+			return s, util.CmdHandler(messages.CloseSecretPromptMsg{ConfigName: s.request.ConfigName})
+		case "enter", "ctrl+s":
+			if s.input.Value() == "" {
+				s.err = fmt.Errorf("secret must not be empty")
+				return s, nil
+			}
+			provided := messages.SecretProvidedMsg{
+				ConfigName: s.request.ConfigName,
+				Service:    s.request.Service,
+				Account:    s.request.Account,
+				Secret:     s.input.Value(),
+				Save:       msg.String() == "ctrl+s",
+			}
+			// 提交后立即清空，避免密钥留在组件中
+			s.input.SetValue("")
+			return s, tea.Batch(
+				util.CmdHandler(messages.CloseSecretPromptMsg{ConfigName: s.request.ConfigName}),
+				util.CmdHandler(provided),
+			)
+		}
+	}
+
+	var cmd tea.Cmd
+	s.input, cmd = s.input.Update(msg)
+	return s, cmd
+}
+
+func (s *secretPromptCmp) View() string {
+	parts := []string{
+		titleStyle.Render("🔑 Secret required"),
+		"",
+		labelStyle.Render(fmt.Sprintf("%s references keyring:%s/%s,", s.request.Field, s.request.Service, s.request.Account)),
+		labelStyle.Render(fmt.Sprintf("which was not found in %s.", s.request.Backend)),
+		"",
+		"  " + s.input.View(),
+		"",
+	}
+	if s.err != nil {
+		parts = append(parts, errorStyle.Render("Error: "+s.err.Error()))
+	}
+	parts = append(parts, labelStyle.Render("enter use for this session · ctrl+s save to keyring · esc cancel"))
+
+	return lipgloss.NewStyle().
+		Width(s.width).
+		Height(s.height).
+		Render(strings.Join(parts, "\n"))
+}
+
+func (s *secretPromptCmp) SetSize(width, height int) tea.Cmd {
+	s.width = width
+	s.height = height
+	s.input.SetWidth(max(width-4, 10))
+	return nil
+}
+
+func (s *secretPromptCmp) GetSize() (int, int) {
+	return s.width, s.height
+}
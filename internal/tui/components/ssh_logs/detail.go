@@ -0,0 +1,133 @@
+package ssh_logs
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"ssh-messer/internal/ssh_proxy"
+	"ssh-messer/internal/tui/styles"
+
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+const (
+	maxDetailBodyLines = 200 // 详情面板中 body 最多显示的行数
+)
+
+var (
+	detailTitleStyle = lipgloss.NewStyle().Foreground(styles.NeonCyan).Bold(true)
+	detailMetaStyle  = lipgloss.NewStyle().Foreground(styles.Meta)
+)
+
+// formatLogDetail 格式化选中日志的详情内容，original 为重放请求对应的原始请求
+func formatLogDetail(event, original *ssh_proxy.ServiceProxyLogEvent) string {
+	if event == nil {
+		return detailMetaStyle.Render("No request details for this line.")
+	}
+
+	var lines []string
+	lines = append(lines, detailTitleStyle.Render(fmt.Sprintf("[%s] %s %s", event.ServiceAlias, event.Method, event.URL)))
+
+	status := "pending..."
+	if event.StatusCode > 0 {
+		status = fmt.Sprintf("%d %s · %d bytes · %.3fs", event.StatusCode, http.StatusText(event.StatusCode), event.ResponseSize, event.Duration.Seconds())
+	}
+	lines = append(lines, detailMetaStyle.Render(fmt.Sprintf("%s · %s", event.Timestamp.Format("15:04:05.000"), status)))
+	if event.Protocol != "" {
+		lines = append(lines, detailMetaStyle.Render("Upstream protocol: "+event.Protocol))
+	}
+	if event.GRPCStatus != "" {
+		grpcLine := fmt.Sprintf("gRPC status: %s (%s)", ssh_proxy.GRPCStatusName(event.GRPCStatus), event.GRPCStatus)
+		if event.GRPCMessage != "" {
+			grpcLine += " · " + event.GRPCMessage
+		}
+		lines = append(lines, grpcLine)
+	}
+	if event.Mock {
+		lines = append(lines, detailMetaStyle.Render("Served by a local mock, not forwarded through SSH"))
+	}
+	if event.CacheStatus != "" {
+		lines = append(lines, detailMetaStyle.Render("Cache: "+event.CacheStatus))
+	}
+	if event.Faults != "" {
+		lines = append(lines, detailMetaStyle.Render("⚡ Injected: "+event.Faults))
+	}
+	if event.Backend != "" {
+		lines = append(lines, detailMetaStyle.Render("Backend: "+event.Backend))
+	}
+	if event.ErrorMessage != "" {
+		lines = append(lines, "Error: "+event.ErrorMessage)
+	}
+	if event.ReplayOf != "" {
+		if original != nil {
+			lines = append(lines, detailMetaStyle.Render(fmt.Sprintf("↻ Replay of %s %s at %s", original.Method, original.URL, original.Timestamp.Format("15:04:05.000"))))
+		} else {
+			lines = append(lines, detailMetaStyle.Render("↻ Replay of a request no longer in the logs"))
+		}
+	}
+
+	capture := event.Capture
+	if capture == nil {
+		lines = append(lines, "", detailMetaStyle.Render("Capture is disabled. Set [proxy.capture] enabled = true to record headers and bodies."))
+		return strings.Join(lines, "\n")
+	}
+
+	lines = append(lines, "", detailTitleStyle.Render("▶ Request"))
+	lines = append(lines, fmt.Sprintf("%s %s %s", capture.Request.Method, capture.Request.URL, capture.Request.Proto))
+	lines = append(lines, formatHeaders(capture.Request.Header)...)
+	lines = append(lines, formatBody(capture.Request.Body, capture.Request.BodySize, capture.Request.BodyTruncated)...)
+
+	lines = append(lines, "", detailTitleStyle.Render("◀ Response"))
+	if capture.Response == nil {
+		lines = append(lines, detailMetaStyle.Render("(no response)"))
+		return strings.Join(lines, "\n")
+	}
+	response := capture.Response
+	lines = append(lines, fmt.Sprintf("%s %d %s", response.Proto, response.StatusCode, http.StatusText(response.StatusCode)))
+	lines = append(lines, formatHeaders(response.Header)...)
+	lines = append(lines, formatBody(response.DecodedBody(), response.BodySize, response.BodyTruncated)...)
+
+	return strings.Join(lines, "\n")
+}
+
+// formatHeaders 按名称排序输出 header
+func formatHeaders(header http.Header) []string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		for _, value := range header[name] {
+			lines = append(lines, detailMetaStyle.Render(name+":")+" "+value)
+		}
+	}
+	return lines
+}
+
+// formatBody 输出 body，二进制内容只显示大小
+func formatBody(body []byte, size int64, truncated bool) []string {
+	if size == 0 {
+		return nil
+	}
+
+	lines := []string{""}
+	if !utf8.Valid(body) {
+		return append(lines, detailMetaStyle.Render(fmt.Sprintf("<binary body, %d bytes>", size)))
+	}
+
+	bodyLines := strings.Split(strings.TrimRight(string(body), "\n"), "\n")
+	if len(bodyLines) > maxDetailBodyLines {
+		bodyLines = append(bodyLines[:maxDetailBodyLines], "...")
+	}
+	lines = append(lines, bodyLines...)
+	if truncated {
+		lines = append(lines, detailMetaStyle.Render(fmt.Sprintf("<truncated: captured %d of %d bytes>", len(body), size)))
+	}
+	return lines
+}
@@ -2,22 +2,41 @@ package ssh_logs
 
 import (
 	"fmt"
+	"path/filepath"
 	"strings"
+	"time"
 
+	meta "ssh-messer"
 	"ssh-messer/internal/pubsub"
 	"ssh-messer/internal/ssh_proxy"
 	"ssh-messer/internal/tui/components/core/layout"
+	"ssh-messer/internal/tui/messages"
+	"ssh-messer/internal/tui/styles"
 	"ssh-messer/internal/tui/types"
 	"ssh-messer/internal/tui/util"
 
 	"github.com/charmbracelet/bubbles/v2/viewport"
 	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
 )
 
 const (
 	ellipsisLength = 3 // 省略号长度
+	noSelection    = -1
 )
 
+var (
+	selectedLineStyle = lipgloss.NewStyle().Reverse(true)
+	detailBorderStyle = lipgloss.NewStyle().Foreground(styles.Border)
+)
+
+// harExportedMsg HAR 导出完成消息
+type harExportedMsg struct {
+	path  string
+	count int
+	err   error
+}
+
 // LogsCmp SSH 日志组件接口
 type LogsCmp interface {
 	util.Model
@@ -31,17 +50,22 @@ type LogsCmp interface {
 type logEntry struct {
 	requestID string
 	text      string
+	event     *ssh_proxy.ServiceProxyLogEvent // 代理请求日志对应的事件，普通日志为 nil
 }
 
 // logsCmp SSH 日志组件实现
 type logsCmp struct {
-	width, height int
-	appState      *types.AppState
-	logs          []logEntry
-	maxLogs       int
-	viewport      viewport.Model
-	allLines      []string // 存储所有日志行，用于检查是否在底部
-	needsUpdate   bool     // 标记是否需要更新 allLines
+	width, height  int
+	appState       *types.AppState
+	logs           []logEntry
+	maxLogs        int
+	viewport       viewport.Model
+	allLines       []string // 存储所有日志行，用于检查是否在底部
+	entryStarts    []int    // 每条日志在 allLines 中的起始行
+	needsUpdate    bool     // 标记是否需要更新 allLines
+	selected       int      // 选中的日志下标，noSelection 表示跟随最新日志
+	showDetail     bool     // 是否显示选中日志的详情面板
+	detailViewport viewport.Model
 }
 
 // New 创建新的日志组件
@@ -50,6 +74,7 @@ func New(appState *types.AppState) LogsCmp {
 		appState: appState,
 		logs:     make([]logEntry, 0),
 		maxLogs:  100, // 最多保留 100 条日志
+		selected: noSelection,
 	}
 }
 
@@ -67,6 +92,8 @@ func (l *logsCmp) Init() tea.Cmd {
 	l.viewport.MouseWheelEnabled = true
 	l.viewport.MouseWheelDelta = 3
 	l.viewport.SetContent("No logs yet...\nWaiting for SSH proxy activity...")
+	l.detailViewport = viewport.New(viewport.WithWidth(width), viewport.WithHeight(height/2))
+	l.detailViewport.MouseWheelEnabled = true
 	return nil
 }
 
@@ -75,20 +102,52 @@ func (l *logsCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		// 处理键盘事件：方向键、Page Up/Down、Home/End
+		// 处理键盘事件：方向键选择日志，Page Up/Down 滚动，Enter 查看详情
 		switch msg.String() {
 		case "up", "k":
-			l.viewport.ScrollUp(1)
+			l.moveSelection(-1)
 		case "down", "j":
-			l.viewport.ScrollDown(1)
+			l.moveSelection(1)
 		case "pgup":
-			l.viewport.PageUp()
+			if l.showDetail {
+				l.detailViewport.PageUp()
+			} else {
+				l.viewport.PageUp()
+			}
 		case "pgdown":
-			l.viewport.PageDown()
+			if l.showDetail {
+				l.detailViewport.PageDown()
+			} else {
+				l.viewport.PageDown()
+			}
 		case "home":
-			l.viewport.GotoTop()
+			if len(l.logs) > 0 {
+				l.selected = 0
+				l.needsUpdate = true
+			}
 		case "end":
+			// 回到跟随最新日志的模式
+			l.selected = noSelection
+			l.needsUpdate = true
 			l.viewport.GotoBottom()
+		case "enter":
+			if len(l.logs) == 0 {
+				return l, nil
+			}
+			if l.selected == noSelection {
+				l.selected = len(l.logs) - 1
+			}
+			l.setShowDetail(!l.showDetail)
+		case "esc":
+			l.setShowDetail(false)
+		case "x":
+			return l, l.exportHAR()
+		case "r":
+			return l, l.openReplayForm()
+		case "f":
+			return l, util.CmdHandler(messages.ToggleFaultsMsg{})
+		case "p":
+			return l, util.CmdHandler(messages.PurgeCacheMsg{})
 		default:
 			// 将其他键盘事件传递给 viewport（viewport 有自己的 keymap）
 			updatedViewport, cmd := l.viewport.Update(msg)
@@ -97,6 +156,59 @@ func (l *logsCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		}
 		return l, nil
 
+	case messages.FaultsToggledMsg:
+		switch {
+		case msg.Err != nil:
+			return l, l.AddLog("⚡ Fault injection: " + msg.Err.Error())
+		case msg.Active:
+			return l, l.AddLog("⚡ Fault injection resumed")
+		default:
+			return l, l.AddLog("⚡ Fault injection paused")
+		}
+
+	case messages.CachePurgedMsg:
+		if msg.Err != nil {
+			return l, l.AddLog("🗑 Cache purge failed: " + msg.Err.Error())
+		}
+		return l, l.AddLog(fmt.Sprintf("🗑 Purged %d cached responses", msg.Count))
+
+	case messages.SecretRequiredMsg:
+		return l, l.AddLog(fmt.Sprintf("🔑 %s needs keyring:%s/%s, which is missing from %s", msg.Field, msg.Service, msg.Account, msg.Backend))
+
+	case messages.SecretStoredMsg:
+		switch {
+		case msg.Err != nil:
+			return l, l.AddLog(fmt.Sprintf("🔑 Could not save %s/%s to the keyring (using it for this session): %v", msg.Service, msg.Account, msg.Err))
+		case msg.Backend != "":
+			return l, l.AddLog(fmt.Sprintf("🔑 Saved %s/%s to %s, connecting...", msg.Service, msg.Account, msg.Backend))
+		default:
+			return l, l.AddLog(fmt.Sprintf("🔑 Using %s/%s for this session, connecting...", msg.Service, msg.Account))
+		}
+
+	case messages.ConfigAppliedMsg:
+		if msg.Err != nil {
+			return l, l.AddLog("🔄 Config reload: " + msg.Err.Error())
+		}
+		return l, l.AddLog(fmt.Sprintf("🔄 Reloaded services from %s", msg.ConfigName))
+
+	case messages.ConfigHopsChangedMsg:
+		return l, l.AddLog(fmt.Sprintf("🦘 Hop settings in %s changed — press y to reconnect now, n to keep the current connection", msg.ConfigName))
+
+	case messages.ConfigHopsAnsweredMsg:
+		if msg.Reconnect {
+			return l, l.AddLog("🦘 Reconnecting with the new hop settings...")
+		}
+		return l, l.AddLog("🦘 Keeping the current connection with the previous hop settings")
+
+	case messages.ReplayFailedMsg:
+		return l, l.AddLog("↻ Replay failed: " + msg.Err.Error())
+
+	case harExportedMsg:
+		if msg.err != nil {
+			return l, l.AddLog("📦 HAR export failed: " + msg.err.Error())
+		}
+		return l, l.AddLog(fmt.Sprintf("📦 Exported %d captured requests to %s", msg.count, msg.path))
+
 	case tea.MouseMsg:
 		// 将鼠标事件传递给 viewport（viewport 会自动处理滚轮和拖动）
 		updatedViewport, cmd := l.viewport.Update(msg)
@@ -114,6 +226,7 @@ func (l *logsCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 		} else {
 			cmd = l.AddLogWithID(event.RequestID, logText)
 		}
+		l.attachEvent(event)
 		return l, cmd
 	}
 
@@ -144,13 +257,32 @@ func (l *logsCmp) View() string {
 		// 设置 viewport 内容
 		l.viewport.SetContent(content)
 
-		// 如果之前在底部，自动滚动到底部
-		if wasAtBottom {
-			l.viewport.GotoBottom()
+		// 跟随模式下如果之前在底部，自动滚动到底部；选择模式下保证选中行可见
+		if l.selected == noSelection {
+			if wasAtBottom {
+				l.viewport.GotoBottom()
+			}
+		} else {
+			l.ensureSelectedVisible()
 		}
+
+		if l.showDetail {
+			event := l.selectedEvent()
+			var original *ssh_proxy.ServiceProxyLogEvent
+			if event != nil && event.ReplayOf != "" {
+				original = l.findEvent(event.ReplayOf)
+			}
+			l.detailViewport.SetContent(formatLogDetail(event, original))
+		}
+	}
+
+	if !l.showDetail {
+		return l.viewport.View()
 	}
 
-	return l.viewport.View()
+	separator := detailBorderStyle.Render(util.TruncateString(
+		"── detail · esc close · pgup/pgdn scroll · r replay · x export HAR "+strings.Repeat("─", max(l.width, 0)), l.width))
+	return lipgloss.JoinVertical(lipgloss.Left, l.viewport.View(), separator, l.detailViewport.View())
 }
 
 // AddLog 添加日志（直接更新状态）
@@ -166,6 +298,9 @@ func (l *logsCmp) AddLogWithID(requestID string, log string) tea.Cmd {
 	})
 	if len(l.logs) > l.maxLogs {
 		l.logs = l.logs[1:] // 移除最早的日志
+		if l.selected != noSelection {
+			l.selected = max(l.selected-1, 0)
+		}
 	}
 
 	// 标记需要更新
@@ -206,13 +341,18 @@ func (l *logsCmp) UpdateLog(requestID string, log string) tea.Cmd {
 // updateAllLines 更新 allLines 字段，处理所有日志条目
 func (l *logsCmp) updateAllLines() {
 	l.allLines = make([]string, 0)
+	l.entryStarts = make([]int, 0, len(l.logs))
 	for i := 0; i < len(l.logs); i++ {
+		l.entryStarts = append(l.entryStarts, len(l.allLines))
 		log := l.logs[i].text
 		// 按换行符分割日志条目
 		lines := strings.Split(log, "\n")
 		for _, line := range lines {
 			// 截断过长的行以适应窗口宽度
 			line = util.TruncateString(line, l.width)
+			if i == l.selected {
+				line = selectedLineStyle.Render(line)
+			}
 			l.allLines = append(l.allLines, line)
 		}
 	}
@@ -221,13 +361,129 @@ func (l *logsCmp) updateAllLines() {
 func (l *logsCmp) SetSize(width, height int) tea.Cmd {
 	l.width = width
 	l.height = height
-	l.viewport.SetWidth(width)
-	l.viewport.SetHeight(height)
+	l.layoutViewports()
 	// 尺寸变化时需要重新计算
 	l.needsUpdate = true
 	return nil
 }
 
+// layoutViewports 根据是否显示详情面板分配列表与详情的高度
+func (l *logsCmp) layoutViewports() {
+	l.viewport.SetWidth(l.width)
+	l.detailViewport.SetWidth(l.width)
+	if !l.showDetail {
+		l.viewport.SetHeight(l.height)
+		return
+	}
+	listHeight := max(l.height/3, 1)
+	l.viewport.SetHeight(listHeight)
+	l.detailViewport.SetHeight(max(l.height-listHeight-1, 1)) // 1 行分隔线
+}
+
+// setShowDetail 打开或关闭详情面板
+func (l *logsCmp) setShowDetail(show bool) {
+	l.showDetail = show
+	l.layoutViewports()
+	l.detailViewport.GotoTop()
+	l.needsUpdate = true
+}
+
+// moveSelection 移动选中的日志，从跟随模式开始时选中最新一条
+func (l *logsCmp) moveSelection(delta int) {
+	if len(l.logs) == 0 {
+		return
+	}
+	if l.selected == noSelection {
+		l.selected = len(l.logs) - 1
+	} else {
+		l.selected = min(max(l.selected+delta, 0), len(l.logs)-1)
+	}
+	l.detailViewport.GotoTop()
+	l.needsUpdate = true
+}
+
+// ensureSelectedVisible 滚动列表使选中的日志可见
+func (l *logsCmp) ensureSelectedVisible() {
+	if l.selected < 0 || l.selected >= len(l.entryStarts) {
+		return
+	}
+	line := l.entryStarts[l.selected]
+	offset := l.viewport.YOffset()
+	height := l.viewport.Height()
+	if line < offset {
+		l.viewport.SetYOffset(line)
+	} else if line >= offset+height {
+		l.viewport.SetYOffset(line - height + 1)
+	}
+}
+
+// selectedEvent 返回选中日志对应的代理事件
+func (l *logsCmp) selectedEvent() *ssh_proxy.ServiceProxyLogEvent {
+	if l.selected < 0 || l.selected >= len(l.logs) {
+		return nil
+	}
+	return l.logs[l.selected].event
+}
+
+// findEvent 根据 RequestID 查找代理事件
+func (l *logsCmp) findEvent(requestID string) *ssh_proxy.ServiceProxyLogEvent {
+	for i := len(l.logs) - 1; i >= 0; i-- {
+		if l.logs[i].requestID == requestID {
+			return l.logs[i].event
+		}
+	}
+	return nil
+}
+
+// openReplayForm 为选中的请求打开重放表单，需要已捕获的请求内容
+func (l *logsCmp) openReplayForm() tea.Cmd {
+	event := l.selectedEvent()
+	if event == nil {
+		return nil
+	}
+	if event.Capture == nil {
+		return l.AddLog("↻ Replay needs the captured request, enable [proxy.capture] first")
+	}
+	return util.CmdHandler(messages.OpenReplayFormMsg{Event: *event})
+}
+
+// attachEvent 将代理事件关联到对应的日志条目
+func (l *logsCmp) attachEvent(event ssh_proxy.ServiceProxyLogEvent) {
+	for i := len(l.logs) - 1; i >= 0; i-- {
+		if l.logs[i].requestID == event.RequestID {
+			l.logs[i].event = &event
+			return
+		}
+	}
+}
+
+// exportHAR 将当前日志中捕获的请求导出为 HAR 文件（写入当前目录）
+func (l *logsCmp) exportHAR() tea.Cmd {
+	var exchanges []*ssh_proxy.CapturedExchange
+	for _, entry := range l.logs {
+		if entry.event != nil && entry.event.Capture != nil {
+			exchanges = append(exchanges, entry.event.Capture)
+		}
+	}
+
+	configName := "ssh-messer"
+	if l.appState != nil && l.appState.CurrentConfigName != "" {
+		configName = strings.TrimSuffix(l.appState.CurrentConfigName, filepath.Ext(l.appState.CurrentConfigName))
+	}
+
+	return func() tea.Msg {
+		if len(exchanges) == 0 {
+			return harExportedMsg{err: fmt.Errorf("no captured requests, enable [proxy.capture] first")}
+		}
+		path := fmt.Sprintf("ssh-messer-%s-%s.har", configName, time.Now().Format("20060102-150405"))
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		err := ssh_proxy.WriteHARFile(path, exchanges, meta.Version)
+		return harExportedMsg{path: path, count: len(exchanges), err: err}
+	}
+}
+
 // isAtBottom 检查当前滚动位置是否在最底端
 func (l *logsCmp) isAtBottom() bool {
 	if len(l.allLines) == 0 {
@@ -257,7 +513,20 @@ func (l *logsCmp) formatServiceProxyLog(event ssh_proxy.ServiceProxyLogEvent) st
 
 	// 基础格式：[ServiceAlias] Method URL -> StatusCode (ResponseSize bytes)
 	// 估算基础文本长度（不包括 URL）
-	baseText := fmt.Sprintf("[%s] %s ", event.ServiceAlias, event.Method)
+	var markers string
+	if event.ReplayOf != "" {
+		markers += "↻ "
+	}
+	if event.Faults != "" {
+		markers += "⚡ "
+	}
+	if event.Mock {
+		markers += "[mock] "
+	}
+	if event.CacheStatus == ssh_proxy.CacheStatusHit || event.CacheStatus == ssh_proxy.CacheStatusRevalidated {
+		markers += "[cache] "
+	}
+	baseText := fmt.Sprintf("[%s] %s%s ", event.ServiceAlias, markers, event.Method)
 	baseTextLen := len([]rune(baseText))
 
 	// 根据状态码格式化后缀
@@ -269,6 +538,12 @@ func (l *logsCmp) formatServiceProxyLog(event ssh_proxy.ServiceProxyLogEvent) st
 		// 格式化用时信息，单位是秒（s），保留3位小数
 		durationSec := event.Duration.Seconds()
 		suffixText = fmt.Sprintf(" <> %d (%d bytes) %.3fs", event.StatusCode, event.ResponseSize, durationSec)
+		if event.GRPCStatus != "" {
+			suffixText += " grpc=" + ssh_proxy.GRPCStatusName(event.GRPCStatus)
+		}
+		if event.Backend != "" {
+			suffixText += " @" + event.Backend
+		}
 	}
 	suffixTextLen := len([]rune(suffixText))
 
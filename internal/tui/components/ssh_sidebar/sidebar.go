@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"ssh-messer/internal/config_loader"
+	"ssh-messer/internal/pubsub"
 	"ssh-messer/internal/ssh_proxy"
 	"ssh-messer/internal/tui/components/core/layout"
 	"ssh-messer/internal/tui/styles"
@@ -34,11 +35,13 @@ type SidebarCmp interface {
 type sidebarCmp struct {
 	width, height int
 	appState      *types.AppState
+	serviceHealth map[string]ssh_proxy.ServiceHealthEvent // key: configName + "/" + serviceName
 }
 
 func New(appState *types.AppState) SidebarCmp {
 	return &sidebarCmp{
-		appState: appState,
+		appState:      appState,
+		serviceHealth: make(map[string]ssh_proxy.ServiceHealthEvent),
 	}
 }
 
@@ -47,6 +50,17 @@ func (s *sidebarCmp) Init() tea.Cmd {
 }
 
 func (s *sidebarCmp) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case pubsub.Event[ssh_proxy.ServiceHealthEvent]:
+		event := msg.Payload
+		key := event.ConfigName + "/" + event.ServiceName
+		if msg.Type == pubsub.DeletedEvent {
+			// 服务被移除、不再配置健康检查或代理已停止
+			delete(s.serviceHealth, key)
+		} else {
+			s.serviceHealth[key] = event
+		}
+	}
 	return s, nil
 }
 
@@ -162,7 +176,7 @@ func (s *sidebarCmp) generateSSHHopsPart() []string {
 		}
 	}
 
-	status := proxy.Status
+	status := proxy.GetStatus()
 	if status.IsConnected {
 		if status.IsChecking {
 			hopLines = append(hopLines, "\n\n🟢 Connected 👀")
@@ -200,9 +214,17 @@ func (s *sidebarCmp) generateServiceLinks(config *config_loader.TomlConfig) []st
 		return links
 	}
 
-	// 遍历所有服务，收集有 pages 配置的服务
+	// 遍历所有服务，收集有 pages 配置的服务；没有 pages 但有健康检查结果的服务只显示名称与状态
 	for _, service := range config.SSHServices {
+		serviceName := ssh_proxy.ServiceDisplayName(&service)
+		healthIndicator := s.healthIndicator(serviceName)
 		if len(service.Pages) == 0 {
+			if healthIndicator != "" {
+				links = append(links, "")
+				links = append(links, lipgloss.NewStyle().
+					Foreground(styles.Text).
+					Render(util.TruncateString("⚙ "+serviceName+healthIndicator, max(s.width-4, 4))))
+			}
 			continue
 		}
 
@@ -213,7 +235,7 @@ func (s *sidebarCmp) generateServiceLinks(config *config_loader.TomlConfig) []st
 			}
 
 			// 格式化链接显示，适配侧边栏宽度
-			linkText := fmt.Sprintf("🔗 %s", *page.Name)
+			linkText := fmt.Sprintf("🔗 %s%s", *page.Name, healthIndicator)
 			linkURL := *page.URL
 
 			// 如果链接文本太长，截断
@@ -244,3 +266,15 @@ func (s *sidebarCmp) generateServiceLinks(config *config_loader.TomlConfig) []st
 
 	return links
 }
+
+// healthIndicator 服务健康状态标记，未配置健康检查的服务不显示
+func (s *sidebarCmp) healthIndicator(serviceName string) string {
+	event, ok := s.serviceHealth[s.appState.CurrentConfigName+"/"+serviceName]
+	if !ok {
+		return ""
+	}
+	if event.Health == ssh_proxy.ServiceHealthHealthy {
+		return " 🟢"
+	}
+	return " 🔴"
+}
@@ -62,7 +62,7 @@ func (s *statusBarCmp) View() string {
 		return "SSH Proxy not initialized"
 	}
 
-	status := proxy.Status
+	status := proxy.GetStatus()
 	var statusText string
 
 	if status.CurrentInfo != "" {
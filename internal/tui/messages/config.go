@@ -15,3 +15,59 @@ type ConfigLoadedMsg = LoadConfigsMsg
 type ConfigSelectedMsg struct {
 	ConfigName string
 }
+
+// ConfigHopsChangedMsg 当前连接的 hop 配置在文件中发生变化，等待用户确认是否重连
+type ConfigHopsChangedMsg struct {
+	ConfigName string
+}
+
+// ConfigHopsAnsweredMsg 用户对重连提示的回答
+type ConfigHopsAnsweredMsg struct {
+	ConfigName string
+	Reconnect  bool
+}
+
+// ConfigAppliedMsg 配置文件变化后热更新 services 的结果
+type ConfigAppliedMsg struct {
+	ConfigName string
+	Err        error
+}
+
+// EditConfigMsg 打开配置编辑页面，ConfigName 为空时新建配置
+type EditConfigMsg struct {
+	ConfigName string
+}
+
+// CloseConfigEditorMsg 关闭配置编辑页面，返回欢迎页
+type CloseConfigEditorMsg struct{}
+
+// ConfigSavedMsg 配置编辑页面保存的结果
+type ConfigSavedMsg struct {
+	Path string
+	Err  error
+}
+
+// ConnectionTestedMsg 配置编辑页面测试连接的结果
+type ConnectionTestedMsg struct {
+	Hops int
+	Err  error
+}
+
+// OpenBundleImportMsg 在欢迎页打开加密包导入流程
+type OpenBundleImportMsg struct{}
+
+// CloseBundleImportMsg 关闭加密包导入流程
+type CloseBundleImportMsg struct{}
+
+// BundleDecryptedMsg 加密包解密与校验的结果，Plan 用于预览与确认安装
+type BundleDecryptedMsg struct {
+	Plan *config_loader.ImportPlan
+	Err  error
+}
+
+// BundleInstalledMsg 加密包安装结果
+type BundleInstalledMsg struct {
+	Count int
+	Dir   string
+	Err   error
+}
@@ -5,6 +5,8 @@ type PageID string
 const (
 	WelcomePageID   PageID = "welcome"
 	SSHMesserPageID PageID = "ssh_messer"
+	// ConfigEditorPageID 配置编辑页面
+	ConfigEditorPageID PageID = "config_editor"
 )
 
 type PageChangeMsg struct {
@@ -7,3 +7,71 @@ type SSHStatusMsg struct {
 	ConfigName string
 	Status     ssh_proxy.SSHProxyStatus
 }
+
+// OpenReplayFormMsg 打开请求重放编辑表单
+type OpenReplayFormMsg struct {
+	Event ssh_proxy.ServiceProxyLogEvent
+}
+
+// CloseReplayFormMsg 关闭请求重放编辑表单
+type CloseReplayFormMsg struct{}
+
+// ReplayRequestMsg 提交重放请求
+type ReplayRequestMsg struct {
+	Request ssh_proxy.ReplayRequest
+}
+
+// ReplayFailedMsg 重放请求未能发出（代理未运行、URL 无效等）
+type ReplayFailedMsg struct {
+	Err error
+}
+
+// ToggleFaultsMsg 暂停或恢复故障注入
+type ToggleFaultsMsg struct{}
+
+// FaultsToggledMsg 故障注入切换结果
+type FaultsToggledMsg struct {
+	Active bool
+	Err    error
+}
+
+// PurgeCacheMsg 清空当前配置所有服务的本地缓存
+type PurgeCacheMsg struct{}
+
+// CachePurgedMsg 清空缓存的结果
+type CachePurgedMsg struct {
+	Count int
+	Err   error
+}
+
+// SecretRequiredMsg 连接时引用的钥匙串密钥不存在，需要用户输入
+type SecretRequiredMsg struct {
+	ConfigName string
+	Field      string // 引用该密钥的字段，如 "ssh_hops[0].passphrase"
+	Service    string
+	Account    string
+	Backend    string // 钥匙串名称
+}
+
+// CloseSecretPromptMsg 关闭密钥输入提示
+type CloseSecretPromptMsg struct {
+	ConfigName string
+}
+
+// SecretProvidedMsg 用户输入了密钥，Save 为 true 时保存到钥匙串
+type SecretProvidedMsg struct {
+	ConfigName string
+	Service    string
+	Account    string
+	Secret     string
+	Save       bool
+}
+
+// SecretStoredMsg 密钥已记住（并在需要时保存到钥匙串），可以重新连接
+type SecretStoredMsg struct {
+	ConfigName string
+	Service    string
+	Account    string
+	Backend    string // 保存到的钥匙串，仅在本次运行中记住时为空
+	Err        error  // 保存到钥匙串失败（密钥仍在本次运行中有效）
+}
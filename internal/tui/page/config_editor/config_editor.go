@@ -0,0 +1,112 @@
+package config_editor
+
+import (
+	"ssh-messer/internal/config_loader"
+	"ssh-messer/internal/tui/commands"
+	"ssh-messer/internal/tui/components/config_form"
+	"ssh-messer/internal/tui/messages"
+	"ssh-messer/internal/tui/types"
+	"ssh-messer/internal/tui/util"
+
+	tea "github.com/charmbracelet/bubbletea/v2"
+	"github.com/charmbracelet/lipgloss/v2"
+)
+
+// 为应用状态栏预留的高度
+const statusHeight = 1
+
+type ConfigEditorPage interface {
+	util.Model
+}
+
+type configEditorPage struct {
+	appState *types.AppState
+	uiState  *types.UIState
+	compForm config_form.ConfigFormCmp
+}
+
+func New(appState *types.AppState, uiState *types.UIState) ConfigEditorPage {
+	return &configEditorPage{
+		appState: appState,
+		uiState:  uiState,
+		compForm: config_form.New(),
+	}
+}
+
+func (p *configEditorPage) Init() tea.Cmd {
+	return p.compForm.Init()
+}
+
+func (p *configEditorPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		return p, p.compForm.SetSize(msg.Width, max(msg.Height-statusHeight, 0))
+
+	case messages.EditConfigMsg:
+		return p, p.open(msg.ConfigName)
+
+	case messages.CloseConfigEditorMsg:
+		return p, p.close()
+
+	case messages.ConfigSavedMsg:
+		p.updateForm(msg)
+		if msg.Err != nil {
+			return p, nil
+		}
+		// 配置目录的监听也会触发重新加载，这里立即刷新列表
+		return p, tea.Batch(
+			p.close(),
+			commands.LoadAllConfigs(),
+			util.ReportInfo("Saved "+msg.Path),
+		)
+	}
+
+	return p, p.updateForm(msg)
+}
+
+func (p *configEditorPage) View() string {
+	width, height := p.compForm.GetSize()
+	return lipgloss.NewStyle().
+		Width(width).
+		Height(height).
+		Render(p.compForm.View())
+}
+
+// open 打开配置编辑表单，configName 为空时在优先级最高的配置目录中新建
+func (p *configEditorPage) open(configName string) tea.Cmd {
+	var edit *config_loader.ConfigEdit
+	if configName == "" {
+		dir, err := config_loader.PrimaryConfigDir()
+		if err != nil {
+			return tea.Batch(p.close(), util.ReportError(err))
+		}
+		edit = config_loader.NewConfigEdit(dir)
+	} else {
+		config := p.appState.GetConfig(configName)
+		if config == nil {
+			return tea.Batch(p.close(), util.ReportWarn(configName+" no longer exists"))
+		}
+		var err error
+		edit, err = config_loader.LoadConfigEdit(config)
+		if err != nil {
+			return tea.Batch(p.close(), util.ReportError(err))
+		}
+	}
+
+	p.uiState.InputFocused = true
+	return p.compForm.Open(edit)
+}
+
+// close 返回欢迎页
+func (p *configEditorPage) close() tea.Cmd {
+	p.uiState.InputFocused = false
+	return util.CmdHandler(messages.PageChangeMsg{ID: messages.WelcomePageID})
+}
+
+func (p *configEditorPage) updateForm(msg tea.Msg) tea.Cmd {
+	updated, cmd := p.compForm.Update(msg)
+	if updatedForm, ok := updated.(config_form.ConfigFormCmp); ok {
+		p.compForm = updatedForm
+	}
+	return cmd
+}
@@ -3,9 +3,13 @@ package ssh_messer
 import (
 	"ssh-messer/internal/pubsub"
 	"ssh-messer/internal/ssh_proxy"
+	"ssh-messer/internal/tui/commands"
+	"ssh-messer/internal/tui/components/replay_form"
+	"ssh-messer/internal/tui/components/secret_prompt"
 	"ssh-messer/internal/tui/components/ssh_logs"
 	"ssh-messer/internal/tui/components/ssh_sidebar"
 	"ssh-messer/internal/tui/components/ssh_statusbar"
+	"ssh-messer/internal/tui/messages"
 	"ssh-messer/internal/tui/types"
 	"ssh-messer/internal/tui/util"
 
@@ -37,6 +41,13 @@ type sshMesserPage struct {
 	compStatusBar ssh_statusbar.StatusBarCmp
 	compSidebar   ssh_sidebar.SidebarCmp
 	compLogs      ssh_logs.LogsCmp
+	compReplay    replay_form.ReplayFormCmp
+	compSecret    secret_prompt.SecretPromptCmp
+
+	replayOpen bool // 重放表单打开时替代日志区域并接管键盘输入
+	secretOpen bool // 密钥输入提示打开时替代日志区域并接管键盘输入
+
+	pendingHopsReload string // hop 配置变化待确认的配置名，非空时 y/n 用于回答重连提示
 }
 
 func New(appState *types.AppState, uiState *types.UIState) SSHMesserPage {
@@ -49,6 +60,8 @@ func New(appState *types.AppState, uiState *types.UIState) SSHMesserPage {
 		compStatusBar: statusBar,
 		compSidebar:   ssh_sidebar.New(appState),
 		compLogs:      ssh_logs.New(appState),
+		compReplay:    replay_form.New(),
+		compSecret:    secret_prompt.New(),
 		compact:       false,
 	}
 }
@@ -58,6 +71,8 @@ func (p *sshMesserPage) Init() tea.Cmd {
 		p.compStatusBar.Init(),
 		p.compSidebar.Init(),
 		p.compLogs.Init(),
+		p.compReplay.Init(),
+		p.compSecret.Init(),
 	)
 }
 
@@ -82,7 +97,89 @@ func (p *sshMesserPage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 			logsWidth = msg.Width - SideBarWidth
 		}
 
-		return p, tea.Batch(p.compLogs.SetSize(logsWidth, logsHeight), p.compSidebar.SetSize(sidebarWidth, sidebarHeight), p.compStatusBar.SetSize(statusBarWidth, statusBarHeight))
+		return p, tea.Batch(p.compLogs.SetSize(logsWidth, logsHeight), p.compReplay.SetSize(logsWidth, logsHeight), p.compSecret.SetSize(logsWidth, logsHeight), p.compSidebar.SetSize(sidebarWidth, sidebarHeight), p.compStatusBar.SetSize(statusBarWidth, statusBarHeight))
+
+	case messages.OpenReplayFormMsg:
+		p.replayOpen = true
+		p.uiState.InputFocused = true
+		return p, p.compReplay.Open(msg.Event)
+
+	case messages.CloseReplayFormMsg:
+		p.replayOpen = false
+		p.uiState.InputFocused = false
+		return p, nil
+
+	case messages.ReplayRequestMsg:
+		return p, commands.ReplayRequest(p.appState, msg.Request)
+
+	case messages.SecretRequiredMsg:
+		p.secretOpen = true
+		p.uiState.InputFocused = true
+		cmds = append(cmds, p.compSecret.Open(msg))
+		cmds = append(cmds, p.updateAllComponents(msg)...)
+
+	case messages.CloseSecretPromptMsg:
+		p.secretOpen = false
+		p.uiState.InputFocused = false
+		return p, nil
+
+	case messages.SecretProvidedMsg:
+		return p, commands.ProvideSecret(msg)
+
+	case messages.SecretStoredMsg:
+		// 密钥就绪后重新初始化连接；代理已在运行时是热更新 services 缺少密钥，重新应用 services 配置
+		if p.appState.GetSSHProxy(msg.ConfigName) != nil {
+			cmds = append(cmds, commands.ApplyServiceChanges(p.appState, msg.ConfigName))
+		} else {
+			cmds = append(cmds, commands.InitSSHProxy(p.appState, msg.ConfigName))
+		}
+		cmds = append(cmds, p.updateAllComponents(msg)...)
+
+	case messages.ToggleFaultsMsg:
+		return p, commands.ToggleFaults(p.appState)
+
+	case messages.PurgeCacheMsg:
+		return p, commands.PurgeCache(p.appState)
+
+	case messages.ConfigHopsChangedMsg:
+		p.pendingHopsReload = msg.ConfigName
+		cmds = append(cmds, p.updateAllComponents(msg)...)
+
+	case messages.ConfigHopsAnsweredMsg:
+		p.pendingHopsReload = ""
+		if msg.Reconnect {
+			cmds = append(cmds, commands.ReconnectWithHops(p.appState, msg.ConfigName))
+		}
+		cmds = append(cmds, p.updateAllComponents(msg)...)
+
+	case tea.KeyMsg:
+		// 密钥输入提示打开时键盘输入只交给提示
+		if p.secretOpen {
+			s, cmd := p.compSecret.Update(msg)
+			if updatedSecret, ok := s.(secret_prompt.SecretPromptCmp); ok {
+				p.compSecret = updatedSecret
+			}
+			return p, cmd
+		}
+		// 重放表单打开时键盘输入只交给表单
+		if p.replayOpen {
+			s, cmd := p.compReplay.Update(msg)
+			if updatedReplay, ok := s.(replay_form.ReplayFormCmp); ok {
+				p.compReplay = updatedReplay
+			}
+			return p, cmd
+		}
+		// 等待确认是否按新的 hop 配置重连
+		if p.pendingHopsReload != "" {
+			switch msg.String() {
+			case "y", "n":
+				return p, util.CmdHandler(messages.ConfigHopsAnsweredMsg{
+					ConfigName: p.pendingHopsReload,
+					Reconnect:  msg.String() == "y",
+				})
+			}
+		}
+		cmds = append(cmds, p.updateAllComponents(msg)...)
 
 	case pubsub.Event[ssh_proxy.SSHStatusUpdateEvent]:
 		// SSH 状态更新需要更新状态栏和侧边栏
@@ -120,11 +217,18 @@ func (p *sshMesserPage) View() string {
 	sidebarWidth, sidebarHeight := p.compSidebar.GetSize()
 	statusBarWidth, statusBarHeight := p.compStatusBar.GetSize()
 
+	logsView := p.compLogs.View()
+	if p.replayOpen {
+		logsView = p.compReplay.View()
+	}
+	if p.secretOpen {
+		logsView = p.compSecret.View()
+	}
 	logsComponent := lipgloss.NewStyle().
 		Width(logsWidth).
 		Height(logsHeight).
 		Align(lipgloss.Left, lipgloss.Top).
-		Render(p.compLogs.View())
+		Render(logsView)
 
 	var mainComponent string
 	if p.compact {
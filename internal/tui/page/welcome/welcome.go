@@ -3,8 +3,11 @@ package welcome
 import (
 	"fmt"
 	meta "ssh-messer"
+	"ssh-messer/internal/tui/commands"
 	app_logo "ssh-messer/internal/tui/components/animation"
+	"ssh-messer/internal/tui/components/bundle_import"
 	"ssh-messer/internal/tui/components/config_list"
+	"ssh-messer/internal/tui/messages"
 	"ssh-messer/internal/tui/styles"
 	"ssh-messer/internal/tui/types"
 	"ssh-messer/internal/tui/util"
@@ -26,6 +29,8 @@ type welcomePage struct {
 	uiState        *types.UIState
 	compLogo       app_logo.AppLogoCmp
 	compConfigList config_list.ConfigListCmp
+	compImport     bundle_import.BundleImportCmp
+	importOpen     bool
 }
 
 func New(appState *types.AppState, uiState *types.UIState) WelcomePage {
@@ -34,6 +39,7 @@ func New(appState *types.AppState, uiState *types.UIState) WelcomePage {
 		uiState:        uiState,
 		compLogo:       app_logo.NewLogo(),
 		compConfigList: config_list.New(),
+		compImport:     bundle_import.New(),
 	}
 }
 
@@ -51,7 +57,38 @@ func (p *welcomePage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 
 		return p, tea.Batch(p.compLogo.SetSize(msg.Width, msg.Height/2-copyrightCompHeight),
-			p.compConfigList.SetSize(msg.Width, msg.Height/2))
+			p.compConfigList.SetSize(msg.Width, msg.Height/2),
+			p.compImport.SetSize(min(msg.Width, 100), msg.Height/2))
+
+	case messages.OpenBundleImportMsg:
+		p.importOpen = true
+		p.uiState.InputFocused = true
+		return p, p.compImport.Open()
+
+	case messages.CloseBundleImportMsg:
+		p.importOpen = false
+		p.uiState.InputFocused = false
+		return p, nil
+
+	case messages.BundleInstalledMsg:
+		if msg.Err != nil {
+			return p, p.updateImport(msg)
+		}
+		p.importOpen = false
+		p.uiState.InputFocused = false
+		return p, tea.Batch(
+			commands.LoadAllConfigs(),
+			util.ReportInfo(fmt.Sprintf("Imported %d file(s) into %s", msg.Count, msg.Dir)),
+		)
+
+	case messages.BundleDecryptedMsg:
+		return p, p.updateImport(msg)
+
+	case tea.KeyMsg:
+		// 导入流程打开时按键只交给导入组件
+		if p.importOpen {
+			return p, p.updateImport(msg)
+		}
 	}
 
 	updated, cmd := p.compLogo.Update(msg)
@@ -66,9 +103,21 @@ func (p *welcomePage) Update(msg tea.Msg) (util.Model, tea.Cmd) {
 	}
 	cmds = append(cmds, cmd)
 
+	if p.importOpen {
+		cmds = append(cmds, p.updateImport(msg))
+	}
+
 	return p, tea.Batch(cmds...)
 }
 
+func (p *welcomePage) updateImport(msg tea.Msg) tea.Cmd {
+	updated, cmd := p.compImport.Update(msg)
+	if updatedImport, ok := updated.(bundle_import.BundleImportCmp); ok {
+		p.compImport = updatedImport
+	}
+	return cmd
+}
+
 func (p *welcomePage) View() string {
 	compConfigListWidth, compConfigListHeight := p.compConfigList.GetSize()
 	compLogoWidth, compLogoHeight := p.compLogo.GetSize()
@@ -78,10 +127,14 @@ func (p *welcomePage) View() string {
 		Height(compLogoHeight).
 		Align(lipgloss.Center, lipgloss.Center).Render(p.compLogo.View())
 
+	configListView := p.compConfigList.View()
+	if p.importOpen {
+		configListView = p.compImport.View()
+	}
 	configListComponent := lipgloss.NewStyle().
 		Width(compConfigListWidth).
 		Height(compConfigListHeight).
-		Align(lipgloss.Center, lipgloss.Center).Render(p.compConfigList.View())
+		Align(lipgloss.Center, lipgloss.Center).Render(configListView)
 
 	copyrightComponent := lipgloss.NewStyle().
 		Foreground(styles.Meta).
@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"ssh-messer/internal/config_loader"
 	"ssh-messer/internal/pubsub"
 	"ssh-messer/internal/ssh_proxy"
 
@@ -70,3 +71,27 @@ func (a *appModel) setupServiceProxyLogSubscriber() {
 		broker.Subscribe,
 	)
 }
+
+// setupServiceHealthSubscriber 设置服务健康检查订阅
+func (a *appModel) setupServiceHealthSubscriber() {
+	broker := ssh_proxy.GetServiceHealthBroker()
+	setupSubscriber(
+		a.eventsCtx,
+		a.serviceEventsWG,
+		a.events,
+		"service-health",
+		broker.Subscribe,
+	)
+}
+
+// setupConfigChangeSubscriber 设置配置文件变更订阅
+func (a *appModel) setupConfigChangeSubscriber() {
+	broker := config_loader.GetConfigChangeBroker()
+	setupSubscriber(
+		a.eventsCtx,
+		a.serviceEventsWG,
+		a.events,
+		"config-change",
+		broker.Subscribe,
+	)
+}
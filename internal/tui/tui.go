@@ -6,11 +6,13 @@ import (
 	"os"
 	"sync"
 
+	"ssh-messer/internal/config_loader"
 	"ssh-messer/internal/pubsub"
 	"ssh-messer/internal/ssh_proxy"
 	"ssh-messer/internal/tui/commands"
 	"ssh-messer/internal/tui/components/core/status"
 	"ssh-messer/internal/tui/messages"
+	"ssh-messer/internal/tui/page/config_editor"
 	"ssh-messer/internal/tui/page/ssh_messer"
 	"ssh-messer/internal/tui/page/welcome"
 	"ssh-messer/internal/tui/types"
@@ -96,12 +98,30 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.Err != nil {
 			return a, util.ReportError(msg.Err)
 		}
+		// 当前连接的配置发生变化时热更新 services，hop 变化需要用户确认后重连
+		cmds = append(cmds, a.handleCurrentConfigReload(msg.Configs))
 		// 更新应用状态
 		a.appState.SetConfigs(msg.Configs)
+		// 欢迎页不是当前页面时也需要刷新配置列表
+		if a.currentPage != messages.WelcomePageID {
+			if page, ok := a.pages[messages.WelcomePageID]; ok {
+				updated, cmd := page.Update(msg)
+				a.pages[messages.WelcomePageID] = updated
+				cmds = append(cmds, cmd)
+			}
+		}
 		// 消息需要传递到当前页面，所以继续执行，不 return
 	case messages.ConfigSelectedMsg:
 		model, cmd := a.handleConfigSelectedMsg(msg)
 		return model, cmd
+	case messages.EditConfigMsg:
+		// 切换到编辑页面后由页面加载配置
+		cmds = append(cmds, a.moveToPage(messages.ConfigEditorPageID))
+		page := a.pages[messages.ConfigEditorPageID]
+		updated, cmd := page.Update(msg)
+		a.pages[messages.ConfigEditorPageID] = updated
+		cmds = append(cmds, cmd)
+		return a, tea.Batch(cmds...)
 
 	// App error
 	case messages.AppErrMsg:
@@ -113,6 +133,10 @@ func (a *appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		model, cmd := a.handleSSHStatusUpdate(msg)
 		return model, cmd
 
+	// 配置目录中的文件变化，重新加载所有配置
+	case pubsub.Event[config_loader.ConfigChangeEvent]:
+		return a, commands.LoadAllConfigs()
+
 	// Service proxy log events via pubsub
 	case pubsub.Event[ssh_proxy.ServiceProxyLogEvent]:
 		// Forward to current page
@@ -189,7 +213,8 @@ func (a *appModel) handleWindowResize(width, height int) tea.Cmd {
 // handleKeyPressMsg processes keyboard input and routes to appropriate handlers.
 func (a *appModel) handleKeyPressMsg(msg tea.KeyMsg) tea.Cmd {
 	// Check this first as the user should be able to quit no matter what.
-	if key.Matches(msg, a.keyMap.Quit) {
+	// 输入框获得焦点时只有 ctrl+c 可以退出，"q" 作为普通输入
+	if key.Matches(msg, a.keyMap.Quit) && (!a.uiState.InputFocused || msg.String() == "ctrl+c") {
 		return tea.Quit
 	}
 
@@ -245,6 +270,44 @@ func (a *appModel) handleLoadConfigsMsg(msg messages.LoadConfigsMsg) (tea.Model,
 	return a, nil
 }
 
+// handleCurrentConfigReload 比较当前连接的配置在重新加载前后的差异
+func (a *appModel) handleCurrentConfigReload(configs map[string]*config_loader.TomlConfig) tea.Cmd {
+	configName := a.appState.CurrentConfigName
+	if configName == "" || a.appState.GetSSHProxy(configName) == nil {
+		return nil
+	}
+
+	newConfig, exists := configs[configName]
+	if !exists {
+		return util.CmdHandler(messages.ConfigAppliedMsg{
+			ConfigName: configName,
+			Err:        fmt.Errorf("%s was removed, keeping the running connection", configName),
+		})
+	}
+	if !newConfig.IsValid() {
+		// 无效配置不应用，当前连接继续使用之前的配置；列表中的条目带上新的校验错误，便于在欢迎页查看问题
+		if running := a.appState.GetConfig(configName); running != nil {
+			kept := *running
+			kept.Errors = newConfig.Errors
+			configs[configName] = &kept
+		}
+		return util.CmdHandler(messages.ConfigAppliedMsg{
+			ConfigName: configName,
+			Err:        fmt.Errorf("%d problem(s), keeping the running configuration: %v", len(newConfig.Errors), newConfig.Errors[0]),
+		})
+	}
+
+	diff := config_loader.Diff(a.appState.GetConfig(configName), newConfig)
+	var cmds []tea.Cmd
+	if diff.ServicesChanged {
+		cmds = append(cmds, commands.ApplyServiceChanges(a.appState, configName))
+	}
+	if diff.HopsChanged {
+		cmds = append(cmds, util.CmdHandler(messages.ConfigHopsChangedMsg{ConfigName: configName}))
+	}
+	return tea.Batch(cmds...)
+}
+
 // handleConfigSelectedMsg handles configuration selection messages
 func (a *appModel) handleConfigSelectedMsg(msg messages.ConfigSelectedMsg) (tea.Model, tea.Cmd) {
 	a.appState.CurrentConfigName = msg.ConfigName
@@ -313,8 +376,9 @@ func New() *appModel {
 		serviceEventsWG: &sync.WaitGroup{},
 
 		pages: map[messages.PageID]util.Model{
-			messages.WelcomePageID:   welcomePage,
-			messages.SSHMesserPageID: ssh_messer.New(appState, uiState),
+			messages.WelcomePageID:      welcomePage,
+			messages.SSHMesserPageID:    ssh_messer.New(appState, uiState),
+			messages.ConfigEditorPageID: config_editor.New(appState, uiState),
 		},
 	}
 
@@ -324,6 +388,13 @@ func New() *appModel {
 	// 设置 Service Proxy 日志订阅
 	model.setupServiceProxyLogSubscriber()
 
+	// 设置服务健康检查订阅
+	model.setupServiceHealthSubscriber()
+
+	// 监听所有配置来源，文件变化时重新加载
+	model.setupConfigChangeSubscriber()
+	go config_loader.WatchConfigSources(ctx, config_loader.DefaultWatchInterval)
+
 	return model
 }
 
@@ -4,6 +4,9 @@ package types
 type UIState struct {
 	Width  int
 	Height int
+
+	// InputFocused 为 true 时有输入框获得焦点，"q" 等单键快捷键不生效
+	InputFocused bool
 }
 
 // NewUIState 创建新的 UI 状态